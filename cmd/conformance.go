@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang-crud-clean-arch/config"
+	"golang-crud-clean-arch/internal/event"
+	"golang-crud-clean-arch/internal/event/conformance"
+	"golang-crud-clean-arch/internal/kafka"
+
+	"github.com/nats-io/nats.go/jetstream"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+const conformanceUsage = "usage: main conformance [-backend kafka|redis|nats|memory] [-timeout 30s] [-settle 0]"
+
+// settleDefaults adalah jeda default sebelum publish per backend; consumer group Kafka butuh
+// beberapa detik untuk join dan mendapat partition
+var settleDefaults = map[string]time.Duration{
+	"memory": 50 * time.Millisecond,
+	"redis":  200 * time.Millisecond,
+	"nats":   200 * time.Millisecond,
+	"kafka":  10 * time.Second,
+}
+
+// runConformance menjalankan conformance suite event.Bus terhadap backend sungguhan dan
+// mengembalikan exit code. Topic dan consumer group suite selalu baru (conformance-*).
+func runConformance(args []string) int {
+	fs := flag.NewFlagSet("conformance", flag.ContinueOnError)
+	backend := fs.String("backend", config.LoadEventConfig().Backend, "event backend to check: kafka, redis, nats or memory")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long each case waits for events")
+	settle := fs.Duration("settle", 0, "delay between starting consumers and publishing, 0 for the backend default")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		fmt.Println(conformanceUsage)
+		return 2
+	}
+	if _, ok := settleDefaults[*backend]; !ok {
+		fmt.Println(conformanceUsage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Tanpa sequencer: suite tidak butuh PostgreSQL/MongoDB
+	envelope := event.NewEnvelope(config.LoadEventConfig())
+	opts := conformance.Options{Settle: *settle, Timeout: *timeout}
+	if opts.Settle <= 0 {
+		opts.Settle = settleDefaults[*backend]
+	}
+
+	var bus event.Bus
+	switch *backend {
+	case "memory":
+		bus = event.NewMemoryBroker(envelope)
+	case "redis":
+		redisClient := config.ConnectRedis()
+		defer redisClient.Close()
+		// Redelivery lewat XCLAIM dipercepat agar case redelivery tidak menunggu satu menit
+		streamConfig := config.LoadRedisStreamConfig()
+		streamConfig.ClaimMinIdle = time.Second
+		streamConfig.ClaimInterval = 500 * time.Millisecond
+		bus = event.NewRedisStreamBus(redisClient, envelope, streamConfig)
+
+		var topics []string
+		opts.Prepare = func(ctx context.Context, topic string) error {
+			topics = append(topics, topic)
+			return nil
+		}
+		defer func() {
+			for _, topic := range topics {
+				redisClient.Del(context.Background(), event.StreamKey(topic), event.StreamKey(topic)+".dlq")
+			}
+		}()
+	case "nats":
+		natsConfig := config.LoadNATSConfig()
+		natsConn := config.ConnectNATS(natsConfig)
+		defer natsConn.Close()
+		// NAK dengan jeda singkat agar case redelivery tidak menunggu RetryDelay
+		natsConfig.RetryDelay = 200 * time.Millisecond
+		natsConfig.FetchTimeout = 500 * time.Millisecond
+		jetStreamBus, err := event.NewJetStreamBus(natsConn, envelope, natsConfig)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			return 1
+		}
+		bus = jetStreamBus
+
+		js, _ := jetstream.New(natsConn)
+		var topics []string
+		opts.Prepare = func(ctx context.Context, topic string) error {
+			topics = append(topics, topic)
+			return nil
+		}
+		defer func() {
+			for _, topic := range topics {
+				js.DeleteStream(context.Background(), event.JetStreamName(topic))
+			}
+		}()
+	case "kafka":
+		brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+		producer := config.LoadProducerConfig()
+		retry := kafka.NewRetryPolicy(config.LoadConsumerConfig())
+		retry.RetryDelays = nil
+		bus = kafka.NewBus(brokers, event.NewKafkaPublisher(brokers, "", envelope, producer), retry, nil)
+		opts.Prepare = func(ctx context.Context, topic string) error {
+			return createTopic(brokers, topic)
+		}
+	}
+	defer bus.Close()
+
+	fmt.Printf("🧪 Running event bus conformance suite against %s\n", *backend)
+	failed := 0
+	for _, r := range conformance.Run(ctx, bus, opts) {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("❌ %-16s %6s  %v\n", r.Name, r.Duration.Round(time.Millisecond), r.Err)
+			continue
+		}
+		fmt.Printf("✅ %-16s %6s\n", r.Name, r.Duration.Round(time.Millisecond))
+	}
+	if failed > 0 {
+		log.Printf("❌ %d conformance case(s) failed", failed)
+		return 1
+	}
+	fmt.Println("✅ Event bus conforms")
+	return 0
+}
+
+// createTopic membuat topic Kafka dengan satu partition lewat controller cluster
+func createTopic(brokers []string, topic string) error {
+	conn, err := kafkago.Dial("tcp", brokers[0])
+	if err != nil {
+		return fmt.Errorf("failed to dial kafka: %w", err)
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to find kafka controller: %w", err)
+	}
+	controllerConn, err := kafkago.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to dial kafka controller: %w", err)
+	}
+	defer controllerConn.Close()
+
+	return controllerConn.CreateTopics(kafkago.TopicConfig{Topic: topic, NumPartitions: 1, ReplicationFactor: 1})
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/event"
+	"golang-crud-clean-arch/internal/kafka"
+	"golang-crud-clean-arch/internal/stream"
+	"golang-crud-clean-arch/internal/usecase"
+
+	"go.opentelemetry.io/otel"
+)
+
+// consumerGroupID dipakai oleh semua consumer event aplikasi ini
+const consumerGroupID = "user-group"
+
+// syncGroupID adalah consumer group sync worker PostgreSQL ↔ MongoDB
+const syncGroupID = "sync-group"
+
+// webhookGroupID adalah consumer group dispatcher webhook
+const webhookGroupID = "webhook-group"
+
+// liveGroupID adalah consumer group yang mengisi buffer stream live (SSE/WebSocket)
+const liveGroupID = "live-stream-group"
+
+// eventTopics adalah topic yang dikonsumsi aplikasi ini
+var eventTopics = []string{"user-events", "repo-events"}
+
+// entityRef adalah payload event yang hanya berisi id (deleted, purged)
+type entityRef struct {
+	ID interface{} `json:"id"`
+}
+
+// newEventRouter mendaftarkan handler untuk setiap tipe event user dan repository.
+// Event yang sudah pernah diproses (berdasarkan id CloudEvent) dilewati oleh dedup dan event
+// yang datang tidak berurutan dicatat oleh pengecekan sequence.
+// Keduanya nil untuk replay karena event lama memang sengaja diproses ulang.
+func newEventRouter(dedup kafka.DedupStore, sequences kafka.SequenceStore) *kafka.Router {
+	router := kafka.NewRouter()
+	router.Use(kafka.Recovery(), kafka.Logging(), kafka.Tracing(otel.Tracer("kafka-consumer")))
+	if dedup != nil {
+		router.Use(kafka.Dedup(dedup))
+	}
+	if sequences != nil {
+		router.Use(kafka.Sequence(sequences))
+	}
+
+	// User events
+	router.Handle("user.created", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, user entity.User) error {
+		log.Printf("👤 User created: %v (%s)", user.ID, user.Email)
+		return nil
+	}))
+	router.Handle("user.updated", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, user entity.UserChanges) error {
+		if user.User == nil {
+			log.Printf("👤 User updated: %s", ce.Subject)
+			return nil
+		}
+		log.Printf("👤 User updated: %v (version %d, %d changed fields)", user.ID, user.Version, len(user.ChangedFields))
+		return nil
+	}))
+	router.Handle("user.deleted", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, ref entityRef) error {
+		log.Printf("👤 User deleted: %v", ref.ID)
+		return nil
+	}))
+	router.Handle("user.restored", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, user entity.User) error {
+		log.Printf("👤 User restored: %v", user.ID)
+		return nil
+	}))
+	router.Handle("user.purged", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, ref entityRef) error {
+		log.Printf("👤 User purged: %v", ref.ID)
+		return nil
+	}))
+
+	// Repository events
+	router.Handle("repo.created", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, repo entity.Repository) error {
+		log.Printf("📦 Repository created: %v (%s)", repo.ID, repo.URL)
+		return nil
+	}))
+	router.Handle("repo.updated", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, repo entity.RepositoryChanges) error {
+		if repo.Repository == nil {
+			log.Printf("📦 Repository updated: %s", ce.Subject)
+			return nil
+		}
+		log.Printf("📦 Repository updated: %v (version %d, %d changed fields)", repo.ID, repo.Version, len(repo.ChangedFields))
+		return nil
+	}))
+	router.Handle("repo.deleted", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, ref entityRef) error {
+		log.Printf("📦 Repository deleted: %v", ref.ID)
+		return nil
+	}))
+	router.Handle("repo.restored", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, repo entity.Repository) error {
+		log.Printf("📦 Repository restored: %v", repo.ID)
+		return nil
+	}))
+	router.Handle("repo.purged", kafka.Typed(func(ctx context.Context, ce event.CloudEvent, ref entityRef) error {
+		log.Printf("📦 Repository purged: %v", ref.ID)
+		return nil
+	}))
+
+	return router
+}
+
+// newSyncRouter meneruskan setiap event user dan repository ke sync worker.
+// dedup dan sequences nil untuk replay, sama seperti newEventRouter.
+func newSyncRouter(sync *usecase.SyncUsecase, dedup kafka.DedupStore, sequences kafka.SequenceStore) *kafka.Router {
+	router := kafka.NewRouter()
+	router.Use(kafka.Recovery(), kafka.Logging(), kafka.Tracing(otel.Tracer("sync-consumer")))
+	if dedup != nil {
+		router.Use(kafka.Dedup(dedup))
+	}
+	if sequences != nil {
+		router.Use(kafka.Sequence(sequences))
+	}
+
+	for _, action := range []string{"created", "updated", "deleted", "restored", "purged"} {
+		router.Handle("user."+action, sync.ApplyUserEvent)
+		router.Handle("repo."+action, sync.ApplyRepositoryEvent)
+	}
+	return router
+}
+
+// newWebhookRouter meneruskan setiap event ke dispatcher webhook, yang memilih langganan berdasarkan tipe event
+func newWebhookRouter(webhooks *usecase.WebhookUsecase, dedup kafka.DedupStore) *kafka.Router {
+	router := kafka.NewRouter()
+	router.Use(kafka.Recovery(), kafka.Logging(), kafka.Tracing(otel.Tracer("webhook-consumer")))
+	if dedup != nil {
+		router.Use(kafka.Dedup(dedup))
+	}
+	router.Default(webhooks.Dispatch)
+	return router
+}
+
+// newLiveRouter menyimpan setiap event ke buffer stream live; satu consumer group untuk semua
+// instance sehingga setiap event masuk buffer sekali, lalu dibaca oleh hub di setiap instance
+func newLiveRouter(buffer *stream.RedisBuffer, dedup kafka.DedupStore) *kafka.Router {
+	router := kafka.NewRouter()
+	router.Use(kafka.Recovery(), kafka.Logging(), kafka.Tracing(otel.Tracer("live-stream-consumer")))
+	if dedup != nil {
+		router.Use(kafka.Dedup(dedup))
+	}
+	router.Default(buffer.Ingest)
+	return router
+}
+
+// startBusConsumers menjalankan router di background untuk setiap topic lewat event.Bus
+// (EVENT_BACKEND=memory|redis). Redelivery dan dead-lettering diatur oleh backend-nya.
+func startBusConsumers(ctx context.Context, bus event.Bus, group string, router *kafka.Router) {
+	for _, topic := range eventTopics {
+		go func() {
+			if err := bus.Consume(ctx, topic, group, router.Dispatch); err != nil {
+				log.Printf("❌ Event Consumer Error (%s): %v", topic, err)
+			}
+		}()
+	}
+	log.Printf("📥 Consumers of group %s started for %v", group, eventTopics)
+}
+
+// startConsumers menjalankan KafkaConsumer di background untuk setiap topic beserta retry topic-nya.
+// Event yang tetap gagal berakhir di {topic}.dlq.
+func startConsumers(ctx context.Context, brokers []string, router *kafka.Router, retry kafka.RetryPolicy, forwarder *kafka.Forwarder) {
+	for _, source := range eventTopics {
+		topics := []string{source}
+		for stage := 1; stage <= len(retry.RetryDelays); stage++ {
+			topics = append(topics, kafka.RetryTopic(source, stage))
+		}
+
+		for _, topic := range topics {
+			consumer := &kafka.KafkaConsumer{
+				Brokers:   brokers,
+				Topic:     topic,
+				GroupID:   consumerGroupID,
+				Handler:   router.Dispatch,
+				Retry:     retry,
+				Forwarder: forwarder,
+			}
+			go func() {
+				if err := consumer.Start(ctx); err != nil {
+					log.Printf("❌ Kafka Consumer Error (%s): %v", consumer.Topic, err)
+				}
+			}()
+		}
+	}
+}
+
+// startGroupConsumers menjalankan consumer tambahan (sync worker, dispatcher webhook, stream live) di consumer
+// group terpisah. Setiap group punya retry topic dan DLQ sendiri ({topic}.{group}.retry.N dan
+// {topic}.{group}.dlq) agar event yang gagal hanya diproses ulang oleh group itu; topic {topic}.{group}
+// menerima pesan yang di-redrive dari DLQ group.
+func startGroupConsumers(ctx context.Context, brokers []string, group string, router *kafka.Router, retry kafka.RetryPolicy, forwarder *kafka.Forwarder) {
+	for _, source := range eventTopics {
+		lane := kafka.GroupFailureTopic(source, group)
+		topics := []string{source, lane}
+		for stage := 1; stage <= len(retry.RetryDelays); stage++ {
+			topics = append(topics, kafka.RetryTopic(lane, stage))
+		}
+
+		for _, topic := range topics {
+			consumer := &kafka.KafkaConsumer{
+				Brokers:      brokers,
+				Topic:        topic,
+				GroupID:      group,
+				Handler:      router.Dispatch,
+				Retry:        retry,
+				Forwarder:    forwarder,
+				FailureTopic: lane,
+			}
+			go func() {
+				if err := consumer.Start(ctx); err != nil {
+					log.Printf("❌ Consumer Error (%s, %s): %v", group, consumer.Topic, err)
+				}
+			}()
+		}
+	}
+}
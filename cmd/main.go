@@ -2,30 +2,56 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"golang-crud-clean-arch/config"
 	httpHandler "golang-crud-clean-arch/delivery/http"
+	"golang-crud-clean-arch/delivery/openapi"
 	"golang-crud-clean-arch/delivery/routes"
+	"golang-crud-clean-arch/internal/cache"
 	"golang-crud-clean-arch/internal/event"
 	"golang-crud-clean-arch/internal/kafka"
+	"golang-crud-clean-arch/internal/outbox"
 	"golang-crud-clean-arch/internal/repository"
+	"golang-crud-clean-arch/internal/stream"
 	"golang-crud-clean-arch/internal/usecase"
 
 	"github.com/go-chi/chi/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 func main() {
+	// Subcommand: migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	// Subcommand: replay -topic ... [-handler events|sync|webhook|live] [-apply]
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
+	// Subcommand: conformance [-backend kafka|redis|nats|memory]
+	if len(os.Args) > 1 && os.Args[1] == "conformance" {
+		os.Exit(runConformance(os.Args[2:]))
+	}
+
 	// ✅ Init Tracing
 	cleanup, tracerProvider := config.InitTracerWithProvider("golang-clean-arch")
 	defer cleanup()
 	otel.SetTracerProvider(tracerProvider)
+	// W3C traceparent/baggage dipakai untuk meneruskan trace lewat header Kafka
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
 	// PostgreSQL
 	postgresDB, err := config.PostgresConnect()
@@ -34,6 +60,15 @@ func main() {
 	}
 	defer postgresDB.Close()
 
+	// Migrasi skema otomatis saat startup; jika dimatikan, server berhenti selama masih ada migrasi tertunda
+	if config.GetEnv("MIGRATE_ON_STARTUP", "true") == "true" {
+		if err := migrateOnStartup(postgresDB); err != nil {
+			log.Fatalf("❌ Failed to run database migrations: %v", err)
+		}
+	} else if err := checkMigrations(postgresDB); err != nil {
+		log.Fatalf("❌ Database schema is not up to date: %v (run `main migrate up` or set MIGRATE_ON_STARTUP=true)", err)
+	}
+
 	// MongoDB
 	mongoClient := config.MongoConnect()
 	mongoDBName := os.Getenv("MONGO_DB_NAME")
@@ -41,51 +76,203 @@ func main() {
 	// Redis
 	redisClient := config.ConnectRedis()
 
+	// Read-through cache, namespace terpisah per backend
+	cacheConfig := config.LoadCacheConfig()
+	pgCache := cache.New(redisClient, "pg", cacheConfig.ItemTTL, cacheConfig.ListTTL)
+	mongoCache := cache.New(redisClient, "mongo", cacheConfig.ItemTTL, cacheConfig.ListTTL)
+
 	// Kafka
 	kafkaBrokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
 
-	publisherUsers := event.NewKafkaPublisher(kafkaBrokers, "user-events")
-	publisherRepos := event.NewKafkaPublisher(kafkaBrokers, "repo-events")
-	defer publisherUsers.Close()
-	defer publisherRepos.Close()
-	fmt.Println("✅ Kafka publisher initialized")
+	// Semua event dibungkus CloudEvents 1.0 (EVENT_CONTENT_MODE=structured|binary)
+	eventConfig := config.LoadEventConfig()
+	envelope := event.NewEnvelope(eventConfig)
+
+	// Publisher Kafka/Redis Streams/in-memory dipakai langsung oleh stack MongoDB (ekstensi backend=mongo).
+	// Event PostgreSQL dibungkus oleh outbox, relay meneruskannya tanpa membungkus ulang.
+	// Setiap event mendapat nomor urut per agregat (ekstensi sequence) dari backend-nya.
+	mongoEnvelope := envelope.ForBackend(event.BackendMongo).
+		WithSequencer(repository.NewEventSequenceRepositoryMongo(mongoClient, mongoDBName))
+	pgEnvelope := envelope.ForBackend(event.BackendPostgres).
+		WithSequencer(repository.NewEventSequenceRepositoryPostgres(postgresDB))
+
+	var (
+		publisherUsers  event.EventPublisher
+		publisherRepos  event.EventPublisher
+		relayPublisher  event.EventPublisher
+		eventBus        event.Bus // nil untuk Kafka, yang memakai consumer dengan retry topic dan DLQ
+		asyncPublishers []*event.AsyncPublisher
+	)
+	producerConfig := config.LoadProducerConfig()
+	if err := event.ValidateProducerConfig(producerConfig); err != nil {
+		log.Fatalf("❌ Invalid Kafka producer config: %v", err)
+	}
+	switch eventConfig.Backend {
+	case "memory":
+		// Broker in-process untuk development tanpa Kafka
+		eventBus = event.NewMemoryBroker(mongoEnvelope)
+		fmt.Println("✅ In-memory event broker initialized")
+	case "redis":
+		// Redis Streams dengan consumer group, memakai koneksi Redis yang sama dengan cache
+		eventBus = event.NewRedisStreamBus(redisClient, mongoEnvelope, config.LoadRedisStreamConfig())
+		fmt.Println("✅ Redis Streams event bus initialized")
+	case "nats":
+		// NATS JetStream: satu stream per topic, consumer group = durable pull consumer
+		natsConfig := config.LoadNATSConfig()
+		natsConn := config.ConnectNATS(natsConfig)
+		defer natsConn.Close()
+		jetStreamBus, err := event.NewJetStreamBus(natsConn, mongoEnvelope, natsConfig)
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize NATS JetStream event bus: %v", err)
+		}
+		eventBus = jetStreamBus
+		fmt.Println("✅ NATS JetStream event bus initialized")
+	default:
+		// Codec, acks, batching dan mode async dari KAFKA_PRODUCER_*
+		kafkaUsers := event.NewKafkaPublisher(kafkaBrokers, "user-events", mongoEnvelope, producerConfig)
+		kafkaRepos := event.NewKafkaPublisher(kafkaBrokers, "repo-events", mongoEnvelope, producerConfig)
+		defer kafkaUsers.Close()
+		defer kafkaRepos.Close()
+		publisherUsers, publisherRepos = kafkaUsers, kafkaRepos
+
+		// Mode async: request HTTP tidak menunggu broker, event dikirim per batch di background
+		if producerConfig.Async {
+			asyncUsers := event.NewAsyncPublisher(kafkaUsers, mongoEnvelope, producerConfig, event.DeliveryCallbacks{})
+			asyncRepos := event.NewAsyncPublisher(kafkaRepos, mongoEnvelope, producerConfig, event.DeliveryCallbacks{})
+			defer asyncUsers.Close()
+			defer asyncRepos.Close()
+			publisherUsers, publisherRepos = asyncUsers, asyncRepos
+			asyncPublishers = append(asyncPublishers, asyncUsers, asyncRepos)
+		}
+
+		// Relay harus menunggu ack sebelum menandai event outbox terkirim, jadi tidak pernah async
+		kafkaRelay := event.NewKafkaPublisher(kafkaBrokers, "user-events", mongoEnvelope, producerConfig)
+		defer kafkaRelay.Close()
+		relayPublisher = kafkaRelay
+		fmt.Printf("✅ Kafka publisher initialized (compression=%s, acks=%s, async=%t, overflow=%s)\n",
+			producerConfig.Compression, producerConfig.RequiredAcks, producerConfig.Async, producerConfig.Overflow)
+	}
+	if eventBus != nil {
+		publisherUsers, publisherRepos, relayPublisher = eventBus, eventBus, eventBus
+	}
+
+	// Transactional outbox: event PostgreSQL disimpan dalam transaksi yang sama dengan perubahan data,
+	// lalu dikirim ke Kafka oleh relay di background
+	pgTransactor := repository.NewPostgresTransactor(postgresDB)
+	outboxStore := repository.NewOutboxRepositoryPostgres(postgresDB)
+	outboxPublisher := outbox.NewPublisher(outboxStore, pgEnvelope)
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	go outbox.NewRelay(outboxStore, pgTransactor, relayPublisher, config.LoadOutboxConfig()).Run(relayCtx)
 
 	// Repositories
-	userRepoPostgres := repository.NewUserRepositoryPostgres(postgresDB, redisClient, publisherUsers)
-	userRepoMongo := repository.NewUserRepositoryMongo(mongoClient, redisClient, mongoDBName, publisherUsers)
-	repoRepoPostgres := repository.NewRepoRepositoryPostgres(postgresDB, redisClient)
-	repoRepoMongo := repository.NewRepoRepository(mongoClient, redisClient, mongoDBName)
+	userRepoPostgres := repository.NewUserRepositoryPostgres(postgresDB, pgCache, outboxPublisher)
+	userRepoMongo := repository.NewUserRepositoryMongo(mongoClient, mongoCache, mongoDBName, publisherUsers)
+	repoRepoPostgres := repository.NewRepoRepositoryPostgres(postgresDB, pgCache)
+	repoRepoMongo := repository.NewRepoRepository(mongoClient, mongoCache, mongoDBName)
 
 	// Usecases
-	userUsecasePostgres := usecase.NewUserUsecase(userRepoPostgres, redisClient, publisherUsers)
-	userUsecaseMongo := usecase.NewUserUsecase(userRepoMongo, redisClient, publisherUsers)
-	repoUsecasePostgres := usecase.NewRepositoryUsecase(repoRepoPostgres, redisClient, publisherRepos)
-	repoUsecaseMongo := usecase.NewRepositoryUsecase(repoRepoMongo, redisClient, publisherRepos)
+	userUsecasePostgres := usecase.NewUserUsecase(userRepoPostgres, redisClient, outboxPublisher, pgTransactor)
+	userUsecaseMongo := usecase.NewUserUsecase(userRepoMongo, redisClient, publisherUsers, nil)
+	repoUsecasePostgres := usecase.NewRepositoryUsecase(repoRepoPostgres, redisClient, outboxPublisher, pgTransactor)
+	repoUsecaseMongo := usecase.NewRepositoryUsecase(repoRepoMongo, redisClient, publisherRepos, nil)
+
+	// Sync worker PostgreSQL ↔ MongoDB, pasangan id disimpan di tabel id_mappings
+	idMappings := repository.NewIDMappingRepositoryPostgres(postgresDB)
+	syncUsecase := usecase.NewSyncUsecase(userRepoPostgres, userRepoMongo, repoRepoPostgres, repoRepoMongo, idMappings)
+	syncHandler := httpHandler.NewSyncHandler(syncUsecase)
+
+	// Webhook: langganan HTTP callback untuk event user dan repository
+	webhookConfig := config.LoadWebhookConfig()
+	webhookUsecase := usecase.NewWebhookUsecase(repository.NewWebhookRepositoryPostgres(postgresDB), webhookConfig)
+	webhookHandler := httpHandler.NewWebhookHandler(webhookUsecase)
+
+	// Stream live SSE/WebSocket: consumer mengisi buffer Redis, hub meneruskannya ke client
+	liveConfig := config.LoadLiveStreamConfig()
+	liveBuffer := stream.NewRedisBuffer(redisClient, liveConfig.BufferLen)
+	liveHub := stream.NewHub(liveBuffer, liveConfig.QueueSize)
+	streamHandler := httpHandler.NewStreamHandler(liveHub, liveConfig.Heartbeat)
 
 	// Handlers
-	userHandlerPostgres := httpHandler.NewUserHandler(userUsecasePostgres)
-	userHandlerMongo := httpHandler.NewUserHandler(userUsecaseMongo)
+	userHandlerPostgres := httpHandler.NewUserHandler(userUsecasePostgres, repoUsecasePostgres)
+	userHandlerMongo := httpHandler.NewUserHandler(userUsecaseMongo, repoUsecaseMongo)
 	repoHandlerPostgres := httpHandler.NewRepositoryHandler(repoUsecasePostgres)
 	repoHandlerMongo := httpHandler.NewRepositoryHandler(repoUsecaseMongo)
 
-	// Health Handler
+	// Health Handler (tanpa pengecekan Kafka jika memakai backend event lain)
 	kafkaAddr := strings.Join(kafkaBrokers, ",")
-	healthHandler := httpHandler.NewHealthHandler(mongoClient, redisClient, postgresDB, kafkaAddr, tracerProvider)
+	if eventBus != nil {
+		kafkaAddr = ""
+	}
+	healthHandler := httpHandler.NewHealthHandler(mongoClient, redisClient, postgresDB, kafkaAddr, tracerProvider, pgCache, mongoCache)
 
-	// Kafka Consumer (run in background)
-	go func() {
-		userConsumer := &kafka.KafkaConsumer{
-			Brokers: kafkaBrokers,
-			Topic:   "user-events",
-			GroupID: "user-group",
+	// Consumers untuk user-events dan repo-events
+	consumerConfig := config.LoadConsumerConfig()
+	dedupStore := kafka.NewRedisDedupStore(redisClient, consumerGroupID, consumerConfig.DedupTTL)
+	sequenceStore := kafka.NewRedisSequenceStore(redisClient, consumerGroupID, consumerConfig.SequenceTTL)
+	eventRouter := newEventRouter(dedupStore, sequenceStore)
+	retryPolicy := kafka.NewRetryPolicy(consumerConfig)
+
+	// Sync worker aktif jika SYNC_ENABLED=true
+	var syncRouter *kafka.Router
+	if config.GetEnv("SYNC_ENABLED", "false") == "true" {
+		syncDedup := kafka.NewRedisDedupStore(redisClient, syncGroupID, consumerConfig.DedupTTL)
+		syncSequences := kafka.NewRedisSequenceStore(redisClient, syncGroupID, consumerConfig.SequenceTTL)
+		syncRouter = newSyncRouter(syncUsecase, syncDedup, syncSequences)
+	}
+
+	// Dispatcher webhook aktif kecuali WEBHOOK_ENABLED=false
+	var webhookRouter *kafka.Router
+	if webhookConfig.Enabled {
+		webhookRouter = newWebhookRouter(webhookUsecase, kafka.NewRedisDedupStore(redisClient, webhookGroupID, consumerConfig.DedupTTL))
+
+		// Percobaan kedua dan seterusnya dikirim dari antrian retry, bukan oleh consumer
+		retryCtx, stopRetries := context.WithCancel(context.Background())
+		defer stopRetries()
+		go webhookUsecase.RunRetries(retryCtx)
+	}
+
+	// Stream live aktif kecuali LIVE_STREAM_ENABLED=false
+	var liveRouter *kafka.Router
+	if liveConfig.Enabled {
+		liveRouter = newLiveRouter(liveBuffer, kafka.NewRedisDedupStore(redisClient, liveGroupID, consumerConfig.DedupTTL))
+	}
+
+	var dlqHandler *httpHandler.DLQHandler
+	if eventBus != nil {
+		startBusConsumers(context.Background(), eventBus, consumerGroupID, eventRouter)
+		if syncRouter != nil {
+			startBusConsumers(context.Background(), eventBus, syncGroupID, syncRouter)
 		}
-		if err := userConsumer.Start(context.Background()); err != nil {
-			log.Printf("❌ Kafka Consumer Error: %v", err)
+		if webhookRouter != nil {
+			startBusConsumers(context.Background(), eventBus, webhookGroupID, webhookRouter)
 		}
-	}()
+		if liveRouter != nil {
+			startBusConsumers(context.Background(), eventBus, liveGroupID, liveRouter)
+		}
+	} else {
+		// Kafka consumers (run in background), event yang gagal di-retry lewat
+		// {topic}.retry.N lalu dipindah ke {topic}.dlq
+		forwarder := kafka.NewForwarder(kafkaBrokers, producerConfig)
+		defer forwarder.Close()
+		startConsumers(context.Background(), kafkaBrokers, eventRouter, retryPolicy, forwarder)
+		dlqHandler = httpHandler.NewDLQHandler(kafka.NewDeadLetterQueue(kafkaBrokers, forwarder))
+		if syncRouter != nil {
+			startGroupConsumers(context.Background(), kafkaBrokers, syncGroupID, syncRouter, retryPolicy, forwarder)
+		}
+		if webhookRouter != nil {
+			startGroupConsumers(context.Background(), kafkaBrokers, webhookGroupID, webhookRouter, retryPolicy, forwarder)
+		}
+		if liveRouter != nil {
+			startGroupConsumers(context.Background(), kafkaBrokers, liveGroupID, liveRouter, retryPolicy, forwarder)
+		}
+	}
 
-	// HTTP Router
+	// HTTP Router; body request /pg/* dan /mongo/* divalidasi terhadap spesifikasi OpenAPI
+	apiDoc := openapi.Spec()
 	r := chi.NewRouter()
+	r.Use(openapi.ValidateRequest(apiDoc))
 
 	r.Route("/pg", func(r chi.Router) {
 		routes.SetupUserRoutes(r, userHandlerPostgres)
@@ -98,11 +285,50 @@ func main() {
 	})
 
 	routes.SetupHealthRoutes(r, healthHandler)
+	if dlqHandler != nil {
+		routes.SetupAdminRoutes(r, dlqHandler)
+	}
+	routes.SetupSyncRoutes(r, syncHandler)
+	routes.SetupOutboxRoutes(r, httpHandler.NewOutboxHandler(outboxStore))
+	routes.SetupWebhookRoutes(r, webhookHandler)
+	routes.SetupDocsRoutes(r, httpHandler.NewDocsHandler(apiDoc))
+	if liveConfig.Enabled {
+		routes.SetupStreamRoutes(r, streamHandler)
+	}
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("🚀 API is running on /pg/* and /mongo/*"))
+		w.Write([]byte("🚀 API is running on /pg/* and /mongo/*, docs at /docs"))
 	})
 
+	// Graceful shutdown: berhenti menerima request, lalu kirim event yang masih di buffer async
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: ":9000", Handler: r}
+	if liveConfig.Enabled {
+		// Koneksi SSE/WebSocket tidak pernah idle; hub memutusnya saat shutdown agar Shutdown tidak menunggu timeout
+		hubCtx, stopHub := context.WithCancel(context.Background())
+		defer stopHub()
+		server.RegisterOnShutdown(stopHub)
+		go liveHub.Run(hubCtx)
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("❌ HTTP server failed: %v", err)
+		}
+	}()
 	fmt.Println("🌍 Server berjalan di port :9000")
-	log.Fatal(http.ListenAndServe(":9000", r))
+
+	<-ctx.Done()
+	log.Println("🛑 Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ HTTP server shutdown: %v", err)
+	}
+	for _, p := range asyncPublishers {
+		if err := p.Flush(shutdownCtx); err != nil {
+			log.Printf("⚠️ Failed to flush event buffer: %v", err)
+		}
+	}
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	"golang-crud-clean-arch/config"
+	"golang-crud-clean-arch/dbmigration"
+	"golang-crud-clean-arch/internal/migration"
+)
+
+const migrateUsage = "usage: main migrate up | down [steps] | status"
+
+// runMigrate menjalankan subcommand `migrate up|down [steps]|status` dan mengembalikan exit code
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	db, err := config.PostgresConnect()
+	if err != nil {
+		log.Printf("❌ Failed to connect to PostgreSQL: %v", err)
+		return 1
+	}
+	defer db.Close()
+
+	migrator, err := migration.New(db, dbmigration.Migrations, "migrations")
+	if err != nil {
+		log.Printf("❌ Failed to load migrations: %v", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("⬆️  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Printf("❌ Migration up failed: %v", err)
+			return 1
+		}
+		fmt.Printf("✅ %d migration(s) applied\n", len(applied))
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				fmt.Println(migrateUsage)
+				return 2
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("⬇️  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Printf("❌ Migration down failed: %v", err)
+			return 1
+		}
+		fmt.Printf("✅ %d migration(s) reverted\n", len(reverted))
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Printf("❌ Migration status failed: %v", err)
+			return 1
+		}
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Missing:
+				state = "applied (file missing)"
+			case s.Modified:
+				state = "applied (checksum mismatch)"
+			case s.Applied:
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		fmt.Println(migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+// migrateOnStartup menerapkan migrasi yang tertunda sebelum server berjalan
+func migrateOnStartup(db *sql.DB) error {
+	migrator, err := migration.New(db, dbmigration.Migrations, "migrations")
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Database schema up to date (%d migration(s) applied)\n", len(applied))
+	return nil
+}
+
+// checkMigrations memastikan semua migrasi sudah diterapkan saat MIGRATE_ON_STARTUP=false
+func checkMigrations(db *sql.DB) error {
+	migrator, err := migration.New(db, dbmigration.Migrations, "migrations")
+	if err != nil {
+		return err
+	}
+	return migrator.Check(context.Background())
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang-crud-clean-arch/config"
+	"golang-crud-clean-arch/internal/cache"
+	"golang-crud-clean-arch/internal/kafka"
+	"golang-crud-clean-arch/internal/repository"
+	"golang-crud-clean-arch/internal/stream"
+	"golang-crud-clean-arch/internal/usecase"
+)
+
+const replayUsage = "usage: main replay -topic <topic> [-from-offset N | -from-time RFC3339] [-type t1,t2] [-entity id] [-limit N] [-handler events|sync|webhook|live] [-apply]"
+
+// runReplay menjalankan subcommand `replay` dan mengembalikan exit code.
+// Default-nya dry-run: event yang cocok hanya ditampilkan; -apply meneruskannya ke handler consumer.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	topic := fs.String("topic", "", "topic to replay, e.g. user-events")
+	fromOffset := fs.Int64("from-offset", 0, "first offset to replay in every partition")
+	fromTime := fs.String("from-time", "", "replay messages produced at or after this RFC3339 timestamp")
+	types := fs.String("type", "", "comma-separated event types to replay, e.g. user.created,user.updated")
+	entity := fs.String("entity", "", "only replay events whose subject (entity id) matches")
+	limit := fs.Int("limit", 0, "maximum number of matching events, 0 for no limit")
+	apply := fs.Bool("apply", false, "feed matching events to the consumer handlers instead of a dry-run")
+	handler := fs.String("handler", "events", "consumer handler to feed with -apply: events, sync, webhook or live")
+	if err := fs.Parse(args); err != nil || *topic == "" || fs.NArg() > 0 || !replayHandlers[*handler] {
+		fmt.Println(replayUsage)
+		return 2
+	}
+
+	opts := kafka.ReplayOptions{
+		Topic:      *topic,
+		FromOffset: *fromOffset,
+		EntityID:   *entity,
+		Apply:      *apply,
+		Limit:      *limit,
+	}
+	if *fromTime != "" {
+		t, err := time.Parse(time.RFC3339, *fromTime)
+		if err != nil {
+			fmt.Println(replayUsage)
+			return 2
+		}
+		opts.FromTime = t
+	}
+	for _, t := range strings.Split(*types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			opts.Types = append(opts.Types, t)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Dry-run tidak membutuhkan handler, jadi koneksi database hanya dibuka dengan -apply
+	var dispatch kafka.HandlerFunc
+	mode := "dry-run"
+	if opts.Apply {
+		router, cleanup, err := newReplayRouter(*handler)
+		if err != nil {
+			log.Printf("❌ Replay failed: %v", err)
+			return 1
+		}
+		defer cleanup()
+		dispatch = router.Dispatch
+		mode = "apply to " + *handler
+	}
+
+	brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+	retry := kafka.NewRetryPolicy(config.LoadConsumerConfig())
+	replayer := kafka.NewReplayer(brokers, dispatch, retry)
+
+	fmt.Printf("⏪ Replaying %s (%s)\n", opts.Topic, mode)
+
+	stats, err := replayer.Replay(ctx, opts)
+	fmt.Printf("📊 scanned=%d invalid=%d matched=%d applied=%d failed=%d\n",
+		stats.Scanned, stats.Invalid, stats.Matched, stats.Applied, stats.Failed)
+	if err != nil {
+		log.Printf("❌ Replay failed: %v", err)
+		return 1
+	}
+	if stats.Failed > 0 {
+		return 1
+	}
+	fmt.Println("✅ Replay finished")
+	return 0
+}
+
+// replayHandlers adalah nilai yang valid untuk -handler
+var replayHandlers = map[string]bool{"events": true, "sync": true, "webhook": true, "live": true}
+
+// newReplayRouter membuat router consumer untuk -handler beserta koneksi yang dibutuhkannya.
+// Semua router dibuat tanpa dedup dan pengecekan sequence: event yang sudah pernah diproses
+// memang sengaja diproses ulang.
+func newReplayRouter(handler string) (*kafka.Router, func(), error) {
+	switch handler {
+	case "sync":
+		postgresDB, err := config.PostgresConnect()
+		if err != nil {
+			return nil, nil, fmt.Errorf("connect to PostgreSQL: %w", err)
+		}
+		mongoClient := config.MongoConnect()
+		mongoDBName := os.Getenv("MONGO_DB_NAME")
+		redisClient := config.ConnectRedis()
+
+		// Cache tetap diinvalidasi agar API tidak menyajikan data lama setelah replay.
+		// Publisher nil: sync worker tidak mempublikasikan event.
+		cacheConfig := config.LoadCacheConfig()
+		pgCache := cache.New(redisClient, "pg", cacheConfig.ItemTTL, cacheConfig.ListTTL)
+		mongoCache := cache.New(redisClient, "mongo", cacheConfig.ItemTTL, cacheConfig.ListTTL)
+		sync := usecase.NewSyncUsecase(
+			repository.NewUserRepositoryPostgres(postgresDB, pgCache, nil),
+			repository.NewUserRepositoryMongo(mongoClient, mongoCache, mongoDBName, nil),
+			repository.NewRepoRepositoryPostgres(postgresDB, pgCache),
+			repository.NewRepoRepository(mongoClient, mongoCache, mongoDBName),
+			repository.NewIDMappingRepositoryPostgres(postgresDB),
+		)
+		cleanup := func() {
+			postgresDB.Close()
+			mongoClient.Disconnect(context.Background())
+			redisClient.Close()
+		}
+		return newSyncRouter(sync, nil, nil), cleanup, nil
+	case "webhook":
+		postgresDB, err := config.PostgresConnect()
+		if err != nil {
+			return nil, nil, fmt.Errorf("connect to PostgreSQL: %w", err)
+		}
+		webhooks := usecase.NewWebhookUsecase(repository.NewWebhookRepositoryPostgres(postgresDB), config.LoadWebhookConfig())
+		return newWebhookRouter(webhooks, nil), func() { postgresDB.Close() }, nil
+	case "live":
+		redisClient := config.ConnectRedis()
+		buffer := stream.NewRedisBuffer(redisClient, config.LoadLiveStreamConfig().BufferLen)
+		return newLiveRouter(buffer, nil), func() { redisClient.Close() }, nil
+	default:
+		return newEventRouter(nil, nil), func() {}, nil
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+// CacheConfig menyimpan TTL untuk read-through cache Redis
+type CacheConfig struct {
+	ItemTTL time.Duration // TTL untuk satu entitas, mis. "users:{id}"
+	ListTTL time.Duration // TTL untuk daftar entitas, mis. "users:all"
+}
+
+// LoadCacheConfig membaca CACHE_ITEM_TTL dan CACHE_LIST_TTL (format time.Duration, mis. "5m").
+// Nilai "0" menonaktifkan cache untuk jenis key tersebut.
+func LoadCacheConfig() CacheConfig {
+	return CacheConfig{
+		ItemTTL: GetDuration("CACHE_ITEM_TTL", 5*time.Minute),
+		ListTTL: GetDuration("CACHE_LIST_TTL", 1*time.Minute),
+	}
+}
+
+// GetDuration membaca env sebagai time.Duration, fallback ke defaultValue jika kosong atau tidak valid
+func GetDuration(key string, defaultValue time.Duration) time.Duration {
+	raw := GetEnv(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf("⚠️ Invalid duration for %s=%q, using default %s", key, raw, defaultValue)
+		return defaultValue
+	}
+	return d
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConsumerConfig menyimpan kebijakan retry untuk event yang gagal diproses consumer
+type ConsumerConfig struct {
+	MaxAttempts    int             // percobaan di dalam proses sebelum event dipindah ke retry topic
+	InitialBackoff time.Duration   // jeda sebelum percobaan kedua, berlipat dua setiap percobaan
+	MaxBackoff     time.Duration   // jeda maksimum antar percobaan di dalam proses
+	RetryDelays    []time.Duration // jeda setiap retry topic ({topic}.retry.1, .retry.2, ...) sebelum DLQ
+	DedupTTL       time.Duration   // berapa lama id event yang sudah diproses diingat
+	SequenceTTL    time.Duration   // berapa lama nomor urut terakhir per agregat diingat
+}
+
+// LoadConsumerConfig membaca CONSUMER_MAX_ATTEMPTS, CONSUMER_INITIAL_BACKOFF,
+// CONSUMER_MAX_BACKOFF, CONSUMER_RETRY_DELAYS (daftar durasi dipisah koma, kosong = langsung DLQ)
+// CONSUMER_DEDUP_TTL dan CONSUMER_SEQUENCE_TTL
+func LoadConsumerConfig() ConsumerConfig {
+	maxAttempts, err := strconv.Atoi(GetEnv("CONSUMER_MAX_ATTEMPTS", "3"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+
+	return ConsumerConfig{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: GetDuration("CONSUMER_INITIAL_BACKOFF", 200*time.Millisecond),
+		MaxBackoff:     GetDuration("CONSUMER_MAX_BACKOFF", 10*time.Second),
+		RetryDelays:    getDurations("CONSUMER_RETRY_DELAYS", []time.Duration{time.Minute, 10 * time.Minute}),
+		DedupTTL:       GetDuration("CONSUMER_DEDUP_TTL", 24*time.Hour),
+		SequenceTTL:    GetDuration("CONSUMER_SEQUENCE_TTL", 7*24*time.Hour),
+	}
+}
+
+// getDurations membaca env berisi daftar time.Duration dipisah koma
+func getDurations(key string, defaultValue []time.Duration) []time.Duration {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	var durations []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil || d <= 0 {
+			log.Printf("⚠️ Invalid duration list for %s=%q, using default %v", key, raw, defaultValue)
+			return defaultValue
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
@@ -0,0 +1,32 @@
+package config
+
+import "log"
+
+// EventConfig menyimpan atribut CloudEvents untuk semua event yang dipublikasikan
+type EventConfig struct {
+	Backend       string // "kafka", "redis" (Redis Streams), "nats" (NATS JetStream) atau "memory" (broker in-process, tanpa Kafka)
+	Source        string // atribut "source", URI yang mengidentifikasi service ini
+	ContentMode   string // "structured" (envelope JSON di body) atau "binary" (atribut di header Kafka)
+	SchemaBaseURL string // prefix atribut "dataschema", diikuti /{versi}/{type}.json
+}
+
+// LoadEventConfig membaca EVENT_BACKEND, EVENT_SOURCE, EVENT_CONTENT_MODE dan EVENT_SCHEMA_BASE_URL
+func LoadEventConfig() EventConfig {
+	cfg := EventConfig{
+		Backend:       GetEnv("EVENT_BACKEND", "kafka"),
+		Source:        GetEnv("EVENT_SOURCE", "/golang-crud-clean-arch"),
+		ContentMode:   GetEnv("EVENT_CONTENT_MODE", "structured"),
+		SchemaBaseURL: GetEnv("EVENT_SCHEMA_BASE_URL", "https://golang-crud-clean-arch/schemas"),
+	}
+	switch cfg.Backend {
+	case "kafka", "redis", "nats", "memory":
+	default:
+		log.Printf("⚠️ Invalid EVENT_BACKEND=%q, using kafka", cfg.Backend)
+		cfg.Backend = "kafka"
+	}
+	if cfg.ContentMode != "structured" && cfg.ContentMode != "binary" {
+		log.Printf("⚠️ Invalid EVENT_CONTENT_MODE=%q, using structured", cfg.ContentMode)
+		cfg.ContentMode = "structured"
+	}
+	return cfg
+}
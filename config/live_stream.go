@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// LiveStreamConfig menyimpan pengaturan stream event live (SSE dan WebSocket)
+type LiveStreamConfig struct {
+	Enabled   bool          // menjalankan consumer yang mengisi buffer live dan endpoint /events/*
+	BufferLen int64         // jumlah event terakhir (perkiraan) di buffer Redis untuk resume Last-Event-ID
+	QueueSize int           // antrean per koneksi; client yang tertinggal sejauh ini diputus
+	Heartbeat time.Duration // jeda heartbeat agar proxy tidak menutup koneksi yang sepi
+}
+
+// LoadLiveStreamConfig membaca LIVE_STREAM_ENABLED, LIVE_STREAM_BUFFER_LEN,
+// LIVE_STREAM_QUEUE_SIZE dan LIVE_STREAM_HEARTBEAT
+func LoadLiveStreamConfig() LiveStreamConfig {
+	bufferLen, err := strconv.ParseInt(GetEnv("LIVE_STREAM_BUFFER_LEN", "1000"), 10, 64)
+	if err != nil || bufferLen <= 0 {
+		bufferLen = 1000
+	}
+	queueSize, err := strconv.Atoi(GetEnv("LIVE_STREAM_QUEUE_SIZE", "64"))
+	if err != nil || queueSize <= 0 {
+		queueSize = 64
+	}
+
+	cfg := LiveStreamConfig{
+		Enabled:   GetEnv("LIVE_STREAM_ENABLED", "true") == "true",
+		BufferLen: bufferLen,
+		QueueSize: queueSize,
+		Heartbeat: GetDuration("LIVE_STREAM_HEARTBEAT", 15*time.Second),
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = 15 * time.Second
+	}
+	return cfg
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSConfig menyimpan pengaturan backend event NATS JetStream (EVENT_BACKEND=nats)
+type NATSConfig struct {
+	URL           string        // alamat server NATS, boleh beberapa dipisah koma
+	MaxAge        time.Duration // umur maksimum pesan di stream, 0 = disimpan selamanya
+	FetchBatch    int           // jumlah pesan maksimum per Fetch
+	FetchTimeout  time.Duration // berapa lama Fetch menunggu pesan baru
+	AckWait       time.Duration // pesan yang tidak di-ack selama ini dikirim ulang (consumer mati)
+	RetryDelay    time.Duration // jeda sebelum pesan yang handler-nya gagal dikirim ulang (NAK)
+	MaxDeliveries int           // pengiriman maksimum sebelum pesan dipindah ke subject {topic}.dlq
+}
+
+// LoadNATSConfig membaca NATS_URL, NATS_STREAM_MAX_AGE, NATS_FETCH_BATCH, NATS_FETCH_TIMEOUT,
+// NATS_ACK_WAIT, NATS_RETRY_DELAY dan NATS_MAX_DELIVERIES
+func LoadNATSConfig() NATSConfig {
+	fetchBatch, err := strconv.Atoi(GetEnv("NATS_FETCH_BATCH", "50"))
+	if err != nil || fetchBatch <= 0 {
+		fetchBatch = 50
+	}
+	maxDeliveries, err := strconv.Atoi(GetEnv("NATS_MAX_DELIVERIES", "5"))
+	if err != nil || maxDeliveries <= 0 {
+		maxDeliveries = 5
+	}
+
+	cfg := NATSConfig{
+		URL:           GetEnv("NATS_URL", nats.DefaultURL),
+		MaxAge:        GetDuration("NATS_STREAM_MAX_AGE", 7*24*time.Hour),
+		FetchBatch:    fetchBatch,
+		FetchTimeout:  GetDuration("NATS_FETCH_TIMEOUT", 2*time.Second),
+		AckWait:       GetDuration("NATS_ACK_WAIT", 30*time.Second),
+		RetryDelay:    GetDuration("NATS_RETRY_DELAY", 5*time.Second),
+		MaxDeliveries: maxDeliveries,
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = 2 * time.Second
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = 30 * time.Second
+	}
+	return cfg
+}
+
+// ConnectNATS membuka koneksi ke server NATS. Koneksi otomatis tersambung ulang jika terputus.
+func ConnectNATS(cfg NATSConfig) *nats.Conn {
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name("golang-crud-clean-arch"),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		log.Fatalf("Failed to connect to NATS: %v", err)
+	}
+
+	fmt.Printf("✅ Connected to NATS at %s!\n", conn.ConnectedUrl())
+	return conn
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// OutboxConfig menyimpan pengaturan relay transactional outbox
+type OutboxConfig struct {
+	PollInterval time.Duration // jeda antar pengecekan event pending
+	BatchSize    int           // jumlah event maksimum per putaran
+	Retention    time.Duration // berapa lama event terkirim disimpan sebelum dihapus
+	MaxBackoff   time.Duration // jeda retry maksimum untuk event yang gagal dikirim
+	ClaimTTL     time.Duration // lama klaim batch berlaku sebelum boleh diambil relay lain
+	MaxAttempts  int           // percobaan kirim sebelum event ditandai gagal permanen
+}
+
+// LoadOutboxConfig membaca OUTBOX_POLL_INTERVAL, OUTBOX_BATCH_SIZE, OUTBOX_RETENTION, OUTBOX_MAX_BACKOFF,
+// OUTBOX_CLAIM_TTL dan OUTBOX_MAX_ATTEMPTS
+func LoadOutboxConfig() OutboxConfig {
+	batchSize, err := strconv.Atoi(GetEnv("OUTBOX_BATCH_SIZE", "100"))
+	if err != nil || batchSize <= 0 {
+		batchSize = 100
+	}
+	maxAttempts, err := strconv.Atoi(GetEnv("OUTBOX_MAX_ATTEMPTS", "20"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 20
+	}
+
+	cfg := OutboxConfig{
+		PollInterval: GetDuration("OUTBOX_POLL_INTERVAL", 1*time.Second),
+		BatchSize:    batchSize,
+		Retention:    GetDuration("OUTBOX_RETENTION", 24*time.Hour),
+		MaxBackoff:   GetDuration("OUTBOX_MAX_BACKOFF", 5*time.Minute),
+		ClaimTTL:     GetDuration("OUTBOX_CLAIM_TTL", 30*time.Second),
+		MaxAttempts:  maxAttempts,
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 1 * time.Second
+	}
+	if cfg.ClaimTTL <= 0 {
+		cfg.ClaimTTL = 30 * time.Second
+	}
+	return cfg
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// ProducerConfig menyimpan pengaturan producer Kafka yang dipakai semua jalur publish
+type ProducerConfig struct {
+	Compression  string        // "none", "gzip", "snappy", "lz4" atau "zstd"
+	RequiredAcks string        // "all" (seluruh ISR), "one" (leader saja) atau "none"
+	BatchSize    int           // jumlah pesan maksimum per batch ke satu partition
+	BatchTimeout time.Duration // jeda maksimum sebelum batch yang belum penuh dikirim
+	MaxAttempts  int           // percobaan pengiriman sebelum write dianggap gagal
+	Async        bool          // true: Publish hanya memasukkan event ke buffer, dikirim di background
+	BufferSize   int           // kapasitas buffer mode async, dibagi rata ke setiap worker
+	Workers      int           // goroutine pengirim mode async; event dengan key yang sama selalu di worker yang sama
+	Overflow     string        // perilaku saat buffer penuh: "block", "drop" atau "error"
+	Idempotent   bool          // idempotent producer; kafka-go tidak mendukungnya, lihat event.ValidateProducerConfig
+}
+
+// LoadProducerConfig membaca KAFKA_PRODUCER_COMPRESSION, KAFKA_PRODUCER_ACKS, KAFKA_PRODUCER_BATCH_SIZE,
+// KAFKA_PRODUCER_BATCH_TIMEOUT, KAFKA_PRODUCER_MAX_ATTEMPTS, KAFKA_PRODUCER_ASYNC,
+// KAFKA_PRODUCER_BUFFER_SIZE, KAFKA_PRODUCER_WORKERS, KAFKA_PRODUCER_OVERFLOW dan KAFKA_PRODUCER_IDEMPOTENT
+func LoadProducerConfig() ProducerConfig {
+	cfg := ProducerConfig{
+		Compression:  GetEnv("KAFKA_PRODUCER_COMPRESSION", "none"),
+		RequiredAcks: GetEnv("KAFKA_PRODUCER_ACKS", "all"),
+		BatchSize:    100,
+		BatchTimeout: GetDuration("KAFKA_PRODUCER_BATCH_TIMEOUT", 10*time.Millisecond),
+		MaxAttempts:  10,
+		Async:        GetEnv("KAFKA_PRODUCER_ASYNC", "false") == "true",
+		BufferSize:   10000,
+		Workers:      4,
+		Overflow:     GetEnv("KAFKA_PRODUCER_OVERFLOW", "block"),
+		Idempotent:   GetEnv("KAFKA_PRODUCER_IDEMPOTENT", "false") == "true",
+	}
+
+	switch cfg.Compression {
+	case "none", "gzip", "snappy", "lz4", "zstd":
+	default:
+		log.Printf("⚠️ Invalid KAFKA_PRODUCER_COMPRESSION=%q, using none", cfg.Compression)
+		cfg.Compression = "none"
+	}
+	switch cfg.RequiredAcks {
+	case "all", "one", "none":
+	default:
+		log.Printf("⚠️ Invalid KAFKA_PRODUCER_ACKS=%q, using all", cfg.RequiredAcks)
+		cfg.RequiredAcks = "all"
+	}
+	if n, err := strconv.Atoi(GetEnv("KAFKA_PRODUCER_BATCH_SIZE", "100")); err == nil && n > 0 {
+		cfg.BatchSize = n
+	}
+	if n, err := strconv.Atoi(GetEnv("KAFKA_PRODUCER_MAX_ATTEMPTS", "10")); err == nil && n > 0 {
+		cfg.MaxAttempts = n
+	}
+	if n, err := strconv.Atoi(GetEnv("KAFKA_PRODUCER_BUFFER_SIZE", "10000")); err == nil && n > 0 {
+		cfg.BufferSize = n
+	}
+	if n, err := strconv.Atoi(GetEnv("KAFKA_PRODUCER_WORKERS", "4")); err == nil && n > 0 {
+		cfg.Workers = n
+	}
+	switch cfg.Overflow {
+	case "block", "drop", "error":
+	default:
+		log.Printf("⚠️ Invalid KAFKA_PRODUCER_OVERFLOW=%q, using block", cfg.Overflow)
+		cfg.Overflow = "block"
+	}
+	return cfg
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// RedisStreamConfig menyimpan pengaturan backend event Redis Streams (EVENT_BACKEND=redis)
+type RedisStreamConfig struct {
+	MaxLen        int64         // panjang stream maksimum (perkiraan, XADD MAXLEN ~), 0 = tidak dipangkas
+	BatchSize     int64         // jumlah pesan maksimum per XREADGROUP dan per putaran XCLAIM
+	BlockTimeout  time.Duration // berapa lama XREADGROUP menunggu pesan baru
+	ClaimInterval time.Duration // jeda antar pengecekan pesan pending milik consumer lain
+	ClaimMinIdle  time.Duration // pesan pending yang tidak di-ack selama ini diambil alih lewat XCLAIM
+	MaxDeliveries int64         // pengiriman maksimum sebelum pesan dipindah ke {stream}.dlq
+
+	ConsumerIdleTimeout time.Duration // consumer tanpa pesan pending yang idle selama ini dihapus dari group
+}
+
+// LoadRedisStreamConfig membaca REDIS_STREAM_MAX_LEN, REDIS_STREAM_BATCH_SIZE, REDIS_STREAM_BLOCK_TIMEOUT,
+// REDIS_STREAM_CLAIM_INTERVAL, REDIS_STREAM_CLAIM_MIN_IDLE, REDIS_STREAM_MAX_DELIVERIES dan
+// REDIS_STREAM_CONSUMER_IDLE_TIMEOUT
+func LoadRedisStreamConfig() RedisStreamConfig {
+	maxLen, err := strconv.ParseInt(GetEnv("REDIS_STREAM_MAX_LEN", "100000"), 10, 64)
+	if err != nil || maxLen < 0 {
+		maxLen = 100000
+	}
+	batchSize, err := strconv.ParseInt(GetEnv("REDIS_STREAM_BATCH_SIZE", "50"), 10, 64)
+	if err != nil || batchSize <= 0 {
+		batchSize = 50
+	}
+	maxDeliveries, err := strconv.ParseInt(GetEnv("REDIS_STREAM_MAX_DELIVERIES", "5"), 10, 64)
+	if err != nil || maxDeliveries <= 0 {
+		maxDeliveries = 5
+	}
+
+	cfg := RedisStreamConfig{
+		MaxLen:        maxLen,
+		BatchSize:     batchSize,
+		BlockTimeout:  GetDuration("REDIS_STREAM_BLOCK_TIMEOUT", 2*time.Second),
+		ClaimInterval: GetDuration("REDIS_STREAM_CLAIM_INTERVAL", 30*time.Second),
+		ClaimMinIdle:  GetDuration("REDIS_STREAM_CLAIM_MIN_IDLE", time.Minute),
+		MaxDeliveries: maxDeliveries,
+
+		ConsumerIdleTimeout: GetDuration("REDIS_STREAM_CONSUMER_IDLE_TIMEOUT", time.Hour),
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = 2 * time.Second
+	}
+	if cfg.ClaimInterval <= 0 {
+		cfg.ClaimInterval = 30 * time.Second
+	}
+	if cfg.ConsumerIdleTimeout <= 0 {
+		cfg.ConsumerIdleTimeout = time.Hour
+	}
+	return cfg
+}
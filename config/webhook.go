@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// WebhookConfig menyimpan pengaturan pengiriman webhook
+type WebhookConfig struct {
+	Enabled        bool          // menjalankan dispatcher yang mengonsumsi user-events dan repo-events
+	Timeout        time.Duration // batas waktu satu request HTTP ke endpoint langganan
+	MaxAttempts    int           // percobaan per event sebelum pengiriman dianggap gagal
+	InitialBackoff time.Duration // jeda sebelum percobaan kedua, berlipat dua setiap percobaan
+	MaxBackoff     time.Duration // jeda maksimum antar percobaan
+	DisableAfter   int           // event gagal berturut-turut sebelum langganan dinonaktifkan otomatis
+	RetryInterval  time.Duration // jeda antar pengecekan antrian retry
+	RetryBatchSize int           // jumlah retry maksimum per putaran
+
+	AllowPrivateNetworks bool // mengizinkan URL ke loopback/jaringan private, hanya untuk development
+}
+
+// LoadWebhookConfig membaca WEBHOOK_ENABLED, WEBHOOK_TIMEOUT, WEBHOOK_MAX_ATTEMPTS,
+// WEBHOOK_INITIAL_BACKOFF, WEBHOOK_MAX_BACKOFF, WEBHOOK_DISABLE_AFTER, WEBHOOK_RETRY_INTERVAL,
+// WEBHOOK_RETRY_BATCH_SIZE dan WEBHOOK_ALLOW_PRIVATE_NETWORKS
+func LoadWebhookConfig() WebhookConfig {
+	maxAttempts, err := strconv.Atoi(GetEnv("WEBHOOK_MAX_ATTEMPTS", "5"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	disableAfter, err := strconv.Atoi(GetEnv("WEBHOOK_DISABLE_AFTER", "10"))
+	if err != nil || disableAfter <= 0 {
+		disableAfter = 10
+	}
+	retryBatchSize, err := strconv.Atoi(GetEnv("WEBHOOK_RETRY_BATCH_SIZE", "50"))
+	if err != nil || retryBatchSize <= 0 {
+		retryBatchSize = 50
+	}
+	retryInterval := GetDuration("WEBHOOK_RETRY_INTERVAL", time.Second)
+	if retryInterval <= 0 {
+		retryInterval = time.Second
+	}
+
+	return WebhookConfig{
+		Enabled:        GetEnv("WEBHOOK_ENABLED", "true") == "true",
+		Timeout:        GetDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: GetDuration("WEBHOOK_INITIAL_BACKOFF", time.Second),
+		MaxBackoff:     GetDuration("WEBHOOK_MAX_BACKOFF", time.Minute),
+		DisableAfter:   disableAfter,
+		RetryInterval:  retryInterval,
+		RetryBatchSize: retryBatchSize,
+
+		AllowPrivateNetworks: GetEnv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false") == "true",
+	}
+}
@@ -1,10 +1,18 @@
+-- Bootstrap untuk container Postgres baru (docker-entrypoint).
+-- Sumber skema yang sebenarnya ada di dbmigration/migrations dan dijalankan lewat `main migrate up`
+-- atau otomatis saat server start (MIGRATE_ON_STARTUP, default true). File ini hanya membuat
+-- tabel users dan repositories agar seed_data.sql bisa dijalankan; tabel lain (outbox,
+-- event_sequences, webhooks, id_mappings, ...) dibuat oleh migrasi.
+
 -- Table: users
 CREATE TABLE IF NOT EXISTS users (
     id UUID PRIMARY KEY,
     name VARCHAR(100) NOT NULL,
     email VARCHAR(100) UNIQUE NOT NULL,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version BIGINT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP NULL
 );
 
 -- Table: repositories
@@ -15,5 +23,11 @@ CREATE TABLE IF NOT EXISTS repositories (
     url TEXT NOT NULL,
     ai_enabled BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version BIGINT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP NULL
 );
+
+-- Indexes for soft delete filtering
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_repositories_deleted_at ON repositories (deleted_at);
@@ -0,0 +1,10 @@
+// Package dbmigration menyimpan skema PostgreSQL.
+// File di migrations/ di-embed ke binary dan dijalankan oleh internal/migration.
+package dbmigration
+
+import "embed"
+
+// Migrations berisi file migrasi berformat NNNN_nama.up.sql / NNNN_nama.down.sql
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
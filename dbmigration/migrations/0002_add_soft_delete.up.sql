@@ -0,0 +1,7 @@
+-- Soft delete: baris dengan deleted_at terisi dianggap ada di trash
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+
+-- Indexes for soft delete filtering
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_repositories_deleted_at ON repositories (deleted_at);
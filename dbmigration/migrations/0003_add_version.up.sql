@@ -0,0 +1,3 @@
+-- Versi untuk optimistic concurrency (ETag / If-Match)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
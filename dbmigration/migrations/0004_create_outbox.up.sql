@@ -0,0 +1,18 @@
+-- Transactional outbox: event ditulis dalam transaksi yang sama dengan perubahan entitas,
+-- lalu dikirim ke Kafka oleh relay di background
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    topic TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    event_key TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error TEXT NULL,
+    published_at TIMESTAMP NULL
+);
+
+-- Relay hanya membaca baris yang belum terkirim, berurutan berdasarkan id
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at ON outbox (published_at) WHERE published_at IS NOT NULL;
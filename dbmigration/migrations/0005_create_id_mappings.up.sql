@@ -0,0 +1,9 @@
+-- Pemetaan id entitas antara PostgreSQL (UUID) dan MongoDB (ObjectID hex), diisi oleh sync worker
+CREATE TABLE IF NOT EXISTS id_mappings (
+    entity TEXT NOT NULL, -- "user" atau "repository"
+    pg_id UUID NOT NULL,
+    mongo_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entity, pg_id),
+    UNIQUE (entity, mongo_id)
+);
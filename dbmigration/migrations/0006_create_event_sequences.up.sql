@@ -0,0 +1,8 @@
+-- Nomor urut event terakhir per agregat, dinaikkan dalam transaksi yang sama dengan penulisan outbox
+CREATE TABLE IF NOT EXISTS event_sequences (
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    sequence BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (aggregate_type, aggregate_id)
+);
@@ -0,0 +1,2 @@
+-- Relay mengklaim batch outbox dengan lease agar publish ke broker bisa dilakukan di luar transaksi
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP NULL;
@@ -0,0 +1,16 @@
+-- Antrian retry webhook: consumer hanya mencoba sekali, percobaan berikutnya dikirim oleh worker
+-- yang membaca baris dengan next_attempt_at yang sudah lewat
+CREATE TABLE IF NOT EXISTS webhook_retries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    attempt INT NOT NULL, -- nomor percobaan berikutnya
+    next_attempt_at TIMESTAMP NOT NULL,
+    claimed_until TIMESTAMP NULL,
+    last_error TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_retries_next_attempt ON webhook_retries (next_attempt_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_failed_at;
+DROP INDEX IF EXISTS idx_outbox_pending_aggregate;
+DROP INDEX IF EXISTS idx_outbox_pending;
+ALTER TABLE outbox DROP COLUMN IF EXISTS failed_at;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,9 @@
+-- Event yang gagal dikirim OUTBOX_MAX_ATTEMPTS kali ditandai failed_at dan tidak dicoba lagi
+-- oleh relay sampai dikembalikan lewat POST /admin/outbox/failed/requeue
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP NULL;
+
+-- Relay hanya membaca baris yang belum terkirim dan belum gagal permanen
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE published_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_aggregate ON outbox (topic, aggregate_id, id) WHERE published_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_failed_at ON outbox (failed_at) WHERE failed_at IS NOT NULL;
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"golang-crud-clean-arch/internal/entity"
+)
+
+// maxBatchBody membatasi ukuran body request batch
+const maxBatchBody = 10 << 20
+
+// decodeBatch membaca body request batch
+func decodeBatch(w http.ResponseWriter, r *http.Request, dest interface{}) error {
+	return json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody)).Decode(dest)
+}
+
+// batchStatus memilih status HTTP untuk response batch:
+// 200 jika semua berhasil, 422 jika batch atomic dibatalkan, 207 jika sebagian gagal
+func batchStatus(result *entity.BatchResult) int {
+	switch {
+	case result.Failed == 0:
+		return http.StatusOK
+	case result.Atomic:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusMultiStatus
+	}
+}
+
+// batchErrorStatus memetakan error batch: request tidak valid adalah 400, sisanya 500
+func batchErrorStatus(err error) int {
+	if errors.Is(err, entity.ErrInvalidBatch) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// writeBatchResult menulis hasil batch sebagai JSON dengan status yang sesuai
+func writeBatchResult(w http.ResponseWriter, result *entity.BatchResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(batchStatus(result))
+	json.NewEncoder(w).Encode(result)
+}
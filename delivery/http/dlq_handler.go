@@ -0,0 +1,89 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang-crud-clean-arch/internal/kafka"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultDLQLimit adalah jumlah pesan per partition yang ditampilkan jika ?limit= kosong
+const defaultDLQLimit = 50
+
+// DLQHandler menyediakan endpoint admin untuk melihat dan me-redrive dead-letter topic
+type DLQHandler struct {
+	dlq *kafka.DeadLetterQueue
+}
+
+func NewDLQHandler(dlq *kafka.DeadLetterQueue) *DLQHandler {
+	return &DLQHandler{dlq}
+}
+
+// redriveRequest adalah body POST /admin/dlq/{topic}/redrive
+type redriveRequest struct {
+	Messages []kafka.MessageRef `json:"messages"`
+}
+
+// List returns the most recent messages of a dead-letter topic, e.g. GET /admin/dlq/user-events.dlq?limit=20
+func (h *DLQHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := defaultDLQLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	letters, err := h.dlq.List(r.Context(), chi.URLParam(r, "topic"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), dlqErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":    len(letters),
+		"messages": letters,
+	})
+}
+
+// Redrive publishes the selected dead-letter messages back to their original topic
+func (h *DLQHandler) Redrive(w http.ResponseWriter, r *http.Request) {
+	var req redriveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) == 0 {
+		http.Error(w, "messages must contain at least one {partition, offset}", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.dlq.Redrive(r.Context(), chi.URLParam(r, "topic"), req.Messages)
+	if err != nil {
+		http.Error(w, err.Error(), dlqErrorStatus(err))
+		return
+	}
+
+	status := http.StatusOK
+	for _, result := range results {
+		if result.Status != "redriven" {
+			status = http.StatusMultiStatus
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+}
+
+// dlqErrorStatus memetakan error DLQ: topic yang bukan *.dlq adalah 400, sisanya 502 (Kafka)
+func dlqErrorStatus(err error) int {
+	if errors.Is(err, kafka.ErrNotDeadLetterTopic) {
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang-crud-clean-arch/delivery/openapi"
+)
+
+// swaggerUIPage memuat Swagger UI dari CDN dan membaca spesifikasi dari /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="UTF-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1.0" />
+    <title>API Docs</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+  </head>
+  <body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+    <script>
+      window.onload = function () {
+        window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+      };
+    </script>
+  </body>
+</html>
+`
+
+// DocsHandler menyajikan spesifikasi OpenAPI dan Swagger UI
+type DocsHandler struct {
+	doc *openapi.Document
+}
+
+func NewDocsHandler(doc *openapi.Document) *DocsHandler {
+	return &DocsHandler{doc: doc}
+}
+
+// Spec returns the OpenAPI 3 document describing the /pg, /mongo and /health routes
+func (h *DocsHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(h.doc)
+}
+
+// SwaggerUI serves an interactive page for exploring and calling the API
+func (h *DocsHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang-crud-clean-arch/internal/entity"
+)
+
+// errInvalidIfMatch dikembalikan jika header If-Match tidak berisi ETag versi yang dikenali
+var errInvalidIfMatch = errors.New("If-Match must be a version ETag such as \"3\" or *")
+
+// setETag menulis versi entitas sebagai strong ETag, misalnya "3"
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// parseIfMatch membaca header If-Match dan mengembalikan versi yang diharapkan.
+// Header kosong atau "*" berarti tanpa pengecekan versi (0). Header boleh berisi daftar ETag
+// yang dipisah koma; If-Match memakai strong comparison sehingga weak ETag (W/"3") tidak pernah
+// cocok. Jika daftar berisi lebih dari satu versi, current dipanggil untuk membaca versi entitas
+// saat ini dan versi itu dipakai jika ada di daftar, selain itu ErrVersionConflict.
+func parseIfMatch(r *http.Request, current func() (int64, error)) (int64, error) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	if raw == "" || raw == "*" {
+		return 0, nil
+	}
+
+	var versions []int64
+	weak := false
+	for _, tag := range strings.Split(raw, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if strings.HasPrefix(tag, "W/") {
+			weak = true
+			continue
+		}
+		version, err := parseETagVersion(tag)
+		if err != nil {
+			return 0, err
+		}
+		versions = append(versions, version)
+	}
+
+	switch len(versions) {
+	case 0:
+		if weak {
+			// Hanya berisi weak ETag: tidak ada yang lolos strong comparison
+			return 0, entity.ErrVersionConflict
+		}
+		return 0, errInvalidIfMatch
+	case 1:
+		return versions[0], nil
+	}
+
+	version, err := current()
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range versions {
+		if v == version {
+			return version, nil
+		}
+	}
+	return 0, entity.ErrVersionConflict
+}
+
+// parseETagVersion membaca satu strong ETag versi, misalnya "3"
+func parseETagVersion(tag string) (int64, error) {
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil || !strings.HasPrefix(tag, `"`) {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// writeErrorStatus memetakan error versi dan If-Match ke 412/404, selain itu memakai status fallback
+func writeErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, entity.ErrVersionConflict), errors.Is(err, errInvalidIfMatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, entity.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return fallback
+	}
+}
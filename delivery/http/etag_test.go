@@ -0,0 +1,89 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang-crud-clean-arch/internal/entity"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	errLookup := errors.New("lookup failed")
+
+	tests := []struct {
+		name       string
+		header     string
+		current    int64
+		currentErr error
+		want       int64
+		wantErr    error
+	}{
+		{name: "absent", header: "", want: 0},
+		{name: "any", header: "*", want: 0},
+		{name: "single", header: `"3"`, want: 3},
+		{name: "single with spaces", header: ` "3" `, want: 3},
+		{name: "weak only", header: `W/"3"`, wantErr: entity.ErrVersionConflict},
+		{name: "weak ignored in list", header: `W/"2", "3"`, want: 3},
+		{name: "list contains current", header: `"2", "3", "4"`, current: 3, want: 3},
+		{name: "list misses current", header: `"2","4"`, current: 3, wantErr: entity.ErrVersionConflict},
+		{name: "list with empty elements", header: `, "5" ,`, want: 5},
+		{name: "list lookup fails", header: `"2", "3"`, currentErr: errLookup, wantErr: errLookup},
+		{name: "list entity missing", header: `"2", "3"`, currentErr: entity.ErrNotFound, wantErr: entity.ErrNotFound},
+		{name: "unquoted", header: `3`, wantErr: errInvalidIfMatch},
+		{name: "not a version", header: `"abc"`, wantErr: errInvalidIfMatch},
+		{name: "zero", header: `"0"`, wantErr: errInvalidIfMatch},
+		{name: "negative", header: `"-1"`, wantErr: errInvalidIfMatch},
+		{name: "star in list", header: `*, "3"`, wantErr: errInvalidIfMatch},
+		{name: "only commas", header: `,,`, wantErr: errInvalidIfMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/users/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			calls := 0
+			current := func() (int64, error) {
+				calls++
+				return tt.current, tt.currentErr
+			}
+
+			got, err := parseIfMatch(r, current)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseIfMatch(%q) error = %v, want %v", tt.header, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseIfMatch(%q) = %d, want %d", tt.header, got, tt.want)
+			}
+			if tt.current == 0 && tt.currentErr == nil && calls != 0 {
+				t.Errorf("parseIfMatch(%q) read the current version without a list", tt.header)
+			}
+		})
+	}
+}
+
+func TestWriteErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "version conflict", err: entity.ErrVersionConflict, want: http.StatusPreconditionFailed},
+		{name: "wrapped version conflict", err: fmt.Errorf("update: %w", entity.ErrVersionConflict), want: http.StatusPreconditionFailed},
+		{name: "invalid If-Match", err: errInvalidIfMatch, want: http.StatusPreconditionFailed},
+		{name: "not found", err: entity.ErrNotFound, want: http.StatusNotFound},
+		{name: "other", err: errors.New("boom"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := writeErrorStatus(tt.err, http.StatusInternalServerError); got != tt.want {
+				t.Errorf("writeErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-crud-clean-arch/internal/entity"
+)
+
+// parseRepositoryFilter membaca filter listing repository dari query string:
+// user_id, ai_enabled, name_prefix, name_contains, created_from/created_to,
+// updated_from/updated_to (RFC3339 atau YYYY-MM-DD) dan sort=field,-field
+func parseRepositoryFilter(r *http.Request) (entity.RepositoryFilter, error) {
+	q := r.URL.Query()
+	filter := entity.RepositoryFilter{
+		UserID:       q.Get("user_id"),
+		NamePrefix:   q.Get("name_prefix"),
+		NameContains: q.Get("name_contains"),
+	}
+
+	if raw := q.Get("ai_enabled"); raw != "" {
+		aiEnabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("%w: ai_enabled must be a boolean", entity.ErrInvalidFilter)
+		}
+		filter.AIEnabled = &aiEnabled
+	}
+
+	timeParams := []struct {
+		name string
+		dest **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"updated_from", &filter.UpdatedFrom},
+		{"updated_to", &filter.UpdatedTo},
+	}
+	for _, p := range timeParams {
+		raw := q.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		t, err := parseTimeParam(raw)
+		if err != nil {
+			return filter, fmt.Errorf("%w: %s must be RFC3339 or YYYY-MM-DD", entity.ErrInvalidFilter, p.name)
+		}
+		*p.dest = &t
+	}
+
+	sort, err := entity.ParseSort(q.Get("sort"), entity.RepositorySortFields)
+	if err != nil {
+		return filter, err
+	}
+	filter.Sort = sort
+
+	deleted, err := parseDeletedScope(r)
+	if err != nil {
+		return filter, err
+	}
+	filter.Deleted = deleted
+
+	return filter, nil
+}
+
+// parseUserFilter membaca filter listing user dari query string (?include_deleted=)
+func parseUserFilter(r *http.Request) (entity.UserFilter, error) {
+	deleted, err := parseDeletedScope(r)
+	return entity.UserFilter{Deleted: deleted}, err
+}
+
+// parseDeletedScope membaca ?include_deleted=true untuk ikut menampilkan data yang di-soft-delete
+func parseDeletedScope(r *http.Request) (entity.DeletedScope, error) {
+	raw := r.URL.Query().Get("include_deleted")
+	if raw == "" {
+		return entity.DeletedExcluded, nil
+	}
+
+	includeDeleted, err := strconv.ParseBool(raw)
+	if err != nil {
+		return entity.DeletedExcluded, fmt.Errorf("%w: include_deleted must be a boolean", entity.ErrInvalidFilter)
+	}
+	if includeDeleted {
+		return entity.DeletedIncluded, nil
+	}
+	return entity.DeletedExcluded, nil
+}
+
+// parseTimeParam menerima RFC3339 atau tanggal saja (tengah malam UTC)
+func parseTimeParam(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"time"
 
+	"golang-crud-clean-arch/internal/cache"
+
 	"github.com/go-redis/redis/v8"
 	"github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -20,15 +22,17 @@ type HealthHandler struct {
 	PostgresDB           *sql.DB
 	KafkaAddr            string
 	JaegerTracerProvider *trace.TracerProvider
+	Caches               []*cache.Cache
 }
 
-func NewHealthHandler(mongoClient *mongo.Client, redisClient *redis.Client, postgresDB *sql.DB, kafkaAddr string, tracer *trace.TracerProvider) *HealthHandler {
+func NewHealthHandler(mongoClient *mongo.Client, redisClient *redis.Client, postgresDB *sql.DB, kafkaAddr string, tracer *trace.TracerProvider, caches ...*cache.Cache) *HealthHandler {
 	return &HealthHandler{
 		MongoClient:          mongoClient,
 		RedisClient:          redisClient,
 		PostgresDB:           postgresDB,
 		KafkaAddr:            kafkaAddr,
 		JaegerTracerProvider: tracer,
+		Caches:               caches,
 	}
 }
 
@@ -68,9 +72,10 @@ func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
 		overallStatus = http.StatusServiceUnavailable
 	}
 
-	// Check Kafka
-	kafkaConn, err := kafka.DialContext(ctx, "tcp", h.KafkaAddr)
-	if err != nil {
+	// Check Kafka (KafkaAddr kosong berarti EVENT_BACKEND=memory)
+	if h.KafkaAddr == "" {
+		status["kafka"] = "disabled"
+	} else if kafkaConn, err := kafka.DialContext(ctx, "tcp", h.KafkaAddr); err != nil {
 		status["kafka"] = "unreachable"
 		overallStatus = http.StatusServiceUnavailable
 	} else {
@@ -94,3 +99,16 @@ func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
 		"status": status,
 	})
 }
+
+// CacheStats melaporkan jumlah cache hit/miss per namespace backend
+func (h *HealthHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	stats := make([]cache.Stats, 0, len(h.Caches))
+	for _, c := range h.Caches {
+		stats = append(stats, c.Stats())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"caches": stats,
+	})
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"golang-crud-clean-arch/internal/outbox"
+)
+
+// defaultOutboxFailedLimit adalah jumlah event yang ditampilkan jika ?limit= kosong
+const defaultOutboxFailedLimit = 50
+
+// OutboxHandler menyediakan endpoint admin untuk event outbox yang gagal permanen
+type OutboxHandler struct {
+	store outbox.FailedStore
+}
+
+func NewOutboxHandler(store outbox.FailedStore) *OutboxHandler {
+	return &OutboxHandler{store}
+}
+
+// requeueRequest adalah body POST /admin/outbox/failed/requeue; ids kosong berarti semua
+type requeueRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// ListFailed returns outbox events the relay gave up on, e.g. GET /admin/outbox/failed?limit=20
+func (h *OutboxHandler) ListFailed(w http.ResponseWriter, r *http.Request) {
+	limit := defaultOutboxFailedLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	msgs, err := h.store.ListFailed(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":  len(msgs),
+		"events": msgs,
+	})
+}
+
+// Requeue puts failed outbox events back in the queue; an empty body requeues all of them
+func (h *OutboxHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	var req requeueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.store.Requeue(r.Context(), req.IDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"requeued": n})
+}
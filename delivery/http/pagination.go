@@ -0,0 +1,41 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang-crud-clean-arch/internal/entity"
+)
+
+// parsePageRequest membaca parameter pagination ?limit=&cursor=&include_total= dari query string
+func parsePageRequest(r *http.Request) (entity.PageRequest, error) {
+	q := r.URL.Query()
+	page := entity.PageRequest{Cursor: q.Get("cursor")}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return page, errors.New("limit must be a positive integer")
+		}
+		page.Limit = limit
+	}
+
+	if raw := q.Get("include_total"); raw != "" {
+		withTotal, err := strconv.ParseBool(raw)
+		if err != nil {
+			return page, errors.New("include_total must be a boolean")
+		}
+		page.WithTotal = withTotal
+	}
+
+	return page.Normalize(), nil
+}
+
+// listErrorStatus memetakan error listing ke status HTTP: cursor/filter invalid adalah 400, sisanya 500
+func listErrorStatus(err error) int {
+	if errors.Is(err, entity.ErrInvalidCursor) || errors.Is(err, entity.ErrInvalidFilter) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/patch"
+)
+
+// maxPatchBody membatasi ukuran body PATCH
+const maxPatchBody = 1 << 20
+
+// readPatch membaca body PATCH sesuai Content-Type (merge patch atau JSON patch)
+func readPatch(w http.ResponseWriter, r *http.Request) (patch.Patch, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBody))
+	if err != nil {
+		return nil, errors.Join(patch.ErrInvalidPatch, err)
+	}
+	return patch.Parse(r.Header.Get("Content-Type"), body)
+}
+
+// patchErrorStatus memetakan error PATCH ke status HTTP; error validasi menjadi 422,
+// error yang tidak dikenal (database tidak bisa dihubungi) menjadi 500
+func patchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, patch.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, patch.ErrInvalidPatch):
+		return http.StatusBadRequest
+	case errors.Is(err, patch.ErrTestFailed):
+		return http.StatusConflict
+	default:
+		return writeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -39,13 +40,49 @@ func (h *RepositoryHandler) Get(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Repository not found", http.StatusNotFound)
 		return
 	}
+	setETag(w, repo.Version)
 	json.NewEncoder(w).Encode(repo)
 }
 
 func (h *RepositoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	repos, err := h.usecase.GetAllRepositories(r.Context())
+	page, err := parsePageRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseRepositoryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repos, err := h.usecase.GetAllRepositories(r.Context(), filter, page)
+	if err != nil {
+		http.Error(w, err.Error(), listErrorStatus(err))
+		return
+	}
+	json.NewEncoder(w).Encode(repos)
+}
+
+// Trash mengembalikan satu halaman repository yang sudah di-soft-delete
+func (h *RepositoryHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseRepositoryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Deleted = entity.DeletedOnly
+
+	repos, err := h.usecase.GetAllRepositories(r.Context(), filter, page)
+	if err != nil {
+		http.Error(w, err.Error(), listErrorStatus(err))
 		return
 	}
 	json.NewEncoder(w).Encode(repos)
@@ -54,25 +91,113 @@ func (h *RepositoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 func (h *RepositoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
+	// Versi yang diharapkan hanya diambil dari If-Match, bukan dari body
+	expectedVersion, err := parseIfMatch(r, h.currentVersion(r.Context(), id))
+	if err != nil {
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
 	var repo entity.Repository
 	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
 		http.Error(w, "Invalid payload", http.StatusBadRequest)
 		return
 	}
 	repo.ID = id
+	repo.Version = expectedVersion
 
 	if err := h.usecase.UpdateRepository(r.Context(), &repo); err != nil {
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusUnprocessableEntity))
 		return
 	}
+	setETag(w, repo.Version)
+	json.NewEncoder(w).Encode(repo)
+}
+
+// Patch menerapkan perubahan parsial (merge patch atau JSON patch) ke repository
+func (h *RepositoryHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	expectedVersion, err := parseIfMatch(r, h.currentVersion(r.Context(), id))
+	if err != nil {
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	p, err := readPatch(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), patchErrorStatus(err))
+		return
+	}
+
+	repo, err := h.usecase.PatchRepository(r.Context(), id, p, expectedVersion)
+	if err != nil {
+		http.Error(w, err.Error(), patchErrorStatus(err))
+		return
+	}
+	setETag(w, repo.Version)
 	json.NewEncoder(w).Encode(repo)
 }
 
 func (h *RepositoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if err := h.usecase.DeleteRepository(r.Context(), id); err != nil {
+
+	expectedVersion, err := parseIfMatch(r, h.currentVersion(r.Context(), id))
+	if err != nil {
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if err := h.usecase.DeleteRepository(r.Context(), id, expectedVersion); err != nil {
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusNotFound))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Batch menjalankan banyak operasi create/update/delete repository dalam satu request
+func (h *RepositoryHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var req entity.RepositoryBatchRequest
+	if err := decodeBatch(w, r, &req); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.usecase.BatchRepositories(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), batchErrorStatus(err))
+		return
+	}
+	writeBatchResult(w, result)
+}
+
+func (h *RepositoryHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	repo, err := h.usecase.RestoreRepository(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Repository not found in trash", http.StatusNotFound)
+		return
+	}
+	setETag(w, repo.Version)
+	json.NewEncoder(w).Encode(repo)
+}
+
+func (h *RepositoryHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.usecase.PurgeRepository(r.Context(), id); err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// currentVersion mengembalikan fungsi pembaca versi repository saat ini untuk daftar If-Match
+func (h *RepositoryHandler) currentVersion(ctx context.Context, id string) func() (int64, error) {
+	return func() (int64, error) {
+		repo, err := h.usecase.GetRepository(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return repo.Version, nil
+	}
+}
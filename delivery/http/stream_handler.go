@@ -0,0 +1,222 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang-crud-clean-arch/internal/stream"
+
+	"golang.org/x/net/websocket"
+)
+
+// StreamHandler meneruskan event user dan repository ke browser lewat SSE dan WebSocket
+type StreamHandler struct {
+	hub       *stream.Hub
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(hub *stream.Hub, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{hub: hub, heartbeat: heartbeat}
+}
+
+// liveMessage adalah pesan WebSocket: type "event", "heartbeat", "reset" atau "error"
+type liveMessage struct {
+	Type  string      `json:"type"`
+	ID    string      `json:"id,omitempty"`
+	Event interface{} `json:"event,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// SSE streams events as Server-Sent Events, e.g. GET /events/stream?types=user.*&entity=42.
+// Each event carries its buffer id, so browsers resume from Last-Event-ID after reconnecting.
+// A "reset" event means events were missed and the client should reload its data.
+func (h *StreamHandler) SSE(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	client, replay, truncated, err := h.hub.Subscribe(r.Context(), parseStreamFilter(r), lastEventID)
+	if err != nil {
+		http.Error(w, err.Error(), streamErrorStatus(err))
+		return
+	}
+	defer h.hub.Unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Batas waktu tulis per pesan agar koneksi yang macet tidak menahan handler selamanya
+	rc := http.NewResponseController(w)
+	write := func(format string, args ...interface{}) error {
+		rc.SetWriteDeadline(time.Now().Add(h.heartbeat))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	writeEntry := func(entry stream.Entry) error {
+		data, err := json.Marshal(entry.Event)
+		if err != nil {
+			return err
+		}
+		return write("id: %s\nevent: %s\ndata: %s\n\n", entry.ID, entry.Event.Type, data)
+	}
+
+	if truncated {
+		if err := write("event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+	last := lastEventID
+	for _, entry := range replay {
+		if err := writeEntry(entry); err != nil {
+			return
+		}
+		last = entry.ID
+	}
+	if err := write(": connected\n\n"); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if err := write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		case entry, ok := <-client.Events():
+			if !ok {
+				data, _ := json.Marshal(map[string]string{"error": client.Err().Error()})
+				write("event: error\ndata: %s\n\n", data)
+				return
+			}
+			if last != "" && stream.CompareID(entry.ID, last) <= 0 {
+				continue
+			}
+			if err := writeEntry(entry); err != nil {
+				return
+			}
+			last = entry.ID
+		}
+	}
+}
+
+// WebSocket streams the same events as SSE over a WebSocket, e.g. /events/ws?types=repo.*&last_event_id=...
+// Every message is a JSON object with type "event", "heartbeat", "reset" or "error".
+func (h *StreamHandler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	websocket.Handler(h.serveWebSocket).ServeHTTP(w, r)
+}
+
+// serveWebSocket menjalankan satu koneksi WebSocket sampai client menutupnya atau diputus hub
+func (h *StreamHandler) serveWebSocket(ws *websocket.Conn) {
+	defer ws.Close()
+	r := ws.Request()
+
+	// Koneksi yang di-hijack tidak membawa context request, jadi penutupan dideteksi lewat pembacaan
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		defer cancel()
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	lastEventID := r.URL.Query().Get("last_event_id")
+	client, replay, truncated, err := h.hub.Subscribe(ctx, parseStreamFilter(r), lastEventID)
+	if err != nil {
+		websocket.JSON.Send(ws, liveMessage{Type: "error", Error: err.Error()})
+		return
+	}
+	defer h.hub.Unsubscribe(client)
+
+	send := func(msg liveMessage) error {
+		ws.SetWriteDeadline(time.Now().Add(h.heartbeat))
+		return websocket.JSON.Send(ws, msg)
+	}
+
+	if truncated {
+		if err := send(liveMessage{Type: "reset"}); err != nil {
+			return
+		}
+	}
+	last := lastEventID
+	for _, entry := range replay {
+		if err := send(liveMessage{Type: "event", ID: entry.ID, Event: entry.Event}); err != nil {
+			return
+		}
+		last = entry.ID
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := send(liveMessage{Type: "heartbeat"}); err != nil {
+				return
+			}
+		case entry, ok := <-client.Events():
+			if !ok {
+				send(liveMessage{Type: "error", Error: client.Err().Error()})
+				return
+			}
+			if last != "" && stream.CompareID(entry.ID, last) <= 0 {
+				continue
+			}
+			if err := send(liveMessage{Type: "event", ID: entry.ID, Event: entry.Event}); err != nil {
+				return
+			}
+			last = entry.ID
+		}
+	}
+}
+
+// parseStreamFilter membaca filter koneksi dari query string: types dan entity, masing-masing
+// dipisah koma atau diulang (types=user.*,repo.created&entity=42)
+func parseStreamFilter(r *http.Request) stream.Filter {
+	q := r.URL.Query()
+	return stream.Filter{
+		Types:     splitParam(q["types"]),
+		EntityIDs: splitParam(q["entity"]),
+	}
+}
+
+// splitParam menggabungkan nilai query yang diulang dan dipisah koma, tanpa nilai kosong
+func splitParam(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// streamErrorStatus memetakan error Subscribe ke status HTTP
+func streamErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, stream.ErrInvalidEventID):
+		return http.StatusBadRequest
+	case errors.Is(err, stream.ErrHubClosed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
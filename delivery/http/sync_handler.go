@@ -0,0 +1,40 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/usecase"
+)
+
+// SyncHandler menyediakan laporan rekonsiliasi data antara PostgreSQL dan MongoDB
+type SyncHandler struct {
+	usecase *usecase.SyncUsecase
+}
+
+func NewSyncHandler(u *usecase.SyncUsecase) *SyncHandler {
+	return &SyncHandler{u}
+}
+
+// Report compares active records of both backends, e.g. GET /admin/sync/report?entity=repository
+func (h *SyncHandler) Report(w http.ResponseWriter, r *http.Request) {
+	entityName := r.URL.Query().Get("entity")
+	if entityName == "" {
+		entityName = entity.SyncEntityUser
+	}
+
+	report, err := h.usecase.Report(r.Context(), entityName)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, entity.ErrInvalidFilter) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
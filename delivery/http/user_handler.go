@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -14,14 +15,16 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type UserHandler struct {
-	usecase *usecase.UserUsecase
+	usecase     *usecase.UserUsecase
+	repoUsecase *usecase.RepositoryUsecase
 }
 
-func NewUserHandler(usecase *usecase.UserUsecase) *UserHandler {
-	return &UserHandler{usecase}
+func NewUserHandler(usecase *usecase.UserUsecase, repoUsecase *usecase.RepositoryUsecase) *UserHandler {
+	return &UserHandler{usecase, repoUsecase}
 }
 
 // CreateUser creates a new user in the database
@@ -79,7 +82,8 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	span.SetAttributes(attribute.String("user.email", user.Email))
 	span.SetStatus(codes.Ok, "User fetched")
 
-	// Return the user details
+	// Return the user details with its version as ETag
+	setETag(w, user.Version)
 	json.NewEncoder(w).Encode(user)
 }
 
@@ -92,6 +96,15 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	span.SetAttributes(attribute.String("user.id", id))
 
+	// The expected version only comes from If-Match, never from the body
+	expectedVersion, err := parseIfMatch(r, h.currentVersion(ctx, id))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "If-Match failed")
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
 	var user entity.User
 	// Decode the incoming user data for updating
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
@@ -103,18 +116,61 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	// Set the user ID and pass to use case for updating
 	user.ID = id
+	user.Version = expectedVersion
 	span.SetAttributes(attribute.String("user.email", user.Email))
 
 	// Use case to update the user in the database
 	if err := h.usecase.UpdateUser(ctx, &user); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "UpdateUser failed")
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusUnprocessableEntity))
 		return
 	}
 
 	span.SetStatus(codes.Ok, "User updated")
-	// Return the updated user
+	// Return the updated user with the new version as ETag
+	setETag(w, user.Version)
+	json.NewEncoder(w).Encode(user)
+}
+
+// PatchUser applies a partial update (merge patch or JSON patch) to a user
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	tr := otel.Tracer("user-handler")
+	ctx, span := tr.Start(r.Context(), "PatchUserHandler")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	span.SetAttributes(attribute.String("user.id", id))
+
+	expectedVersion, err := parseIfMatch(r, h.currentVersion(ctx, id))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "If-Match failed")
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	// Decode the patch document according to its Content-Type
+	p, err := readPatch(w, r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid patch")
+		http.Error(w, err.Error(), patchErrorStatus(err))
+		return
+	}
+
+	// Use case to apply the patch to the stored user
+	user, err := h.usecase.PatchUser(ctx, id, p, expectedVersion)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "PatchUser failed")
+		http.Error(w, err.Error(), patchErrorStatus(err))
+		return
+	}
+
+	span.SetStatus(codes.Ok, "User patched")
+	// Return the patched user with the new version as ETag
+	setETag(w, user.Version)
 	json.NewEncoder(w).Encode(user)
 }
 
@@ -127,11 +183,19 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	span.SetAttributes(attribute.String("user.id", id))
 
+	expectedVersion, err := parseIfMatch(r, h.currentVersion(ctx, id))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "If-Match failed")
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
 	// Use case to delete the user from the database
-	if err := h.usecase.DeleteUser(ctx, id); err != nil {
+	if err := h.usecase.DeleteUser(ctx, id, expectedVersion); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "DeleteUser failed")
-		http.Error(w, err.Error(), http.StatusNotFound)
+		http.Error(w, err.Error(), writeErrorStatus(err, http.StatusNotFound))
 		return
 	}
 
@@ -139,28 +203,231 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetAllUsers fetches all users from the database
+// GetAllUsers fetches one page of users from the database
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	tr := otel.Tracer("user-handler")
 	ctx, span := tr.Start(r.Context(), "GetAllUsersHandler")
 	defer span.End()
 
-	// Use case to fetch all users from the database
-	users, err := h.usecase.GetAllUsers(ctx)
+	// Parse filter parameters (?include_deleted=)
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid filter")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.listUsers(w, r.WithContext(ctx), filter)
+}
+
+// TrashUsers fetches one page of soft-deleted users
+func (h *UserHandler) TrashUsers(w http.ResponseWriter, r *http.Request) {
+	tr := otel.Tracer("user-handler")
+	ctx, span := tr.Start(r.Context(), "TrashUsersHandler")
+	defer span.End()
+
+	h.listUsers(w, r.WithContext(ctx), entity.UserFilter{Deleted: entity.DeletedOnly})
+}
+
+// listUsers writes one page of users matching the filter
+func (h *UserHandler) listUsers(w http.ResponseWriter, r *http.Request, filter entity.UserFilter) {
+	span := trace.SpanFromContext(r.Context())
+
+	// Parse pagination parameters (?limit=&cursor=&include_total=)
+	page, err := parsePageRequest(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid pagination")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Use case to fetch a page of users from the database
+	users, err := h.usecase.GetAllUsers(r.Context(), filter, page)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "GetAllUsers failed")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), listErrorStatus(err))
 		return
 	}
 
-	span.SetAttributes(attribute.Int("user.count", len(users)))
+	span.SetAttributes(attribute.Int("user.count", len(users.Data)))
 	span.SetStatus(codes.Ok, "Users fetched")
 
-	// Return the list of users
+	// Return the page of users with next_cursor
 	json.NewEncoder(w).Encode(users)
 }
 
+// BatchUsers applies many create/update/delete operations in one request
+func (h *UserHandler) BatchUsers(w http.ResponseWriter, r *http.Request) {
+	tr := otel.Tracer("user-handler")
+	ctx, span := tr.Start(r.Context(), "BatchUsersHandler")
+	defer span.End()
+
+	var req entity.UserBatchRequest
+	// Decode the list of operations
+	if err := decodeBatch(w, r, &req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid JSON")
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	span.SetAttributes(attribute.Int("batch.size", len(req.Operations)))
+
+	// Use case to apply the batch in one transaction / bulk write
+	result, err := h.usecase.BatchUsers(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "BatchUsers failed")
+		http.Error(w, err.Error(), batchErrorStatus(err))
+		return
+	}
+
+	span.SetStatus(codes.Ok, "Batch applied")
+	// Return per-item results
+	writeBatchResult(w, result)
+}
+
+// RestoreUser restores a soft-deleted user
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	tr := otel.Tracer("user-handler")
+	ctx, span := tr.Start(r.Context(), "RestoreUserHandler")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	span.SetAttributes(attribute.String("user.id", id))
+
+	// Use case to restore the user from the trash
+	user, err := h.usecase.RestoreUser(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "RestoreUser failed")
+		http.Error(w, "User not found in trash", http.StatusNotFound)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "User restored")
+	// Return the restored user
+	setETag(w, user.Version)
+	json.NewEncoder(w).Encode(user)
+}
+
+// PurgeUser permanently deletes a user, including soft-deleted ones
+func (h *UserHandler) PurgeUser(w http.ResponseWriter, r *http.Request) {
+	tr := otel.Tracer("user-handler")
+	ctx, span := tr.Start(r.Context(), "PurgeUserHandler")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	span.SetAttributes(attribute.String("user.id", id))
+
+	// Use case to permanently delete the user
+	if err := h.usecase.PurgeUser(ctx, id); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "PurgeUser failed")
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "User purged")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserRepositories fetches one page of repositories owned by a user
+func (h *UserHandler) GetUserRepositories(w http.ResponseWriter, r *http.Request) {
+	tr := otel.Tracer("user-handler")
+	ctx, span := tr.Start(r.Context(), "GetUserRepositoriesHandler")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	span.SetAttributes(attribute.String("user.id", id))
+
+	// Make sure the user exists before listing its repositories
+	user, err := h.usecase.GetUser(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "User not found")
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid pagination")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseRepositoryFilter(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid filter")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.UserID = user.GetIDString()
+
+	repos, err := h.repoUsecase.GetAllRepositories(ctx, filter, page)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "GetUserRepositories failed")
+		http.Error(w, err.Error(), listErrorStatus(err))
+		return
+	}
+
+	span.SetAttributes(attribute.Int("repository.count", len(repos.Data)))
+	span.SetStatus(codes.Ok, "User repositories fetched")
+
+	// Return the page of repositories with next_cursor
+	json.NewEncoder(w).Encode(repos)
+}
+
+// CreateUserRepository creates a new repository owned by a user
+func (h *UserHandler) CreateUserRepository(w http.ResponseWriter, r *http.Request) {
+	tr := otel.Tracer("user-handler")
+	ctx, span := tr.Start(r.Context(), "CreateUserRepositoryHandler")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	span.SetAttributes(attribute.String("user.id", id))
+
+	// Make sure the user exists before creating a repository for it
+	user, err := h.usecase.GetUser(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "User not found")
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	var repo entity.Repository
+	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid JSON")
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	// The owner always comes from the URL, not from the payload
+	repo.UserID = user.GetIDString()
+
+	if err := h.repoUsecase.CreateRepository(ctx, &repo); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "CreateUserRepository failed")
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	span.SetAttributes(attribute.String("repository.id", fmt.Sprintf("%v", repo.ID)))
+	span.SetStatus(codes.Ok, "User repository created")
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(repo)
+}
+
 // TestCircuitBreaker tests the circuit breaker functionality
 func (h *UserHandler) TestCircuitBreaker(w http.ResponseWriter, r *http.Request) {
 	tr := otel.Tracer("user-handler")
@@ -169,7 +436,7 @@ func (h *UserHandler) TestCircuitBreaker(w http.ResponseWriter, r *http.Request)
 
 	// Simulating multiple attempts to fetch all users to test the circuit breaker
 	for i := 1; i <= 5; i++ {
-		_, err := h.usecase.GetAllUsers(ctx)
+		_, err := h.usecase.GetAllUsers(ctx, entity.UserFilter{}, entity.PageRequest{}.Normalize())
 		if err != nil {
 			log.Printf("❌ Attempt %d failed: %v\n", i, err)
 		} else {
@@ -182,3 +449,14 @@ func (h *UserHandler) TestCircuitBreaker(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Test circuit breaker complete. Check logs."))
 }
+
+// currentVersion returns a func that reads the user's current version for If-Match lists
+func (h *UserHandler) currentVersion(ctx context.Context, id string) func() (int64, error) {
+	return func() (int64, error) {
+		user, err := h.usecase.GetUser(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return user.Version, nil
+	}
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/usecase"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultDeliveryLimit adalah jumlah log pengiriman yang ditampilkan jika ?limit= kosong
+const defaultDeliveryLimit = 50
+
+// WebhookHandler menyediakan CRUD langganan webhook dan log pengirimannya
+type WebhookHandler struct {
+	usecase *usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(u *usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{u}
+}
+
+// webhookRequest adalah body POST /webhooks dan PUT /webhooks/{id}
+type webhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active"`
+}
+
+// Create registers a subscription; the response is the only time the secret is returned
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	subscription := entity.WebhookSubscription{URL: req.URL, Secret: req.Secret, EventTypes: req.EventTypes}
+	if err := h.usecase.Create(r.Context(), &subscription); err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// List returns all subscriptions, including disabled ones
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.usecase.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":         len(subscriptions),
+		"subscriptions": subscriptions,
+	})
+}
+
+// Get returns one subscription without its secret
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.usecase.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+	subscription.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// Update replaces url, event types and active flag; an empty secret keeps the current one.
+// Setting active to true re-enables a subscription that was disabled after repeated failures.
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	subscription := entity.WebhookSubscription{
+		ID:         chi.URLParam(r, "id"),
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		Active:     req.Active == nil || *req.Active,
+	}
+	if err := h.usecase.Update(r.Context(), &subscription); err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+	subscription.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// Delete removes a subscription and its delivery log
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries returns the most recent delivery attempts of a subscription, e.g. GET /webhooks/{id}/deliveries?limit=20
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	limit := defaultDeliveryLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.usecase.Deliveries(r.Context(), chi.URLParam(r, "id"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":      len(deliveries),
+		"deliveries": deliveries,
+	})
+}
+
+// webhookErrorStatus memetakan error usecase webhook ke status HTTP
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	case errors.Is(err, entity.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,110 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateRequest(t *testing.T) {
+	validUser := `{"name":"Budi","email":"budi@example.com"}`
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantFields  []string // field di details response 422
+	}{
+		{name: "valid user", method: http.MethodPost, path: "/pg/users", body: validUser, wantStatus: http.StatusOK},
+		{name: "content type with charset", method: http.MethodPost, path: "/pg/users", contentType: "application/json; charset=utf-8", body: validUser, wantStatus: http.StatusOK},
+		{name: "missing content type is still validated", method: http.MethodPost, path: "/pg/users", contentType: "-", body: `{"name":"Budi"}`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"email"}},
+		{
+			name:   "read-only fields are ignored",
+			method: http.MethodPut, path: "/mongo/users/42",
+			body:       `{"id":"other","version":"not a number","created_at":"yesterday","deleted_at":null,"name":"Budi","email":"budi@example.com"}`,
+			wantStatus: http.StatusOK,
+		},
+		{name: "unknown field", method: http.MethodPost, path: "/pg/users", body: `{"name":"Budi","email":"budi@example.com","nickname":"b"}`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"nickname"}},
+		{name: "missing required fields", method: http.MethodPost, path: "/pg/users", body: `{}`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"name", "email"}},
+		{name: "wrong types and formats", method: http.MethodPost, path: "/pg/users", body: `{"name":"","email":"not-an-email"}`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"email", "name"}},
+		{name: "null for non-nullable field", method: http.MethodPost, path: "/pg/users", body: `{"name":null,"email":"budi@example.com"}`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"name"}},
+		{name: "body is not an object", method: http.MethodPost, path: "/pg/users", body: `[]`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"body"}},
+		{name: "malformed json", method: http.MethodPost, path: "/pg/users", body: `{"name":`, wantStatus: http.StatusBadRequest},
+		{name: "trailing json", method: http.MethodPost, path: "/pg/users", body: validUser + `{}`, wantStatus: http.StatusBadRequest},
+		{
+			name:   "repository url must be absolute",
+			method: http.MethodPost, path: "/pg/repositories",
+			body:       `{"user_id":"u1","name":"api","url":"/relative","ai_enabled":"yes"}`,
+			wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"ai_enabled", "url"},
+		},
+		{
+			name:   "batch reports nested fields",
+			method: http.MethodPost, path: "/pg/users:batch",
+			body:       `{"operations":[{"op":"create","data":{"name":"Budi","email":"budi@example.com"}},{"op":"rename","data":{"name":"Budi","email":"x","extra":1}}]}`,
+			wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"operations[1].op", "operations[1].data.email", "operations[1].data.extra"},
+		},
+		{name: "empty batch", method: http.MethodPost, path: "/mongo/users:batch", body: `{"operations":[]}`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"operations"}},
+		{name: "merge patch is validated", method: http.MethodPatch, path: "/pg/users/42", contentType: "application/merge-patch+json", body: `{"role":"admin"}`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"role"}},
+		{name: "json patch is validated", method: http.MethodPatch, path: "/pg/users/42", contentType: "application/json-patch+json", body: `[{"op":"replace"}]`, wantStatus: http.StatusUnprocessableEntity, wantFields: []string{"[0].path"}},
+		{name: "undeclared patch media type is left to the handler", method: http.MethodPatch, path: "/pg/users/42", contentType: "text/plain", body: `not json`, wantStatus: http.StatusOK},
+		{name: "operation without request body", method: http.MethodGet, path: "/pg/users", wantStatus: http.StatusOK},
+		{name: "route not in spec", method: http.MethodPost, path: "/internal/reindex", body: `not json`, wantStatus: http.StatusOK},
+	}
+
+	doc := Spec()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forwarded string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				forwarded = string(body)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			switch tt.contentType {
+			case "":
+				req.Header.Set("Content-Type", "application/json")
+			case "-":
+			default:
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			ValidateRequest(doc)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			// Handler harus menerima body yang sama persis dengan yang divalidasi
+			if tt.wantStatus == http.StatusOK && forwarded != tt.body {
+				t.Fatalf("handler body = %q, want %q", forwarded, tt.body)
+			}
+			if tt.wantStatus != http.StatusUnprocessableEntity {
+				return
+			}
+
+			var resp validationError
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode 422 body: %v", err)
+			}
+			got := map[string]bool{}
+			for _, d := range resp.Details {
+				got[d.Field] = true
+			}
+			for _, field := range tt.wantFields {
+				if !got[field] {
+					t.Errorf("details %v do not mention %q", resp.Details, field)
+				}
+			}
+			if len(resp.Details) != len(tt.wantFields) {
+				t.Errorf("details = %v, want exactly %v", resp.Details, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema adalah subset Schema Object OpenAPI 3.0 yang dipakai spesifikasi ini dan juga
+// dipahami oleh validator request
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// AdditionalProperties berisi false (field lain ditolak) atau *Schema untuk nilai map
+	AdditionalProperties interface{}   `json:"additionalProperties,omitempty"`
+	Items                *Schema       `json:"items,omitempty"`
+	OneOf                []*Schema     `json:"oneOf,omitempty"` // hanya untuk response, tidak diperiksa validator
+	Enum                 []interface{} `json:"enum,omitempty"`
+	MinLength            int           `json:"minLength,omitempty"`
+	MaxLength            int           `json:"maxLength,omitempty"`
+	Minimum              *float64      `json:"minimum,omitempty"`
+	MinItems             int           `json:"minItems,omitempty"`
+	MaxItems             int           `json:"maxItems,omitempty"`
+	Nullable             bool          `json:"nullable,omitempty"`
+	ReadOnly             bool          `json:"readOnly,omitempty"`
+	Example              interface{}   `json:"example,omitempty"`
+}
+
+// FieldError adalah satu pelanggaran schema pada body request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate memeriksa value hasil decode JSON (dengan UseNumber) terhadap schema.
+// $ref di-resolve ke components doc. Field readOnly di body request diabaikan.
+func (d *Document) Validate(schema *Schema, value interface{}) []FieldError {
+	v := validator{doc: d}
+	v.check(schema, "", value)
+	return v.errs
+}
+
+type validator struct {
+	doc  *Document
+	errs []FieldError
+}
+
+func (v *validator) fail(field, format string, args ...interface{}) {
+	if field == "" {
+		field = "body"
+	}
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) check(s *Schema, field string, value interface{}) {
+	if s.Ref != "" {
+		ref, ok := v.doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		if !ok {
+			v.fail(field, "unknown schema %s", s.Ref)
+			return
+		}
+		s = ref
+	}
+
+	if value == nil {
+		if !s.Nullable && s.Type != "" {
+			v.fail(field, "must not be null")
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(field, "must be an object")
+			return
+		}
+		v.checkObject(s, field, obj)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.fail(field, "must be an array")
+			return
+		}
+		if len(items) < s.MinItems {
+			v.fail(field, "must contain at least %d item(s)", s.MinItems)
+		}
+		if s.MaxItems > 0 && len(items) > s.MaxItems {
+			v.fail(field, "must contain at most %d items", s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				v.check(s.Items, fmt.Sprintf("%s[%d]", field, i), item)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			v.fail(field, "must be a string")
+			return
+		}
+		v.checkString(s, field, str)
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			v.fail(field, "must be an integer")
+			return
+		}
+		i, err := n.Int64()
+		if err != nil {
+			v.fail(field, "must be an integer")
+			return
+		}
+		if s.Minimum != nil && float64(i) < *s.Minimum {
+			v.fail(field, "must be at least %v", *s.Minimum)
+		}
+	case "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			v.fail(field, "must be a number")
+			return
+		}
+		f, err := n.Float64()
+		if err != nil {
+			v.fail(field, "must be a number")
+			return
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			v.fail(field, "must be at least %v", *s.Minimum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(field, "must be a boolean")
+		}
+	}
+}
+
+func (v *validator) checkObject(s *Schema, field string, obj map[string]interface{}) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			v.fail(join(field, name), "is required")
+		}
+	}
+
+	// Urutan tetap agar pesan error stabil
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		switch {
+		case ok && prop.ReadOnly:
+			// Field yang diisi server (id, version, timestamp) boleh ikut dikirim tetapi diabaikan
+		case ok:
+			v.check(prop, join(field, name), obj[name])
+		default:
+			switch extra := s.AdditionalProperties.(type) {
+			case bool:
+				if !extra {
+					v.fail(join(field, name), "is not a known field")
+				}
+			case *Schema:
+				v.check(extra, join(field, name), obj[name])
+			}
+		}
+	}
+}
+
+func (v *validator) checkString(s *Schema, field, str string) {
+	if len(s.Enum) > 0 {
+		known := false
+		for _, e := range s.Enum {
+			if e == str {
+				known = true
+				break
+			}
+		}
+		if !known {
+			v.fail(field, "must be one of %v", s.Enum)
+			return
+		}
+	}
+
+	length := utf8.RuneCountInString(str)
+	if length < s.MinLength {
+		v.fail(field, "must be at least %d character(s)", s.MinLength)
+	}
+	if s.MaxLength > 0 && length > s.MaxLength {
+		v.fail(field, "must be at most %d characters", s.MaxLength)
+	}
+
+	switch s.Format {
+	case "email":
+		if addr, err := mail.ParseAddress(str); err != nil || addr.Address != str {
+			v.fail(field, "must be an email address")
+		}
+	case "uri":
+		if u, err := url.ParseRequestURI(str); err != nil || u.Scheme == "" || u.Host == "" {
+			v.fail(field, "must be an absolute URL")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			v.fail(field, "must be an RFC 3339 date-time")
+		}
+	}
+}
+
+// join menyusun nama field bertingkat, mis. operations[0].data.email
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
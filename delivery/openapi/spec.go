@@ -0,0 +1,571 @@
+package openapi
+
+import (
+	"strings"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/patch"
+)
+
+// backends adalah prefix route per backend penyimpanan; keduanya punya kontrak yang sama
+var backends = []struct {
+	prefix string
+	name   string
+}{
+	{"/pg", "PostgreSQL"},
+	{"/mongo", "MongoDB"},
+}
+
+// Spec membangun dokumen OpenAPI untuk route /pg/*, /mongo/* dan /health/* di delivery/routes.
+// Route baru di sana perlu ditambahkan juga di sini agar kontrak dan validasi request tetap sesuai.
+func Spec() *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "golang-crud-clean-arch API",
+			Version: "1.0.0",
+			Description: "Users and repositories stored in PostgreSQL (/pg) or MongoDB (/mongo). " +
+				"IDs are UUIDs on /pg and ObjectIDs on /mongo. Writes take the entity version as If-Match " +
+				"and return the new version as ETag. Request bodies are validated against this document; " +
+				"violations are rejected with 422 and a ValidationError body.",
+		},
+		Tags: []Tag{
+			{Name: "users", Description: "User CRUD, soft delete and batch operations"},
+			{Name: "repositories", Description: "Repository CRUD, filtering, soft delete and batch operations"},
+			{Name: "health", Description: "Liveness, readiness and cache statistics"},
+		},
+		Paths:      map[string]*PathItem{},
+		Components: Components{Schemas: schemas()},
+	}
+
+	for _, b := range backends {
+		addUserPaths(doc, b.prefix, b.name)
+		addRepositoryPaths(doc, b.prefix, b.name)
+	}
+	addHealthPaths(doc)
+	return doc
+}
+
+// schemas adalah components.schemas: entitas, body request, halaman listing, batch, error dan health
+func schemas() map[string]*Schema {
+	id := &Schema{Type: "string", ReadOnly: true, Description: "UUID on /pg, ObjectID on /mongo", Example: "3f1c2a9e-8d4b-4c57-9a0e-2b6f1d7c8e10"}
+	timestamp := func() *Schema { return &Schema{Type: "string", Format: "date-time", ReadOnly: true} }
+	deletedAt := &Schema{Type: "string", Format: "date-time", ReadOnly: true, Nullable: true, Description: "Set while the entity is in the trash"}
+	version := &Schema{Type: "integer", Format: "int64", ReadOnly: true, Description: "Incremented on every write, returned as ETag"}
+
+	repository := func(required ...string) *Schema {
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":         id,
+				"user_id":    {Type: "string", Description: "Owner id"},
+				"name":       {Type: "string", MinLength: 1, MaxLength: 100, Example: "golang-crud-clean-arch"},
+				"url":        {Type: "string", Format: "uri", Example: "https://github.com/example/golang-crud-clean-arch"},
+				"ai_enabled": {Type: "boolean"},
+				"created_at": timestamp(),
+				"updated_at": timestamp(),
+				"version":    version,
+				"deleted_at": deletedAt,
+			},
+			Required:             required,
+			AdditionalProperties: false,
+		}
+	}
+	page := func(item string) *Schema {
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"data":        {Type: "array", Items: ref(item)},
+				"next_cursor": {Type: "string", Description: "Pass as ?cursor= to fetch the next page; absent on the last page"},
+				"total":       {Type: "integer", Format: "int64", Description: "Only with ?include_total=true"},
+			},
+			Required: []string{"data"},
+		}
+	}
+	batch := func(item string) *Schema {
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"atomic": {Type: "boolean", Description: "Apply all operations or none"},
+				"operations": {
+					Type:     "array",
+					MinItems: 1,
+					MaxItems: entity.MaxBatchSize,
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"op":      {Type: "string", Enum: []interface{}{string(entity.BatchCreate), string(entity.BatchUpdate), string(entity.BatchDelete)}},
+							"id":      {Type: "string", Description: "Required for update and delete"},
+							"version": {Type: "integer", Format: "int64", Minimum: float(0), Description: "Expected version, like If-Match; 0 skips the check"},
+							"data":    ref(item),
+						},
+						Required:             []string{"op"},
+						AdditionalProperties: false,
+					},
+				},
+			},
+			Required:             []string{"operations"},
+			AdditionalProperties: false,
+		}
+	}
+	status := &Schema{Type: "string", Enum: []interface{}{"ok", "unreachable", "disabled"}}
+
+	return map[string]*Schema{
+		"User": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":         id,
+				"name":       {Type: "string", MinLength: 1, MaxLength: 100, Example: "Budi"},
+				"email":      {Type: "string", Format: "email", MaxLength: 100, Example: "budi@example.com"},
+				"created_at": timestamp(),
+				"updated_at": timestamp(),
+				"version":    version,
+				"deleted_at": deletedAt,
+			},
+			Required:             []string{"name", "email"},
+			AdditionalProperties: false,
+		},
+		"Repository":      repository("user_id", "name", "url"),
+		"OwnedRepository": repository("name", "url"),
+		"UserPatch": {
+			Type:        "object",
+			Description: "JSON merge patch (RFC 7396) of a user",
+			Properties: map[string]*Schema{
+				"name":  {Type: "string", MinLength: 1, MaxLength: 100},
+				"email": {Type: "string", Format: "email", MaxLength: 100},
+			},
+			AdditionalProperties: false,
+		},
+		"RepositoryPatch": {
+			Type:        "object",
+			Description: "JSON merge patch (RFC 7396) of a repository",
+			Properties: map[string]*Schema{
+				"user_id":    {Type: "string"},
+				"name":       {Type: "string", MinLength: 1, MaxLength: 100},
+				"url":        {Type: "string", Format: "uri"},
+				"ai_enabled": {Type: "boolean"},
+			},
+			AdditionalProperties: false,
+		},
+		"JSONPatch": {
+			Type:        "array",
+			Description: "JSON patch (RFC 6902) operations, applied in order",
+			Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"op":    {Type: "string", Enum: []interface{}{"add", "remove", "replace", "move", "copy", "test"}},
+					"path":  {Type: "string", Example: "/name"},
+					"from":  {Type: "string"},
+					"value": {Description: "Any JSON value"},
+				},
+				Required:             []string{"op", "path"},
+				AdditionalProperties: false,
+			},
+		},
+		"UserPage":               page("User"),
+		"RepositoryPage":         page("Repository"),
+		"UserBatchRequest":       batch("User"),
+		"RepositoryBatchRequest": batch("Repository"),
+		"BatchResult": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"atomic":    {Type: "boolean"},
+				"succeeded": {Type: "integer"},
+				"failed":    {Type: "integer"},
+				"results": {Type: "array", Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"index": {Type: "integer", Description: "Position of the operation in the request"},
+						"op":    {Type: "string"},
+						"id":    {Type: "string"},
+						"status": {Type: "string", Enum: []interface{}{
+							entity.BatchStatusCreated, entity.BatchStatusUpdated, entity.BatchStatusDeleted,
+							entity.BatchStatusFailed, entity.BatchStatusAborted,
+						}},
+						"error": {Type: "string"},
+						"data":  {Type: "object", Description: "The created or updated entity"},
+					},
+				}},
+			},
+		},
+		"Error": {Type: "string", Description: "Plain-text error message", Example: "not found"},
+		"ValidationError": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"error": {Type: "string", Example: "request body does not match schema"},
+				"details": {Type: "array", Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"field":   {Type: "string", Example: "email"},
+						"message": {Type: "string", Example: "must be an email address"},
+					},
+				}},
+			},
+		},
+		"Liveness": {
+			Type:       "object",
+			Properties: map[string]*Schema{"status": {Type: "string", Enum: []interface{}{"alive"}}},
+		},
+		"Readiness": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"status": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"mongo": status, "redis": status, "postgres": status, "kafka": status, "jaeger": status,
+					},
+				},
+			},
+		},
+		"CacheStats": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"caches": {Type: "array", Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"namespace": {Type: "string", Example: "pg"},
+						"hits":      {Type: "integer"},
+						"misses":    {Type: "integer"},
+						"hit_ratio": {Type: "number"},
+					},
+				}},
+			},
+		},
+	}
+}
+
+// addUserPaths menambahkan route user untuk satu backend (SetupUserRoutes)
+func addUserPaths(doc *Document, prefix, backend string) {
+	tag := []string{"users"}
+	opID := func(name string) string { return strings.TrimPrefix(prefix, "/") + name }
+	base := prefix + "/users"
+
+	doc.Paths[prefix+"/users:batch"] = &PathItem{
+		Post: &Operation{
+			Tags: tag, OperationID: opID("BatchUsers"), Summary: "Create, update and delete users in one request (" + backend + ")",
+			RequestBody: jsonBody("UserBatchRequest"),
+			Responses:   batchResponses(),
+		},
+	}
+	doc.Paths[base] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: opID("ListUsers"), Summary: "List users (" + backend + ")",
+			Parameters: append(pageParams(), includeDeletedParam()),
+			Responses:  responses(ok("A page of users", "UserPage"), errorResponse("400", "Invalid pagination or filter")),
+		},
+		Post: &Operation{
+			Tags: tag, OperationID: opID("CreateUser"), Summary: "Create a user (" + backend + ")",
+			RequestBody: jsonBody("User"),
+			Responses:   responses(created("The created user", "User"), invalidPayload(), unprocessable()),
+		},
+	}
+	doc.Paths[base+"/trash"] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: opID("ListDeletedUsers"), Summary: "List soft-deleted users (" + backend + ")",
+			Parameters: pageParams(),
+			Responses:  responses(ok("A page of soft-deleted users", "UserPage"), errorResponse("400", "Invalid pagination")),
+		},
+	}
+	doc.Paths[base+"/{id}"] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: opID("GetUser"), Summary: "Get a user (" + backend + ")",
+			Parameters: []Parameter{idParam()},
+			Responses:  responses(withETag(ok("The user", "User")), errorResponse("404", "User not found")),
+		},
+		Put: &Operation{
+			Tags: tag, OperationID: opID("UpdateUser"), Summary: "Replace a user (" + backend + ")",
+			Parameters:  []Parameter{idParam(), ifMatchParam()},
+			RequestBody: jsonBody("User"),
+			Responses: responses(withETag(ok("The updated user", "User")), invalidPayload(), errorResponse("404", "User not found"),
+				preconditionFailed(), unprocessable()),
+		},
+		Patch: &Operation{
+			Tags: tag, OperationID: opID("PatchUser"), Summary: "Partially update a user (" + backend + ")",
+			Parameters:  []Parameter{idParam(), ifMatchParam()},
+			RequestBody: patchBody("UserPatch"),
+			Responses: responses(withETag(ok("The patched user", "User")), errorResponse("400", "Malformed patch"),
+				errorResponse("404", "User not found"), errorResponse("409", "A JSON patch test operation failed"),
+				preconditionFailed(), errorResponse("415", "Unsupported patch media type"), unprocessable()),
+		},
+		Delete: &Operation{
+			Tags: tag, OperationID: opID("DeleteUser"), Summary: "Move a user to the trash (" + backend + ")",
+			Parameters: []Parameter{idParam(), ifMatchParam()},
+			Responses:  responses(noContent("User deleted"), errorResponse("404", "User not found"), preconditionFailed()),
+		},
+	}
+	doc.Paths[base+"/{id}/restore"] = &PathItem{
+		Post: &Operation{
+			Tags: tag, OperationID: opID("RestoreUser"), Summary: "Restore a soft-deleted user (" + backend + ")",
+			Parameters: []Parameter{idParam()},
+			Responses:  responses(withETag(ok("The restored user", "User")), errorResponse("404", "User not found in trash")),
+		},
+	}
+	doc.Paths[base+"/{id}/purge"] = &PathItem{
+		Delete: &Operation{
+			Tags: tag, OperationID: opID("PurgeUser"), Summary: "Permanently delete a user (" + backend + ")",
+			Parameters: []Parameter{idParam()},
+			Responses:  responses(noContent("User purged"), errorResponse("404", "User not found")),
+		},
+	}
+	doc.Paths[base+"/{id}/repositories"] = &PathItem{
+		Get: &Operation{
+			Tags: []string{"users", "repositories"}, OperationID: opID("ListUserRepositories"), Summary: "List repositories of a user (" + backend + ")",
+			Parameters: append(append(append([]Parameter{idParam()}, pageParams()...), repositoryFilterParams()...), includeDeletedParam()),
+			Responses: responses(ok("A page of repositories", "RepositoryPage"), errorResponse("400", "Invalid pagination or filter"),
+				errorResponse("404", "User not found")),
+		},
+		Post: &Operation{
+			Tags: []string{"users", "repositories"}, OperationID: opID("CreateUserRepository"), Summary: "Create a repository owned by a user (" + backend + ")",
+			Parameters:  []Parameter{idParam()},
+			RequestBody: jsonBody("OwnedRepository"),
+			Responses: responses(created("The created repository", "Repository"), invalidPayload(),
+				errorResponse("404", "User not found"), unprocessable()),
+		},
+	}
+}
+
+// addRepositoryPaths menambahkan route repository untuk satu backend (SetupRepositoryRoutes)
+func addRepositoryPaths(doc *Document, prefix, backend string) {
+	tag := []string{"repositories"}
+	opID := func(name string) string { return strings.TrimPrefix(prefix, "/") + name }
+	base := prefix + "/repositories"
+
+	doc.Paths[prefix+"/repositories:batch"] = &PathItem{
+		Post: &Operation{
+			Tags: tag, OperationID: opID("BatchRepositories"), Summary: "Create, update and delete repositories in one request (" + backend + ")",
+			RequestBody: jsonBody("RepositoryBatchRequest"),
+			Responses:   batchResponses(),
+		},
+	}
+	doc.Paths[base] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: opID("ListRepositories"), Summary: "List repositories (" + backend + ")",
+			Parameters: append(append(pageParams(), repositoryFilterParams()...), includeDeletedParam()),
+			Responses:  responses(ok("A page of repositories", "RepositoryPage"), errorResponse("400", "Invalid pagination or filter")),
+		},
+		Post: &Operation{
+			Tags: tag, OperationID: opID("CreateRepository"), Summary: "Create a repository (" + backend + ")",
+			RequestBody: jsonBody("Repository"),
+			Responses:   responses(created("The created repository", "Repository"), invalidPayload(), unprocessable()),
+		},
+	}
+	doc.Paths[base+"/trash"] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: opID("ListDeletedRepositories"), Summary: "List soft-deleted repositories (" + backend + ")",
+			Parameters: append(pageParams(), repositoryFilterParams()...),
+			Responses:  responses(ok("A page of soft-deleted repositories", "RepositoryPage"), errorResponse("400", "Invalid pagination or filter")),
+		},
+	}
+	doc.Paths[base+"/{id}"] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: opID("GetRepository"), Summary: "Get a repository (" + backend + ")",
+			Parameters: []Parameter{idParam()},
+			Responses:  responses(withETag(ok("The repository", "Repository")), errorResponse("404", "Repository not found")),
+		},
+		Put: &Operation{
+			Tags: tag, OperationID: opID("UpdateRepository"), Summary: "Replace a repository (" + backend + ")",
+			Parameters:  []Parameter{idParam(), ifMatchParam()},
+			RequestBody: jsonBody("Repository"),
+			Responses: responses(withETag(ok("The updated repository", "Repository")), invalidPayload(),
+				errorResponse("404", "Repository not found"), preconditionFailed(), unprocessable()),
+		},
+		Patch: &Operation{
+			Tags: tag, OperationID: opID("PatchRepository"), Summary: "Partially update a repository (" + backend + ")",
+			Parameters:  []Parameter{idParam(), ifMatchParam()},
+			RequestBody: patchBody("RepositoryPatch"),
+			Responses: responses(withETag(ok("The patched repository", "Repository")), errorResponse("400", "Malformed patch"),
+				errorResponse("404", "Repository not found"), errorResponse("409", "A JSON patch test operation failed"),
+				preconditionFailed(), errorResponse("415", "Unsupported patch media type"), unprocessable()),
+		},
+		Delete: &Operation{
+			Tags: tag, OperationID: opID("DeleteRepository"), Summary: "Move a repository to the trash (" + backend + ")",
+			Parameters: []Parameter{idParam(), ifMatchParam()},
+			Responses:  responses(noContent("Repository deleted"), errorResponse("404", "Repository not found"), preconditionFailed()),
+		},
+	}
+	doc.Paths[base+"/{id}/restore"] = &PathItem{
+		Post: &Operation{
+			Tags: tag, OperationID: opID("RestoreRepository"), Summary: "Restore a soft-deleted repository (" + backend + ")",
+			Parameters: []Parameter{idParam()},
+			Responses:  responses(withETag(ok("The restored repository", "Repository")), errorResponse("404", "Repository not found in trash")),
+		},
+	}
+	doc.Paths[base+"/{id}/purge"] = &PathItem{
+		Delete: &Operation{
+			Tags: tag, OperationID: opID("PurgeRepository"), Summary: "Permanently delete a repository (" + backend + ")",
+			Parameters: []Parameter{idParam()},
+			Responses:  responses(noContent("Repository purged"), errorResponse("404", "Repository not found")),
+		},
+	}
+}
+
+// addHealthPaths menambahkan route SetupHealthRoutes
+func addHealthPaths(doc *Document) {
+	tag := []string{"health"}
+	doc.Paths["/health/liveness"] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: "liveness", Summary: "Process is running",
+			Responses: responses(ok("Alive", "Liveness")),
+		},
+	}
+	doc.Paths["/health/readiness"] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: "readiness", Summary: "Status of every dependency",
+			Responses: responses(ok("All dependencies are reachable", "Readiness"), jsonResponse("503", "At least one dependency is unreachable", "Readiness")),
+		},
+	}
+	doc.Paths["/health/cache"] = &PathItem{
+		Get: &Operation{
+			Tags: tag, OperationID: "cacheStats", Summary: "Cache hits and misses per backend",
+			Responses: responses(ok("Cache statistics", "CacheStats")),
+		},
+	}
+}
+
+// statusResponse adalah satu entri responses beserta status code-nya
+type statusResponse struct {
+	code     string
+	response Response
+}
+
+func responses(items ...statusResponse) map[string]Response {
+	out := make(map[string]Response, len(items))
+	for _, item := range items {
+		out[item.code] = item.response
+	}
+	return out
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func jsonResponse(code, description, schema string) statusResponse {
+	return statusResponse{code, Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: ref(schema)}},
+	}}
+}
+
+func ok(description, schema string) statusResponse {
+	return jsonResponse("200", description, schema)
+}
+
+func created(description, schema string) statusResponse {
+	return jsonResponse("201", description, schema)
+}
+
+func noContent(description string) statusResponse {
+	return statusResponse{"204", Response{Description: description}}
+}
+
+func errorResponse(code, description string) statusResponse {
+	return statusResponse{code, Response{
+		Description: description,
+		Content:     map[string]MediaType{"text/plain": {Schema: ref("Error")}},
+	}}
+}
+
+func invalidPayload() statusResponse {
+	return errorResponse("400", "Body is not valid JSON")
+}
+
+func preconditionFailed() statusResponse {
+	return errorResponse("412", "If-Match is invalid or does not match the current version")
+}
+
+// unprocessable adalah 422 dari middleware validasi (JSON) atau dari aturan usecase (teks)
+func unprocessable() statusResponse {
+	return statusResponse{"422", Response{
+		Description: "Body does not match the schema or was rejected by the use case",
+		Content: map[string]MediaType{
+			"application/json": {Schema: ref("ValidationError")},
+			"text/plain":       {Schema: ref("Error")},
+		},
+	}}
+}
+
+func batchResponses() map[string]Response {
+	return responses(
+		ok("All operations succeeded", "BatchResult"),
+		jsonResponse("207", "Some operations failed", "BatchResult"),
+		errorResponse("400", "Body is not valid JSON, empty or too large"),
+		statusResponse{"422", Response{
+			Description: "Body does not match the schema, or an atomic batch was rolled back",
+			Content: map[string]MediaType{
+				"application/json": {Schema: &Schema{OneOf: []*Schema{ref("BatchResult"), ref("ValidationError")}}},
+			},
+		}},
+	)
+}
+
+// withETag menambahkan header ETag (versi entitas) ke response
+func withETag(r statusResponse) statusResponse {
+	r.response.Headers = map[string]Header{
+		"ETag": {Description: "Entity version, send it back as If-Match", Schema: &Schema{Type: "string", Example: `"3"`}},
+	}
+	return r
+}
+
+func jsonBody(schema string) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: ref(schema)}},
+	}
+}
+
+// patchBody menerima merge patch (juga sebagai application/json) dan JSON patch
+func patchBody(mergeSchema string) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content: map[string]MediaType{
+			patch.MediaTypeMergePatch: {Schema: ref(mergeSchema)},
+			"application/json":        {Schema: ref(mergeSchema)},
+			patch.MediaTypeJSONPatch:  {Schema: ref("JSONPatch")},
+		},
+	}
+}
+
+func idParam() Parameter {
+	return Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}, Description: "UUID on /pg, ObjectID on /mongo"}
+}
+
+func ifMatchParam() Parameter {
+	return Parameter{Name: "If-Match", In: "header", Schema: &Schema{Type: "string", Example: `"3"`},
+		Description: "Expected version from ETag; empty or * skips the check"}
+}
+
+func pageParams() []Parameter {
+	return []Parameter{
+		{Name: "limit", In: "query", Schema: &Schema{Type: "integer", Minimum: float(1), Description: "Capped at the maximum page size"}},
+		{Name: "cursor", In: "query", Schema: &Schema{Type: "string"}, Description: "next_cursor of the previous page"},
+		{Name: "include_total", In: "query", Schema: &Schema{Type: "boolean"}},
+	}
+}
+
+func includeDeletedParam() Parameter {
+	return Parameter{Name: "include_deleted", In: "query", Schema: &Schema{Type: "boolean"}, Description: "Also return soft-deleted entities"}
+}
+
+func repositoryFilterParams() []Parameter {
+	date := func(name string) Parameter {
+		return Parameter{Name: name, In: "query", Schema: &Schema{Type: "string"}, Description: "RFC 3339 or YYYY-MM-DD"}
+	}
+	sortFields := make([]string, 0, len(entity.RepositorySortFields))
+	for _, f := range entity.RepositorySortFields {
+		sortFields = append(sortFields, f, "-"+f)
+	}
+	return []Parameter{
+		{Name: "user_id", In: "query", Schema: &Schema{Type: "string"}},
+		{Name: "ai_enabled", In: "query", Schema: &Schema{Type: "boolean"}},
+		{Name: "name_prefix", In: "query", Schema: &Schema{Type: "string"}},
+		{Name: "name_contains", In: "query", Schema: &Schema{Type: "string"}},
+		date("created_from"), date("created_to"), date("updated_from"), date("updated_to"),
+		{Name: "sort", In: "query", Schema: &Schema{Type: "string", Example: "-created_at,name"},
+			Description: "Comma-separated fields, - for descending: " + strings.Join(sortFields, ", ")},
+	}
+}
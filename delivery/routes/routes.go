@@ -22,24 +22,36 @@ func SetupRoutes(
 
 // SetupRepositoryRoutes configures repository-related routes
 func SetupRepositoryRoutes(r chi.Router, h *httpHandler.RepositoryHandler) {
+	r.Post("/repositories:batch", http.HandlerFunc(h.Batch))
 	r.Route("/repositories", func(r chi.Router) {
 		r.Post("/", http.HandlerFunc(h.Create))
 		r.Get("/", http.HandlerFunc(h.GetAll))
+		r.Get("/trash", http.HandlerFunc(h.Trash))
 		r.Get("/{id}", http.HandlerFunc(h.Get))
 		r.Put("/{id}", http.HandlerFunc(h.Update))
+		r.Patch("/{id}", http.HandlerFunc(h.Patch))
 		r.Delete("/{id}", http.HandlerFunc(h.Delete))
+		r.Post("/{id}/restore", http.HandlerFunc(h.Restore))
+		r.Delete("/{id}/purge", http.HandlerFunc(h.Purge))
 	})
 }
 
 // SetupUserRoutes configures user-related routes
 func SetupUserRoutes(r chi.Router, h *httpHandler.UserHandler) {
+	r.Post("/users:batch", http.HandlerFunc(h.BatchUsers))
 	r.Route("/users", func(r chi.Router) {
 		r.Get("/test-cb", http.HandlerFunc(h.TestCircuitBreaker)) // 🧪 Test CB (tanpa double `/users`)
 		r.Post("/", http.HandlerFunc(h.CreateUser))
 		r.Get("/", http.HandlerFunc(h.GetAllUsers))
+		r.Get("/trash", http.HandlerFunc(h.TrashUsers))
 		r.Get("/{id}", http.HandlerFunc(h.GetUser))
 		r.Put("/{id}", http.HandlerFunc(h.UpdateUser))
+		r.Patch("/{id}", http.HandlerFunc(h.PatchUser))
 		r.Delete("/{id}", http.HandlerFunc(h.DeleteUser))
+		r.Post("/{id}/restore", http.HandlerFunc(h.RestoreUser))
+		r.Delete("/{id}/purge", http.HandlerFunc(h.PurgeUser))
+		r.Get("/{id}/repositories", http.HandlerFunc(h.GetUserRepositories))
+		r.Post("/{id}/repositories", http.HandlerFunc(h.CreateUserRepository))
 	})
 }
 
@@ -48,5 +60,53 @@ func SetupHealthRoutes(r chi.Router, h *httpHandler.HealthHandler) {
 	r.Route("/health", func(r chi.Router) {
 		r.Get("/liveness", http.HandlerFunc(h.Liveness))
 		r.Get("/readiness", http.HandlerFunc(h.Readiness))
+		r.Get("/cache", http.HandlerFunc(h.CacheStats))
 	})
 }
+
+// SetupAdminRoutes configures operational endpoints for dead-letter topics
+func SetupAdminRoutes(r chi.Router, dlq *httpHandler.DLQHandler) {
+	r.Route("/admin/dlq", func(r chi.Router) {
+		r.Get("/{topic}", http.HandlerFunc(dlq.List))
+		r.Post("/{topic}/redrive", http.HandlerFunc(dlq.Redrive))
+	})
+}
+
+// SetupOutboxRoutes configures the endpoints for outbox events the relay gave up on
+func SetupOutboxRoutes(r chi.Router, h *httpHandler.OutboxHandler) {
+	r.Route("/admin/outbox/failed", func(r chi.Router) {
+		r.Get("/", http.HandlerFunc(h.ListFailed))
+		r.Post("/requeue", http.HandlerFunc(h.Requeue))
+	})
+}
+
+// SetupSyncRoutes configures the Postgres/Mongo reconciliation endpoint
+func SetupSyncRoutes(r chi.Router, h *httpHandler.SyncHandler) {
+	r.Get("/admin/sync/report", http.HandlerFunc(h.Report))
+}
+
+// SetupWebhookRoutes configures webhook subscription management and delivery logs
+func SetupWebhookRoutes(r chi.Router, h *httpHandler.WebhookHandler) {
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Post("/", http.HandlerFunc(h.Create))
+		r.Get("/", http.HandlerFunc(h.List))
+		r.Get("/{id}", http.HandlerFunc(h.Get))
+		r.Put("/{id}", http.HandlerFunc(h.Update))
+		r.Delete("/{id}", http.HandlerFunc(h.Delete))
+		r.Get("/{id}/deliveries", http.HandlerFunc(h.Deliveries))
+	})
+}
+
+// SetupStreamRoutes configures the live event stream over SSE and WebSocket
+func SetupStreamRoutes(r chi.Router, h *httpHandler.StreamHandler) {
+	r.Route("/events", func(r chi.Router) {
+		r.Get("/stream", http.HandlerFunc(h.SSE))
+		r.Get("/ws", http.HandlerFunc(h.WebSocket))
+	})
+}
+
+// SetupDocsRoutes configures the OpenAPI document and the Swagger UI page
+func SetupDocsRoutes(r chi.Router, h *httpHandler.DocsHandler) {
+	r.Get("/openapi.json", http.HandlerFunc(h.Spec))
+	r.Get("/docs", http.HandlerFunc(h.SwaggerUI))
+}
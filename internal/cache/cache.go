@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Cache adalah read-through cache di atas Redis.
+// Setiap backend (pg, mongo) memakai namespace sendiri agar key seperti
+// "users:{id}" tidak saling bertabrakan.
+type Cache struct {
+	redis     *redis.Client
+	namespace string
+	itemTTL   time.Duration
+	listTTL   time.Duration
+	hits      uint64
+	misses    uint64
+}
+
+// Stats adalah ringkasan cache hit/miss untuk satu namespace
+type Stats struct {
+	Namespace string  `json:"namespace"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRatio  float64 `json:"hit_ratio"`
+}
+
+// New membuat cache baru dengan namespace dan TTL untuk item tunggal dan list
+func New(redis *redis.Client, namespace string, itemTTL, listTTL time.Duration) *Cache {
+	return &Cache{
+		redis:     redis,
+		namespace: namespace,
+		itemTTL:   itemTTL,
+		listTTL:   listTTL,
+	}
+}
+
+// Key mengembalikan key Redis lengkap dengan namespace
+func (c *Cache) Key(key string) string {
+	return c.namespace + ":" + key
+}
+
+// ItemTTL adalah TTL untuk entry satu entitas (mis. "users:{id}")
+func (c *Cache) ItemTTL() time.Duration {
+	return c.itemTTL
+}
+
+// ListTTL adalah TTL untuk entry daftar entitas (mis. "users:all")
+func (c *Cache) ListTTL() time.Duration {
+	return c.listTTL
+}
+
+// Get membaca key dari Redis dan men-decode JSON ke dest.
+// Mengembalikan true jika cache hit.
+func (c *Cache) Get(ctx context.Context, key string, dest interface{}) bool {
+	fullKey := c.Key(key)
+	data, err := c.redis.Get(ctx, fullKey).Bytes()
+	return c.decode(ctx, fullKey, data, err, dest)
+}
+
+// GetField membaca satu field dari hash Redis, dipakai untuk listing per halaman
+// sehingga Del(key) menghapus semua halaman sekaligus
+func (c *Cache) GetField(ctx context.Context, key, field string, dest interface{}) bool {
+	fullKey := c.Key(key)
+	data, err := c.redis.HGet(ctx, fullKey, field).Bytes()
+	return c.decode(ctx, fullKey, data, err, dest)
+}
+
+// decode men-decode hasil baca Redis dan mencatat hit/miss
+func (c *Cache) decode(ctx context.Context, fullKey string, data []byte, err error, dest interface{}) bool {
+	if err == nil {
+		err = json.Unmarshal(data, dest)
+	}
+	hit := err == nil
+
+	if hit {
+		atomic.AddUint64(&c.hits, 1)
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+		if err != redis.Nil {
+			log.Printf("⚠️ Cache read error for %s: %v", fullKey, err)
+		}
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("cache.key", fullKey),
+		attribute.Bool("cache.hit", hit),
+	)
+	return hit
+}
+
+// Set menyimpan value sebagai JSON dengan TTL tertentu.
+// TTL 0 berarti cache dinonaktifkan sehingga tidak ada yang disimpan.
+func (c *Cache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("⚠️ Cache marshal error for %s: %v", c.Key(key), err)
+		return
+	}
+	if err := c.redis.Set(ctx, c.Key(key), data, ttl).Err(); err != nil {
+		log.Printf("⚠️ Cache write error for %s: %v", c.Key(key), err)
+	}
+}
+
+// SetField menyimpan value sebagai JSON di field hash Redis.
+// TTL berlaku untuk seluruh hash dan diperbarui setiap kali field ditulis.
+func (c *Cache) SetField(ctx context.Context, key, field string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("⚠️ Cache marshal error for %s: %v", c.Key(key), err)
+		return
+	}
+
+	fullKey := c.Key(key)
+	pipe := c.redis.TxPipeline()
+	pipe.HSet(ctx, fullKey, field, data)
+	pipe.Expire(ctx, fullKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️ Cache write error for %s: %v", fullKey, err)
+	}
+}
+
+// Del menghapus satu atau lebih key (invalidasi cache)
+func (c *Cache) Del(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	fullKeys := make([]string, len(keys))
+	for i, key := range keys {
+		fullKeys[i] = c.Key(key)
+	}
+	if err := c.redis.Del(ctx, fullKeys...).Err(); err != nil {
+		log.Printf("⚠️ Cache invalidation error for %v: %v", fullKeys, err)
+	}
+}
+
+// Stats mengembalikan jumlah hit/miss sejak aplikasi berjalan
+func (c *Cache) Stats() Stats {
+	hits := atomic.LoadUint64(&c.hits)
+	misses := atomic.LoadUint64(&c.misses)
+
+	stats := Stats{Namespace: c.namespace, Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRatio = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+// String memudahkan logging statistik cache
+func (s Stats) String() string {
+	return fmt.Sprintf("%s: hits=%d misses=%d ratio=%.2f", s.Namespace, s.Hits, s.Misses, s.HitRatio)
+}
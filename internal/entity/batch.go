@@ -0,0 +1,65 @@
+package entity
+
+import "errors"
+
+// MaxBatchSize adalah jumlah operasi maksimum dalam satu request batch
+const MaxBatchSize = 1000
+
+// ErrInvalidBatch dikembalikan jika request batch kosong atau terlalu besar
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// BatchOp adalah jenis operasi dalam request batch
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// BatchOperation adalah satu operasi dalam request batch.
+// ID wajib untuk update/delete, Data wajib untuk create/update.
+// Version > 0 mengaktifkan pengecekan versi seperti If-Match.
+type BatchOperation[T any] struct {
+	Op      BatchOp `json:"op"`
+	ID      string  `json:"id,omitempty"`
+	Version int64   `json:"version,omitempty"`
+	Data    *T      `json:"data,omitempty"`
+}
+
+// BatchRequest adalah body POST /users:batch dan /repositories:batch.
+// Atomic=true berarti semua operasi berhasil atau tidak ada yang diterapkan.
+type BatchRequest[T any] struct {
+	Atomic     bool                `json:"atomic"`
+	Operations []BatchOperation[T] `json:"operations"`
+}
+
+type UserBatchRequest = BatchRequest[User]
+type RepositoryBatchRequest = BatchRequest[Repository]
+
+// Status hasil per operasi batch
+const (
+	BatchStatusCreated = "created"
+	BatchStatusUpdated = "updated"
+	BatchStatusDeleted = "deleted"
+	BatchStatusFailed  = "failed"
+	BatchStatusAborted = "aborted" // tidak diterapkan karena operasi lain pada batch atomic gagal
+)
+
+// BatchItemResult adalah hasil satu operasi batch, Index menunjuk posisi operasi di request
+type BatchItemResult struct {
+	Index  int         `json:"index"`
+	Op     BatchOp     `json:"op"`
+	ID     interface{} `json:"id,omitempty"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// BatchResult adalah response batch beserta ringkasan jumlah sukses/gagal
+type BatchResult struct {
+	Atomic    bool              `json:"atomic"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
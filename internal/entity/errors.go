@@ -0,0 +1,14 @@
+package entity
+
+import "errors"
+
+var (
+	// ErrNotFound dikembalikan jika entitas yang akan diubah tidak ditemukan
+	ErrNotFound = errors.New("not found")
+
+	// ErrVersionConflict dikembalikan jika versi entitas tidak sama dengan versi yang diharapkan (If-Match)
+	ErrVersionConflict = errors.New("version conflict: entity was modified by another request")
+
+	// ErrValidation dikembalikan jika entitas hasil perubahan tidak lolos validasi
+	ErrValidation = errors.New("validation failed")
+)
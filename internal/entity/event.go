@@ -5,3 +5,16 @@ type Event struct {
 	Type string      `json:"type"`
 	Data interface{} `json:"data"`
 }
+
+// UserChanges adalah payload event user.updated hasil PATCH:
+// data user terbaru ditambah field yang berubah beserta nilai barunya
+type UserChanges struct {
+	*User
+	ChangedFields map[string]interface{} `json:"changed_fields"`
+}
+
+// RepositoryChanges adalah payload event repo.updated hasil PATCH
+type RepositoryChanges struct {
+	*Repository
+	ChangedFields map[string]interface{} `json:"changed_fields"`
+}
@@ -0,0 +1,139 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidFilter dikembalikan untuk parameter filter/sort yang tidak dikenal atau tidak valid
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// RepositorySortFields adalah whitelist field yang boleh dipakai di ?sort= pada listing repository
+var RepositorySortFields = []string{"name", "created_at", "updated_at", "ai_enabled"}
+
+// DeletedScope menentukan apakah data yang di-soft-delete ikut ditampilkan
+type DeletedScope string
+
+const (
+	DeletedExcluded DeletedScope = ""     // default: hanya data aktif
+	DeletedIncluded DeletedScope = "all"  // ?include_deleted=true: data aktif dan terhapus
+	DeletedOnly     DeletedScope = "only" // /trash: hanya data terhapus
+)
+
+// UserFilter berisi filter untuk listing user
+type UserFilter struct {
+	Email   string // pencocokan persis, dipakai sync worker untuk mencari user berdasarkan natural key
+	Deleted DeletedScope
+}
+
+// String mengembalikan representasi kanonik filter, dipakai sebagai bagian key cache
+func (f UserFilter) String() string {
+	var parts []string
+	if f.Email != "" {
+		parts = append(parts, "email="+f.Email)
+	}
+	if f.Deleted != DeletedExcluded {
+		parts = append(parts, "deleted="+string(f.Deleted))
+	}
+	return strings.Join(parts, "&")
+}
+
+// SortField adalah satu elemen dari ?sort=field,-field
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort mem-parse "field,-field" dan menolak field di luar whitelist
+func ParseSort(raw string, allowed []string) ([]SortField, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		sf := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if !containsString(allowed, sf.Field) {
+			return nil, fmt.Errorf("%w: unknown sort field %q (allowed: %s)", ErrInvalidFilter, sf.Field, strings.Join(allowed, ", "))
+		}
+		if seen[sf.Field] {
+			return nil, fmt.Errorf("%w: duplicate sort field %q", ErrInvalidFilter, sf.Field)
+		}
+		seen[sf.Field] = true
+		fields = append(fields, sf)
+	}
+	return fields, nil
+}
+
+// SortString mengembalikan bentuk kanonik sort, mis. "name,-created_at"
+func SortString(fields []SortField) string {
+	parts := make([]string, len(fields))
+	for i, sf := range fields {
+		if sf.Desc {
+			parts[i] = "-" + sf.Field
+		} else {
+			parts[i] = sf.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// RepositoryFilter berisi filter dan urutan untuk listing repository.
+// Rentang waktu memakai batas bawah inklusif (From) dan batas atas eksklusif (To).
+type RepositoryFilter struct {
+	UserID       string
+	URL          string // pencocokan persis, dipakai sync worker bersama UserID sebagai natural key
+	AIEnabled    *bool
+	NamePrefix   string
+	NameContains string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	UpdatedFrom  *time.Time
+	UpdatedTo    *time.Time
+	Sort         []SortField
+	Deleted      DeletedScope
+}
+
+// String mengembalikan representasi kanonik filter, dipakai sebagai bagian key cache
+func (f RepositoryFilter) String() string {
+	var parts []string
+	add := func(key, value string) {
+		if value != "" {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	addTime := func(key string, t *time.Time) {
+		if t != nil {
+			add(key, t.UTC().Format(time.RFC3339Nano))
+		}
+	}
+
+	add("user_id", f.UserID)
+	add("url", f.URL)
+	if f.AIEnabled != nil {
+		add("ai_enabled", fmt.Sprintf("%t", *f.AIEnabled))
+	}
+	add("name_prefix", f.NamePrefix)
+	add("name_contains", f.NameContains)
+	addTime("created_from", f.CreatedFrom)
+	addTime("created_to", f.CreatedTo)
+	addTime("updated_from", f.UpdatedFrom)
+	addTime("updated_to", f.UpdatedTo)
+	add("sort", SortString(f.Sort))
+	add("deleted", string(f.Deleted))
+	return strings.Join(parts, "&")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage adalah event yang menunggu dikirim oleh relay outbox.
+// Urutan pengiriman dijaga per (Topic, AggregateID). FailedAt terisi jika event gagal dikirim
+// OUTBOX_MAX_ATTEMPTS kali dan tidak lagi dicoba oleh relay.
+type OutboxMessage struct {
+	ID            int64           `json:"id"`
+	Topic         string          `json:"topic"`
+	AggregateID   string          `json:"aggregate_id"`
+	Key           string          `json:"key"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     *string         `json:"last_error,omitempty"`
+	FailedAt      *time.Time      `json:"failed_at,omitempty"`
+}
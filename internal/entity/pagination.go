@@ -0,0 +1,47 @@
+package entity
+
+import "errors"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor dikembalikan jika cursor pagination tidak bisa di-decode
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest berisi parameter pagination berbasis cursor (?limit=&cursor=&total=)
+type PageRequest struct {
+	Limit     int
+	Cursor    string
+	WithTotal bool
+}
+
+// Normalize memastikan Limit berada di antara 1 dan MaxPageLimit
+func (p PageRequest) Normalize() PageRequest {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	return p
+}
+
+// PageInfo adalah metadata halaman yang dikembalikan bersama data
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// UserPage adalah satu halaman hasil listing user
+type UserPage struct {
+	Data []User `json:"data"`
+	PageInfo
+}
+
+// RepositoryPage adalah satu halaman hasil listing repository
+type RepositoryPage struct {
+	Data []Repository `json:"data"`
+	PageInfo
+}
@@ -14,6 +14,8 @@ type Repository struct {
 	AIEnabled bool        `json:"ai_enabled"`
 	CreatedAt time.Time   `json:"created_at"`
 	UpdatedAt time.Time   `json:"updated_at"`
+	Version   int64       `json:"version"`
+	DeletedAt *time.Time  `json:"deleted_at,omitempty"`
 }
 
 func (r *Repository) Validate() error {
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// Nama entitas yang disinkronkan antara PostgreSQL dan MongoDB
+const (
+	SyncEntityUser       = "user"
+	SyncEntityRepository = "repository"
+)
+
+// IDMapping memasangkan id satu entitas di PostgreSQL (UUID) dengan id-nya di MongoDB (ObjectID hex)
+type IDMapping struct {
+	Entity    string    `json:"entity"`
+	PgID      string    `json:"pg_id"`
+	MongoID   string    `json:"mongo_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SyncMismatch adalah pasangan entitas yang field-nya berbeda di kedua backend
+type SyncMismatch struct {
+	PgID    string   `json:"pg_id"`
+	MongoID string   `json:"mongo_id"`
+	Fields  []string `json:"fields"`
+}
+
+// SyncReport adalah hasil rekonsiliasi data aktif (belum di-soft-delete) antara PostgreSQL dan MongoDB
+type SyncReport struct {
+	Entity            string         `json:"entity"`
+	GeneratedAt       time.Time      `json:"generated_at"`
+	PostgresCount     int            `json:"postgres_count"`
+	MongoCount        int            `json:"mongo_count"`
+	InSync            int            `json:"in_sync"`
+	MissingInMongo    []string       `json:"missing_in_mongo"`    // id PostgreSQL tanpa pasangan aktif di MongoDB
+	MissingInPostgres []string       `json:"missing_in_postgres"` // id MongoDB tanpa pasangan aktif di PostgreSQL
+	Mismatched        []SyncMismatch `json:"mismatched"`
+}
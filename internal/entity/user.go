@@ -15,6 +15,8 @@ type User struct {
 	Email     string      `json:"email" bson:"email"`
 	CreatedAt time.Time   `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time   `json:"updated_at" bson:"updated_at"`
+	Version   int64       `json:"version" bson:"version"`
+	DeletedAt *time.Time  `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
 }
 
 func (u *User) Validate() error {
@@ -37,6 +39,8 @@ func (u *User) GetIDString() string {
 		return id.Hex()
 	case uuid.UUID:
 		return id.String()
+	case string:
+		return id
 	default:
 		return ""
 	}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidWebhook dikembalikan jika data langganan webhook tidak valid
+var ErrInvalidWebhook = errors.New("invalid webhook subscription")
+
+// WebhookSubscription adalah langganan HTTP callback untuk event user dan repository.
+// Secret dipakai untuk tanda tangan HMAC-SHA256 dan hanya dikembalikan saat langganan dibuat.
+type WebhookSubscription struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	Secret              string     `json:"secret,omitempty"`
+	EventTypes          []string   `json:"event_types"` // kosong = semua event, "user.*" = semua event user
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      *string    `json:"disabled_reason,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// WebhookDelivery adalah satu percobaan pengiriman event ke langganan webhook
+type WebhookDelivery struct {
+	ID             int64     `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     *int      `json:"status_code,omitempty"` // nil jika request tidak mendapat respons
+	Success        bool      `json:"success"`
+	Error          *string   `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WebhookRetry adalah pengiriman webhook yang gagal dan dijadwalkan untuk dicoba lagi
+type WebhookRetry struct {
+	ID             int64
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Payload        []byte // CloudEvent JSON yang dikirim sebagai body
+	Attempt        int    // nomor percobaan berikutnya
+	NextAttemptAt  time.Time
+	LastError      *string
+	CreatedAt      time.Time
+}
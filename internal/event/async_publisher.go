@@ -0,0 +1,292 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang-crud-clean-arch/config"
+)
+
+var (
+	// ErrBufferFull dikembalikan Publish jika buffer async penuh dan KAFKA_PRODUCER_OVERFLOW=error,
+	// atau diteruskan ke OnFailure jika overflow=drop
+	ErrBufferFull = errors.New("event buffer is full")
+	// ErrPublisherClosed dikembalikan Publish setelah AsyncPublisher ditutup
+	ErrPublisherClosed = errors.New("event publisher is closed")
+)
+
+// DeliveryCallbacks dipanggil oleh worker AsyncPublisher setelah event terkirim atau gagal.
+// Callback berjalan di goroutine worker, jadi harus cepat dan tidak boleh mempublikasikan
+// event lewat publisher yang sama saat buffer bisa penuh. Keduanya boleh nil; jika OnFailure
+// nil kegagalan dicatat di log.
+type DeliveryCallbacks struct {
+	OnSuccess func(topic string, ce CloudEvent)
+	OnFailure func(topic string, ce CloudEvent, err error)
+}
+
+// asyncItem adalah satu event di buffer
+type asyncItem struct {
+	topic string
+	ce    CloudEvent
+}
+
+// asyncWorker memiliki buffer sendiri; flush meminta batch yang belum penuh segera dikirim
+type asyncWorker struct {
+	queue chan asyncItem
+	flush chan struct{}
+}
+
+// AsyncPublisher membuat Publish tidak menunggu broker (KAFKA_PRODUCER_ASYNC=true).
+// Event dibungkus CloudEvent di goroutine pemanggil (trace context, origin dan sequence diambil
+// dari ctx request), dimasukkan ke buffer terbatas, lalu dikirim per batch oleh worker di
+// background lewat publisher di belakangnya. Event dengan key yang sama selalu masuk ke worker
+// yang sama sehingga urutannya tetap terjaga.
+//
+// Jika buffer penuh, perilakunya mengikuti cfg.Overflow: "block" menunggu sampai ada ruang atau
+// ctx selesai, "drop" membuang event (OnFailure dengan ErrBufferFull), "error" mengembalikan
+// ErrBufferFull ke pemanggil. Panggil Flush saat shutdown agar event di buffer tidak hilang.
+type AsyncPublisher struct {
+	inner        BatchPublisher
+	envelope     Envelope
+	callbacks    DeliveryCallbacks
+	overflow     string
+	batchSize    int
+	batchTimeout time.Duration
+	workers      []asyncWorker
+
+	mu      sync.RWMutex // Publish memegang read lock, Close menutup queue dengan write lock
+	closed  bool
+	pending atomic.Int64 // event yang sudah diterima tetapi belum selesai dikirim
+	wg      sync.WaitGroup
+}
+
+// NewAsyncPublisher membuat AsyncPublisher di depan inner dan menjalankan cfg.Workers worker.
+// Ukuran batch dan jeda maksimum batch mengikuti cfg.BatchSize dan cfg.BatchTimeout.
+func NewAsyncPublisher(inner BatchPublisher, envelope Envelope, cfg config.ProducerConfig, callbacks DeliveryCallbacks) *AsyncPublisher {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	perWorker := cfg.BufferSize / workers
+	if perWorker <= 0 {
+		perWorker = 1
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	p := &AsyncPublisher{
+		inner:        inner,
+		envelope:     envelope,
+		callbacks:    callbacks,
+		overflow:     cfg.Overflow,
+		batchSize:    batchSize,
+		batchTimeout: cfg.BatchTimeout,
+		workers:      make([]asyncWorker, workers),
+	}
+	for i := range p.workers {
+		p.workers[i] = asyncWorker{
+			queue: make(chan asyncItem, perWorker),
+			flush: make(chan struct{}, 1),
+		}
+		p.wg.Add(1)
+		go p.run(p.workers[i])
+	}
+	return p
+}
+
+// Publish membungkus value sebagai CloudEvent lalu memasukkannya ke buffer.
+// Nil berarti event diterima (atau dibuang karena overflow=drop), bukan sudah sampai di broker;
+// hasil pengiriman dilaporkan lewat DeliveryCallbacks.
+func (p *AsyncPublisher) Publish(ctx context.Context, topic string, key string, value interface{}) error {
+	ce, err := p.envelope.Wrap(ctx, key, value)
+	if err != nil {
+		return err
+	}
+	return p.enqueue(ctx, asyncItem{topic: topic, ce: ce})
+}
+
+// PublishBatch memasukkan messages ke buffer sesuai urutan
+func (p *AsyncPublisher) PublishBatch(ctx context.Context, topic string, messages []Message) error {
+	for _, m := range messages {
+		if err := p.Publish(ctx, topic, m.Key, m.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueue memasukkan item ke buffer worker untuk key-nya sesuai kebijakan overflow
+func (p *AsyncPublisher) enqueue(ctx context.Context, item asyncItem) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPublisherClosed
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(MessageKey(item.ce)))
+	queue := p.workers[h.Sum32()%uint32(len(p.workers))].queue
+
+	p.pending.Add(1)
+	select {
+	case queue <- item:
+		return nil
+	default:
+	}
+
+	switch p.overflow {
+	case "drop":
+		p.pending.Add(-1)
+		p.failed(item, ErrBufferFull)
+		return nil
+	case "error":
+		p.pending.Add(-1)
+		return ErrBufferFull
+	}
+
+	// block: tunggu sampai worker mengosongkan sebagian buffer
+	select {
+	case queue <- item:
+		return nil
+	case <-ctx.Done():
+		p.pending.Add(-1)
+		return fmt.Errorf("waiting for event buffer: %w", ctx.Err())
+	}
+}
+
+// run mengumpulkan event dari buffer worker dan mengirimnya setiap batch penuh, setiap
+// batchTimeout, atau saat Flush. Worker berhenti setelah queue ditutup dan sisa batch terkirim.
+func (p *AsyncPublisher) run(w asyncWorker) {
+	defer p.wg.Done()
+
+	batch := make([]asyncItem, 0, p.batchSize)
+	timer := time.NewTimer(p.batchTimeout)
+	timer.Stop()
+	var timeout <-chan time.Time
+
+	send := func() {
+		if len(batch) > 0 {
+			p.send(batch)
+			batch = batch[:0]
+		}
+		timer.Stop()
+		timeout = nil
+	}
+
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				send()
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= p.batchSize {
+				send()
+			} else if timeout == nil {
+				timer.Reset(p.batchTimeout)
+				timeout = timer.C
+			}
+		case <-timeout:
+			timeout = nil
+			send()
+		case <-w.flush:
+			send()
+		}
+	}
+}
+
+// send mengirim batch per topic (urutan di dalam topic tetap) lalu memanggil callback
+func (p *AsyncPublisher) send(batch []asyncItem) {
+	defer p.pending.Add(-int64(len(batch)))
+
+	var topics []string
+	byTopic := map[string][]asyncItem{}
+	for _, item := range batch {
+		if _, ok := byTopic[item.topic]; !ok {
+			topics = append(topics, item.topic)
+		}
+		byTopic[item.topic] = append(byTopic[item.topic], item)
+	}
+
+	for _, topic := range topics {
+		items := byTopic[topic]
+		messages := make([]Message, 0, len(items))
+		for _, item := range items {
+			messages = append(messages, Message{Key: item.ce.Type, Value: item.ce})
+		}
+
+		// Request asal sudah selesai; span publish melanjutkan trace dari ekstensi traceparent
+		err := p.inner.PublishBatch(context.Background(), topic, messages)
+		for _, item := range items {
+			if err != nil {
+				p.failed(item, err)
+			} else if p.callbacks.OnSuccess != nil {
+				p.callbacks.OnSuccess(item.topic, item.ce)
+			}
+		}
+	}
+}
+
+// failed melaporkan event yang tidak terkirim
+func (p *AsyncPublisher) failed(item asyncItem, err error) {
+	if p.callbacks.OnFailure != nil {
+		p.callbacks.OnFailure(item.topic, item.ce, err)
+		return
+	}
+	log.Printf("❌ Async publish of %s (%s) to '%s' failed: %v", item.ce.Type, item.ce.ID, item.topic, err)
+}
+
+// Flush mengirim semua batch yang belum penuh dan menunggu sampai buffer kosong atau ctx selesai
+func (p *AsyncPublisher) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		for _, w := range p.workers {
+			select {
+			case w.flush <- struct{}{}:
+			default: // permintaan flush sebelumnya belum diambil worker
+			}
+		}
+		if p.pending.Load() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d event(s) not flushed: %w", p.pending.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Pending mengembalikan jumlah event yang belum selesai dikirim
+func (p *AsyncPublisher) Pending() int64 {
+	return p.pending.Load()
+}
+
+// Close menolak event baru, menunggu worker mengirim sisa buffer, lalu berhenti.
+// Publisher di belakangnya tidak ditutup.
+func (p *AsyncPublisher) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	for _, w := range p.workers {
+		close(w.queue)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return nil
+}
@@ -0,0 +1,263 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-crud-clean-arch/config"
+)
+
+// gatedPublisher adalah BatchPublisher palsu yang menahan setiap batch sampai gate dibuka,
+// sehingga test bisa mengisi buffer AsyncPublisher secara deterministik
+type gatedPublisher struct {
+	gate    chan struct{}
+	started chan struct{}
+	err     error
+
+	mu        sync.Mutex
+	delivered []string
+}
+
+func newGatedPublisher(open bool) *gatedPublisher {
+	p := &gatedPublisher{gate: make(chan struct{}), started: make(chan struct{}, 100)}
+	if open {
+		close(p.gate)
+	}
+	return p
+}
+
+func (p *gatedPublisher) PublishBatch(_ context.Context, _ string, messages []Message) error {
+	p.started <- struct{}{}
+	<-p.gate
+	if p.err != nil {
+		return p.err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, m := range messages {
+		p.delivered = append(p.delivered, m.Value.(CloudEvent).ID)
+	}
+	return nil
+}
+
+func (p *gatedPublisher) ids() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.delivered...)
+}
+
+// deliveryLog mencatat hasil DeliveryCallbacks
+type deliveryLog struct {
+	mu        sync.Mutex
+	succeeded []string
+	failed    map[string]error
+}
+
+func (l *deliveryLog) callbacks() DeliveryCallbacks {
+	l.failed = map[string]error{}
+	return DeliveryCallbacks{
+		OnSuccess: func(_ string, ce CloudEvent) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.succeeded = append(l.succeeded, ce.ID)
+		},
+		OnFailure: func(_ string, ce CloudEvent, err error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.failed[ce.ID] = err
+		},
+	}
+}
+
+func (l *deliveryLog) failure(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.failed[id]
+}
+
+func testAsyncConfig(overflow string, batchSize int, batchTimeout time.Duration) config.ProducerConfig {
+	return config.ProducerConfig{
+		BatchSize:    batchSize,
+		BatchTimeout: batchTimeout,
+		BufferSize:   1,
+		Workers:      1,
+		Overflow:     overflow,
+	}
+}
+
+func publishID(ctx context.Context, p *AsyncPublisher, id string) error {
+	return p.Publish(ctx, "user-events", "user.created", CloudEvent{ID: id, Type: "user.created"})
+}
+
+func TestAsyncPublisherOverflow(t *testing.T) {
+	tests := []struct {
+		overflow    string
+		wantErr     error
+		wantFailure error
+		wantSent    []string
+	}{
+		{overflow: "error", wantErr: ErrBufferFull, wantSent: []string{"e1", "e2"}},
+		{overflow: "drop", wantFailure: ErrBufferFull, wantSent: []string{"e1", "e2"}},
+		{overflow: "block", wantErr: context.DeadlineExceeded, wantSent: []string{"e1", "e2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overflow, func(t *testing.T) {
+			inner := newGatedPublisher(false)
+			var deliveries deliveryLog
+			p := NewAsyncPublisher(inner, NewEnvelope(config.EventConfig{}), testAsyncConfig(tt.overflow, 1, time.Millisecond), deliveries.callbacks())
+			defer p.Close()
+
+			ctx := context.Background()
+			// e1 ditahan worker di PublishBatch, e2 mengisi buffer (kapasitas 1)
+			if err := publishID(ctx, p, "e1"); err != nil {
+				t.Fatal(err)
+			}
+			<-inner.started
+			if err := publishID(ctx, p, "e2"); err != nil {
+				t.Fatal(err)
+			}
+
+			timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			err := publishID(timeoutCtx, p, "e3")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Publish on full buffer = %v, want %v", err, tt.wantErr)
+			}
+			if got := deliveries.failure("e3"); !errors.Is(got, tt.wantFailure) {
+				t.Fatalf("OnFailure(e3) = %v, want %v", got, tt.wantFailure)
+			}
+			if got := p.Pending(); got != 2 {
+				t.Fatalf("Pending = %d, want 2 (the rejected event is not counted)", got)
+			}
+
+			close(inner.gate)
+			if err := p.Flush(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if got := inner.ids(); fmt.Sprint(got) != fmt.Sprint(tt.wantSent) {
+				t.Fatalf("delivered = %v, want %v", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestAsyncPublisherBlockWaitsForSpace(t *testing.T) {
+	inner := newGatedPublisher(false)
+	p := NewAsyncPublisher(inner, NewEnvelope(config.EventConfig{}), testAsyncConfig("block", 1, time.Millisecond), DeliveryCallbacks{})
+	defer p.Close()
+
+	ctx := context.Background()
+	publishID(ctx, p, "e1")
+	<-inner.started
+	publishID(ctx, p, "e2")
+
+	done := make(chan error, 1)
+	go func() { done <- publishID(ctx, p, "e3") }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Publish returned %v before the buffer had space", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(inner.gate)
+	if err := <-done; err != nil {
+		t.Fatalf("Publish = %v, want nil once the buffer drains", err)
+	}
+	if err := p.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := inner.ids(); fmt.Sprint(got) != "[e1 e2 e3]" {
+		t.Fatalf("delivered = %v, want [e1 e2 e3]", got)
+	}
+}
+
+func TestAsyncPublisherFlush(t *testing.T) {
+	tests := []struct {
+		name        string
+		open        bool
+		innerErr    error
+		flushWithin time.Duration
+		wantErr     error
+		wantSuccess int
+		wantFailed  int
+	}{
+		{name: "sends partial batch", open: true, flushWithin: time.Second, wantSuccess: 3},
+		{name: "reports delivery failures", open: true, innerErr: errors.New("broker down"), flushWithin: time.Second, wantFailed: 3},
+		{name: "gives up when ctx ends", open: false, flushWithin: 30 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newGatedPublisher(tt.open)
+			inner.err = tt.innerErr
+			var deliveries deliveryLog
+			// Batch tidak pernah penuh dan timeout-nya lama, jadi hanya Flush yang mengirim
+			cfg := testAsyncConfig("error", 100, time.Hour)
+			cfg.BufferSize = 10
+			p := NewAsyncPublisher(inner, NewEnvelope(config.EventConfig{}), cfg, deliveries.callbacks())
+
+			ctx := context.Background()
+			for _, id := range []string{"e1", "e2", "e3"} {
+				if err := publishID(ctx, p, id); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			flushCtx, cancel := context.WithTimeout(ctx, tt.flushWithin)
+			defer cancel()
+			err := p.Flush(flushCtx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Flush = %v, want %v", err, tt.wantErr)
+			}
+
+			if !tt.open {
+				close(inner.gate)
+			}
+			p.Close()
+
+			deliveries.mu.Lock()
+			defer deliveries.mu.Unlock()
+			if tt.wantErr == nil && (len(deliveries.succeeded) != tt.wantSuccess || len(deliveries.failed) != tt.wantFailed) {
+				t.Fatalf("succeeded = %v, failed = %v, want %d succeeded and %d failed", deliveries.succeeded, deliveries.failed, tt.wantSuccess, tt.wantFailed)
+			}
+			if tt.wantErr == nil && fmt.Sprint(deliveries.succeeded) != fmt.Sprint(inner.ids()) {
+				t.Fatalf("OnSuccess order %v differs from delivery order %v", deliveries.succeeded, inner.ids())
+			}
+		})
+	}
+}
+
+func TestAsyncPublisherClose(t *testing.T) {
+	inner := newGatedPublisher(true)
+	cfg := testAsyncConfig("error", 100, time.Hour)
+	cfg.BufferSize = 10
+	p := NewAsyncPublisher(inner, NewEnvelope(config.EventConfig{}), cfg, DeliveryCallbacks{})
+
+	ctx := context.Background()
+	for _, id := range []string{"e1", "e2"} {
+		if err := publishID(ctx, p, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	// Close mengirim sisa buffer sebelum worker berhenti
+	if got := inner.ids(); fmt.Sprint(got) != "[e1 e2]" {
+		t.Fatalf("delivered = %v, want [e1 e2]", got)
+	}
+	if got := p.Pending(); got != 0 {
+		t.Fatalf("Pending = %d after Close, want 0", got)
+	}
+	if err := publishID(ctx, p, "e3"); !errors.Is(err, ErrPublisherClosed) {
+		t.Fatalf("Publish after Close = %v, want ErrPublisherClosed", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("second Close = %v, want nil", err)
+	}
+}
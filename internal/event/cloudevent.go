@@ -0,0 +1,268 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-crud-clean-arch/config"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// SpecVersion adalah versi spesifikasi CloudEvents yang dipakai
+	SpecVersion = "1.0"
+
+	// SchemaVersion adalah versi skema data event, bagian dari atribut dataschema
+	SchemaVersion = "v1"
+
+	ContentTypeJSON            = "application/json"
+	ContentTypeCloudEventsJSON = "application/cloudevents+json"
+
+	// headerContentType dan headerPrefix mengikuti CloudEvents Kafka protocol binding
+	headerContentType = "content-type"
+	headerPrefix      = "ce_"
+)
+
+// ErrInvalidCloudEvent dikembalikan jika pesan tidak memiliki atribut wajib CloudEvents
+var ErrInvalidCloudEvent = errors.New("invalid cloudevent")
+
+// ContentMode menentukan cara CloudEvent ditulis ke pesan Kafka
+type ContentMode string
+
+const (
+	// StructuredMode: seluruh envelope (atribut + data) di-encode sebagai JSON di body pesan
+	StructuredMode ContentMode = "structured"
+	// BinaryMode: body pesan hanya berisi data, atribut dikirim sebagai header ce_*
+	BinaryMode ContentMode = "binary"
+)
+
+// CloudEvent adalah envelope CloudEvents 1.0 untuk semua event yang dipublikasikan
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	Subject         string          `json:"subject,omitempty"` // id entitas
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Backend         string          `json:"backend,omitempty"`     // ekstensi: backend yang mempublikasikan event
+	Origin          string          `json:"origin,omitempty"`      // ekstensi: backend tempat perubahan pertama kali terjadi
+	TraceParent     string          `json:"traceparent,omitempty"` // ekstensi distributed tracing: span saat event dibuat
+	TraceState      string          `json:"tracestate,omitempty"`
+	Sequence        int64           `json:"sequence,omitempty"` // ekstensi: nomor urut per agregat, naik terus sejak 1
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// DataAs men-decode data event ke v
+func (e CloudEvent) DataAs(v interface{}) error {
+	if len(e.Data) == 0 {
+		return fmt.Errorf("%w: event %s has no data", ErrInvalidCloudEvent, e.ID)
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+// validate memastikan atribut wajib CloudEvents terisi
+func (e CloudEvent) validate() error {
+	if e.SpecVersion == "" || e.ID == "" || e.Source == "" || e.Type == "" {
+		return fmt.Errorf("%w: specversion, id, source and type are required", ErrInvalidCloudEvent)
+	}
+	return nil
+}
+
+// Envelope membungkus data event menjadi CloudEvent dan meng-encode-nya ke pesan Kafka
+type Envelope struct {
+	source        string
+	schemaBaseURL string
+	mode          ContentMode
+	backend       string
+	sequencer     Sequencer
+}
+
+// NewEnvelope membuat Envelope dari konfigurasi EVENT_*
+func NewEnvelope(cfg config.EventConfig) Envelope {
+	return Envelope{
+		source:        cfg.Source,
+		schemaBaseURL: strings.TrimSuffix(cfg.SchemaBaseURL, "/"),
+		mode:          ContentMode(cfg.ContentMode),
+	}
+}
+
+// ForBackend mengembalikan salinan Envelope yang menandai event dengan ekstensi backend/origin
+func (e Envelope) ForBackend(backend string) Envelope {
+	e.backend = backend
+	return e
+}
+
+// Wrap membuat CloudEvent baru dengan id unik. Data yang sudah berupa CloudEvent
+// (misalnya dari outbox) dikembalikan apa adanya agar id dan time tidak berubah saat retry.
+// Origin diambil dari ctx (lihat WithOrigin), default-nya backend envelope.
+func (e Envelope) Wrap(ctx context.Context, eventType string, data interface{}) (CloudEvent, error) {
+	switch ce := data.(type) {
+	case CloudEvent:
+		return ce, nil
+	case *CloudEvent:
+		return *ce, nil
+	}
+
+	payload, err := MarshalData(data)
+	if err != nil {
+		return CloudEvent{}, err
+	}
+	traceParent, traceState := traceContextOf(ctx)
+
+	ce := CloudEvent{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.NewString(),
+		Source:          e.source,
+		Type:            eventType,
+		Time:            time.Now().UTC(),
+		Subject:         subjectOf(payload),
+		DataContentType: ContentTypeJSON,
+		DataSchema:      fmt.Sprintf("%s/%s/%s.json", e.schemaBaseURL, SchemaVersion, eventType),
+		Backend:         e.backend,
+		Origin:          originOr(ctx, e.backend),
+		TraceParent:     traceParent,
+		TraceState:      traceState,
+		Data:            payload,
+	}
+	if err := e.assignSequence(ctx, &ce); err != nil {
+		return CloudEvent{}, fmt.Errorf("failed to assign event sequence: %w", err)
+	}
+	return ce, nil
+}
+
+// Encode menulis CloudEvent ke pesan Kafka sesuai content mode
+func (e Envelope) Encode(ce CloudEvent, key string) (kafka.Message, error) {
+	msg := kafka.Message{
+		Key:  []byte(key),
+		Time: ce.Time,
+	}
+
+	if e.mode == BinaryMode {
+		msg.Value = ce.Data
+		msg.Headers = []kafka.Header{
+			{Key: headerContentType, Value: []byte(ce.DataContentType)},
+			{Key: headerPrefix + "specversion", Value: []byte(ce.SpecVersion)},
+			{Key: headerPrefix + "id", Value: []byte(ce.ID)},
+			{Key: headerPrefix + "source", Value: []byte(ce.Source)},
+			{Key: headerPrefix + "type", Value: []byte(ce.Type)},
+			{Key: headerPrefix + "time", Value: []byte(ce.Time.Format(time.RFC3339Nano))},
+		}
+		if ce.Subject != "" {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: headerPrefix + "subject", Value: []byte(ce.Subject)})
+		}
+		if ce.DataSchema != "" {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: headerPrefix + "dataschema", Value: []byte(ce.DataSchema)})
+		}
+		if ce.Backend != "" {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: headerPrefix + "backend", Value: []byte(ce.Backend)})
+		}
+		if ce.Origin != "" {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: headerPrefix + "origin", Value: []byte(ce.Origin)})
+		}
+		if ce.TraceParent != "" {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: headerPrefix + "traceparent", Value: []byte(ce.TraceParent)})
+		}
+		if ce.TraceState != "" {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: headerPrefix + "tracestate", Value: []byte(ce.TraceState)})
+		}
+		if ce.Sequence > 0 {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: headerPrefix + "sequence", Value: []byte(strconv.FormatInt(ce.Sequence, 10))})
+		}
+		return msg, nil
+	}
+
+	value, err := json.Marshal(ce)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal cloudevent: %w", err)
+	}
+	msg.Value = value
+	msg.Headers = []kafka.Header{{Key: headerContentType, Value: []byte(ContentTypeCloudEventsJSON)}}
+	return msg, nil
+}
+
+// Decode membaca CloudEvent dari pesan Kafka dalam binary maupun structured mode.
+// Pesan lama tanpa envelope dibaca dengan key sebagai type dan body sebagai data.
+func Decode(msg kafka.Message) (CloudEvent, error) {
+	msg.Value = decompressLegacy(msg.Value)
+
+	headers := map[string]string{}
+	for _, h := range msg.Headers {
+		headers[strings.ToLower(h.Key)] = string(h.Value)
+	}
+
+	// Binary mode
+	if specVersion, ok := headers[headerPrefix+"specversion"]; ok {
+		ce := CloudEvent{
+			SpecVersion:     specVersion,
+			ID:              headers[headerPrefix+"id"],
+			Source:          headers[headerPrefix+"source"],
+			Type:            headers[headerPrefix+"type"],
+			Subject:         headers[headerPrefix+"subject"],
+			DataContentType: headers[headerContentType],
+			DataSchema:      headers[headerPrefix+"dataschema"],
+			Backend:         headers[headerPrefix+"backend"],
+			Origin:          headers[headerPrefix+"origin"],
+			TraceParent:     headers[headerPrefix+"traceparent"],
+			TraceState:      headers[headerPrefix+"tracestate"],
+			Data:            msg.Value,
+		}
+		if raw := headers[headerPrefix+"sequence"]; raw != "" {
+			seq, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return CloudEvent{}, fmt.Errorf("%w: invalid sequence %q", ErrInvalidCloudEvent, raw)
+			}
+			ce.Sequence = seq
+		}
+		if raw := headers[headerPrefix+"time"]; raw != "" {
+			t, err := time.Parse(time.RFC3339Nano, raw)
+			if err != nil {
+				return CloudEvent{}, fmt.Errorf("%w: invalid time %q", ErrInvalidCloudEvent, raw)
+			}
+			ce.Time = t
+		}
+		return ce, ce.validate()
+	}
+
+	// Structured mode, content-type bisa hilang jika pesan melewati tool lain
+	structured := strings.HasPrefix(headers[headerContentType], ContentTypeCloudEventsJSON)
+	var ce CloudEvent
+	if err := json.Unmarshal(msg.Value, &ce); err == nil && (structured || ce.SpecVersion != "") {
+		return ce, ce.validate()
+	} else if structured {
+		return CloudEvent{}, fmt.Errorf("%w: %v", ErrInvalidCloudEvent, err)
+	}
+
+	// Pesan lama: {key: type, value: data}
+	return CloudEvent{
+		Type:            string(msg.Key),
+		Time:            msg.Time,
+		Subject:         subjectOf(msg.Value),
+		DataContentType: ContentTypeJSON,
+		Data:            msg.Value,
+	}, nil
+}
+
+// subjectOf mengambil field "id" dari data event sebagai atribut subject
+func subjectOf(payload []byte) string {
+	var doc struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(payload, &doc); err != nil || len(doc.ID) == 0 || string(doc.ID) == "null" {
+		return ""
+	}
+
+	var id string
+	if err := json.Unmarshal(doc.ID, &id); err == nil {
+		return id
+	}
+	return string(doc.ID) // id numerik dipakai apa adanya
+}
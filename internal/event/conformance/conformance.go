@@ -0,0 +1,285 @@
+// Package conformance berisi pengecekan perilaku yang sama untuk semua implementasi event.Bus
+// (Kafka, Redis Streams, NATS JetStream, in-memory). Dijalankan terhadap backend sungguhan lewat
+// `main conformance -backend <nama>` dan oleh go test (lihat conformance_test.go).
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/google/uuid"
+)
+
+// eventType adalah type CloudEvent yang dipublikasikan oleh suite
+const eventType = "conformance.checked"
+
+// Options mengatur waktu tunggu suite
+type Options struct {
+	Settle  time.Duration // jeda setelah consumer dijalankan sebelum publish (join group, rebalance)
+	Timeout time.Duration // batas waktu setiap case menunggu event
+	// Prepare dipanggil sebelum setiap case dengan topic barunya, mis. untuk membuat topic Kafka
+	// jika broker tidak membuat topic otomatis. Boleh nil.
+	Prepare func(ctx context.Context, topic string) error
+}
+
+// Result adalah hasil satu case
+type Result struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// Case adalah satu pengecekan perilaku Bus terhadap topic baru yang belum pernah dipakai
+type Case struct {
+	Name string
+	Run  func(ctx context.Context, bus event.Bus, topic string, opts Options) error
+}
+
+// Cases mengembalikan semua case yang harus dipenuhi setiap backend
+func Cases() []Case {
+	return []Case{
+		{Name: "publish-consume", Run: publishConsume},
+		{Name: "batch-order", Run: batchOrder},
+		{Name: "redelivery", Run: redelivery},
+		{Name: "group-sharing", Run: groupSharing},
+		{Name: "fan-out", Run: fanOut},
+	}
+}
+
+// Run menjalankan semua case secara berurutan, masing-masing di topic sendiri
+func Run(ctx context.Context, bus event.Bus, opts Options) []Result {
+	results := make([]Result, 0, len(Cases()))
+	for _, c := range Cases() {
+		topic := NewTopic(c)
+		start := time.Now()
+		var err error
+		if opts.Prepare != nil {
+			err = opts.Prepare(ctx, topic)
+		}
+		if err == nil {
+			err = c.Run(ctx, bus, topic, opts)
+		}
+		results = append(results, Result{Name: c.Name, Duration: time.Since(start), Err: err})
+	}
+	return results
+}
+
+// NewTopic membuat nama topic baru yang belum pernah dipakai untuk satu case, mis. conformance-fan-out-1a2b3c4d
+func NewTopic(c Case) string {
+	return fmt.Sprintf("conformance-%s-%s", c.Name, uuid.NewString()[:8])
+}
+
+// payload adalah data event suite; id menjadi subject sekaligus key pesan
+type payload struct {
+	ID string `json:"id"`
+	N  int    `json:"n"`
+}
+
+// collector mencatat event yang diterima handler
+type collector struct {
+	mu     sync.Mutex
+	events []event.CloudEvent
+	fail   func(ce event.CloudEvent) error // opsional, error yang dikembalikan handler
+}
+
+func (c *collector) handle(ctx context.Context, ce event.CloudEvent) error {
+	c.mu.Lock()
+	c.events = append(c.events, ce)
+	fail := c.fail
+	c.mu.Unlock()
+	if fail != nil {
+		return fail(ce)
+	}
+	return nil
+}
+
+func (c *collector) snapshot() []event.CloudEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]event.CloudEvent(nil), c.events...)
+}
+
+// waitFor menunggu sampai cond terpenuhi atau timeout
+func waitFor(ctx context.Context, timeout time.Duration, cond func() bool) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for events")
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// consume menjalankan bus.Consume di background, lalu menunggu opts.Settle.
+// Fungsi yang dikembalikan menghentikan consumer dan menunggu sampai Consume selesai.
+func consume(ctx context.Context, bus event.Bus, topic, group string, opts Options, handler event.Subscriber) (stop func() error) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- bus.Consume(ctx, topic, group, handler) }()
+
+	select {
+	case err := <-done:
+		// Consume berhenti sebelum waktunya (mis. group tidak bisa dibuat)
+		cancel()
+		return func() error { return err }
+	case <-time.After(opts.Settle):
+	}
+	return func() error {
+		cancel()
+		return <-done
+	}
+}
+
+// newGroup membuat nama consumer group unik untuk topic
+func newGroup(topic, name string) string {
+	return topic + "-" + name
+}
+
+func publishConsume(ctx context.Context, bus event.Bus, topic string, opts Options) error {
+	c := &collector{}
+	stop := consume(ctx, bus, topic, newGroup(topic, "a"), opts, c.handle)
+	defer stop()
+
+	if err := bus.Publish(ctx, topic, eventType, payload{ID: "agg-1", N: 1}); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	if err := waitFor(ctx, opts.Timeout, func() bool { return len(c.snapshot()) >= 1 }); err != nil {
+		return err
+	}
+
+	ce := c.snapshot()[0]
+	if ce.ID == "" || ce.SpecVersion != event.SpecVersion || ce.Type != eventType {
+		return fmt.Errorf("unexpected cloudevent attributes: id=%q specversion=%q type=%q", ce.ID, ce.SpecVersion, ce.Type)
+	}
+	if ce.Subject != "agg-1" {
+		return fmt.Errorf("expected subject agg-1, got %q", ce.Subject)
+	}
+	var p payload
+	if err := ce.DataAs(&p); err != nil {
+		return fmt.Errorf("decode data: %w", err)
+	}
+	if p != (payload{ID: "agg-1", N: 1}) {
+		return fmt.Errorf("unexpected data %+v", p)
+	}
+	return nil
+}
+
+func batchOrder(ctx context.Context, bus event.Bus, topic string, opts Options) error {
+	const total = 20
+	c := &collector{}
+	stop := consume(ctx, bus, topic, newGroup(topic, "a"), opts, c.handle)
+	defer stop()
+
+	messages := make([]event.Message, 0, total)
+	for i := 0; i < total; i++ {
+		messages = append(messages, event.Message{Key: eventType, Value: payload{ID: "agg-1", N: i}})
+	}
+	if err := bus.PublishBatch(ctx, topic, messages); err != nil {
+		return fmt.Errorf("publish batch: %w", err)
+	}
+	if err := waitFor(ctx, opts.Timeout, func() bool { return len(c.snapshot()) >= total }); err != nil {
+		return err
+	}
+
+	for i, ce := range c.snapshot()[:total] {
+		var p payload
+		if err := ce.DataAs(&p); err != nil {
+			return fmt.Errorf("decode data: %w", err)
+		}
+		if p.N != i {
+			return fmt.Errorf("event %d received at position %d, events of one key must keep their order", p.N, i)
+		}
+	}
+	return nil
+}
+
+func redelivery(ctx context.Context, bus event.Bus, topic string, opts Options) error {
+	c := &collector{}
+	var once sync.Once
+	c.fail = func(ce event.CloudEvent) error {
+		var err error
+		once.Do(func() { err = errors.New("conformance: first delivery fails") })
+		return err
+	}
+	stop := consume(ctx, bus, topic, newGroup(topic, "a"), opts, c.handle)
+	defer stop()
+
+	if err := bus.Publish(ctx, topic, eventType, payload{ID: "agg-1", N: 1}); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	if err := waitFor(ctx, opts.Timeout, func() bool { return len(c.snapshot()) >= 2 }); err != nil {
+		return fmt.Errorf("event was not redelivered after a handler error: %w", err)
+	}
+	events := c.snapshot()
+	if events[0].ID != events[1].ID {
+		return fmt.Errorf("redelivered event has id %q, expected %q", events[1].ID, events[0].ID)
+	}
+	return nil
+}
+
+func groupSharing(ctx context.Context, bus event.Bus, topic string, opts Options) error {
+	const total = 10
+	group := newGroup(topic, "shared")
+	a, b := &collector{}, &collector{}
+	stopA := consume(ctx, bus, topic, group, opts, a.handle)
+	defer stopA()
+	stopB := consume(ctx, bus, topic, group, opts, b.handle)
+	defer stopB()
+
+	messages := make([]event.Message, 0, total)
+	for i := 0; i < total; i++ {
+		messages = append(messages, event.Message{Key: eventType, Value: payload{ID: fmt.Sprintf("agg-%d", i), N: i}})
+	}
+	if err := bus.PublishBatch(ctx, topic, messages); err != nil {
+		return fmt.Errorf("publish batch: %w", err)
+	}
+
+	received := func() int { return len(a.snapshot()) + len(b.snapshot()) }
+	if err := waitFor(ctx, opts.Timeout, func() bool { return received() >= total }); err != nil {
+		return err
+	}
+	// Beri waktu untuk duplikat yang seharusnya tidak ada
+	time.Sleep(opts.Settle)
+
+	seen := map[string]bool{}
+	for _, ce := range append(a.snapshot(), b.snapshot()...) {
+		if seen[ce.ID] {
+			return fmt.Errorf("event %s delivered to more than one consumer of group %s", ce.ID, group)
+		}
+		seen[ce.ID] = true
+	}
+	if len(seen) != total {
+		return fmt.Errorf("expected %d distinct events, got %d", total, len(seen))
+	}
+	return nil
+}
+
+func fanOut(ctx context.Context, bus event.Bus, topic string, opts Options) error {
+	a, b := &collector{}, &collector{}
+	stopA := consume(ctx, bus, topic, newGroup(topic, "a"), opts, a.handle)
+	defer stopA()
+	stopB := consume(ctx, bus, topic, newGroup(topic, "b"), opts, b.handle)
+	defer stopB()
+
+	if err := bus.Publish(ctx, topic, eventType, payload{ID: "agg-1", N: 1}); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	err := waitFor(ctx, opts.Timeout, func() bool { return len(a.snapshot()) >= 1 && len(b.snapshot()) >= 1 })
+	if err != nil {
+		return fmt.Errorf("every consumer group must receive the event: %w", err)
+	}
+	if a.snapshot()[0].ID != b.snapshot()[0].ID {
+		return errors.New("consumer groups received different events")
+	}
+	return nil
+}
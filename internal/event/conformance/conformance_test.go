@@ -0,0 +1,162 @@
+package conformance_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"golang-crud-clean-arch/config"
+	"golang-crud-clean-arch/internal/event"
+	"golang-crud-clean-arch/internal/event/conformance"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// runCases menjalankan setiap case sebagai subtest di topic baru. cleanup dipanggil dengan
+// topic setiap case setelah selesai, boleh nil.
+func runCases(t *testing.T, bus event.Bus, opts conformance.Options, cleanup func(topic string)) {
+	t.Helper()
+	for _, c := range conformance.Cases() {
+		t.Run(c.Name, func(t *testing.T) {
+			topic := conformance.NewTopic(c)
+			if cleanup != nil {
+				t.Cleanup(func() { cleanup(topic) })
+			}
+			if err := c.Run(context.Background(), bus, topic, opts); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// serverAddr membaca alamat server dari env. Di CI (CI diset, lihat .github/workflows/test.yml)
+// server wajib tersedia agar suite Redis dan NATS tidak diam-diam dilewati; di lokal test di-skip.
+func serverAddr(t *testing.T, env string) string {
+	t.Helper()
+	addr := os.Getenv(env)
+	if addr != "" {
+		return addr
+	}
+	if os.Getenv("CI") != "" {
+		t.Fatalf("%s is not set; CI must start the server for this conformance suite", env)
+	}
+	t.Skipf("%s is not set", env)
+	return ""
+}
+
+func testEnvelope() event.Envelope {
+	return event.NewEnvelope(config.EventConfig{Source: "/conformance-test", ContentMode: "structured"})
+}
+
+func TestMemoryBroker(t *testing.T) {
+	bus := event.NewMemoryBroker(testEnvelope())
+	defer bus.Close()
+
+	runCases(t, bus, conformance.Options{Settle: 50 * time.Millisecond, Timeout: 5 * time.Second}, nil)
+}
+
+// TestRedisStreamBus butuh Redis sungguhan di REDIS_ADDR, mis. REDIS_ADDR=127.0.0.1:6379 go test ./...
+func TestRedisStreamBus(t *testing.T) {
+	addr := serverAddr(t, "REDIS_ADDR")
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")})
+	defer client.Close()
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("failed to connect to Redis at %s: %v", addr, err)
+	}
+
+	// Redelivery lewat XCLAIM dipercepat agar case redelivery tidak menunggu satu menit
+	cfg := config.LoadRedisStreamConfig()
+	cfg.ClaimMinIdle = time.Second
+	cfg.ClaimInterval = 500 * time.Millisecond
+	cfg.BlockTimeout = 500 * time.Millisecond
+	bus := event.NewRedisStreamBus(client, testEnvelope(), cfg)
+	defer bus.Close()
+
+	runCases(t, bus, conformance.Options{Settle: 200 * time.Millisecond, Timeout: 15 * time.Second}, func(topic string) {
+		client.Del(context.Background(), event.StreamKey(topic), event.StreamKey(topic)+".dlq")
+	})
+
+	// Consumer yang berhenti tanpa pesan pending dihapus dari group
+	t.Run("consumer removed on exit", func(t *testing.T) {
+		topic := conformance.NewTopic(conformance.Case{Name: "consumer-cleanup"})
+		stream := event.StreamKey(topic)
+		t.Cleanup(func() { client.Del(context.Background(), stream) })
+
+		// Consumer ikut group saat menerima pesan pertama
+		if err := bus.Publish(context.Background(), topic, "k1", map[string]string{"id": "k1"}); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handled := make(chan struct{}, 1)
+		done := make(chan error, 1)
+		go func() {
+			done <- bus.Consume(ctx, topic, "cleanup-group", func(context.Context, event.CloudEvent) error {
+				select {
+				case handled <- struct{}{}:
+				default:
+				}
+				return nil
+			})
+		}()
+
+		select {
+		case <-handled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("consumer did not receive the event")
+		}
+		if n := consumerCount(t, client, stream, "cleanup-group"); n != 1 {
+			t.Fatalf("group has %d consumer(s) while consuming, want 1", n)
+		}
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+		if n := consumerCount(t, client, stream, "cleanup-group"); n != 0 {
+			t.Fatalf("group still has %d consumer(s) after Consume returned", n)
+		}
+	})
+}
+
+// consumerCount mengembalikan jumlah consumer di group, 0 jika group belum ada
+func consumerCount(t *testing.T, client *redis.Client, stream, group string) int {
+	t.Helper()
+	reply, err := client.Do(context.Background(), "XINFO", "CONSUMERS", stream, group).Result()
+	if err != nil {
+		return 0
+	}
+	rows, _ := reply.([]interface{})
+	return len(rows)
+}
+
+// TestJetStreamBus butuh server NATS dengan JetStream di NATS_URL, mis. NATS_URL=nats://127.0.0.1:4222 go test ./...
+func TestJetStreamBus(t *testing.T) {
+	url := serverAddr(t, "NATS_URL")
+	conn, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("failed to connect to NATS at %s: %v", url, err)
+	}
+	defer conn.Close()
+	js, err := jetstream.New(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NAK dengan jeda singkat agar case redelivery tidak menunggu RetryDelay
+	cfg := config.LoadNATSConfig()
+	cfg.RetryDelay = 200 * time.Millisecond
+	cfg.FetchTimeout = 500 * time.Millisecond
+	bus, err := event.NewJetStreamBus(conn, testEnvelope(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close()
+
+	runCases(t, bus, conformance.Options{Settle: 200 * time.Millisecond, Timeout: 15 * time.Second}, func(topic string) {
+		js.DeleteStream(context.Background(), event.JetStreamName(topic))
+	})
+}
@@ -11,6 +11,48 @@ type EventPublisher interface {
 	Publish(ctx context.Context, topic string, key string, value interface{}) error
 }
 
+// Message adalah satu event dalam publish batch
+type Message struct {
+	Key   string
+	Value interface{}
+}
+
+// BatchPublisher diimplementasikan oleh publisher yang bisa mengirim banyak event dalam satu write.
+type BatchPublisher interface {
+	PublishBatch(ctx context.Context, topic string, messages []Message) error
+}
+
+// Bus adalah backend event yang bisa mempublikasikan sekaligus mengonsumsi event
+// (Kafka, Redis Streams, atau in-memory), dipilih lewat EVENT_BACKEND.
+//
+// Consume memanggil handler untuk setiap event di topic sampai ctx selesai. Consumer dengan
+// group yang sama berbagi event (setiap event diproses satu consumer), group berbeda masing-masing
+// menerima semua event. Event yang handler-nya gagal dikirim ulang (at-least-once) dan consumer
+// tunggal menerima event dengan key yang sama sesuai urutan publish.
+type Bus interface {
+	EventPublisher
+	BatchPublisher
+	Consume(ctx context.Context, topic, group string, handler Subscriber) error
+	Close() error
+}
+
+// PublishAll mengirim messages lewat PublishBatch jika publisher mendukungnya,
+// jika tidak event dikirim satu per satu.
+func PublishAll(ctx context.Context, publisher EventPublisher, topic string, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	if batch, ok := publisher.(BatchPublisher); ok {
+		return batch.PublishBatch(ctx, topic, messages)
+	}
+	for _, msg := range messages {
+		if err := publisher.Publish(ctx, topic, msg.Key, msg.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func MarshalData(data interface{}) ([]byte, error) {
 	marshaledData, err := json.Marshal(data)
 	if err != nil {
@@ -2,31 +2,39 @@ package event
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sync"
-	"time"
+
+	"golang-crud-clean-arch/config"
 
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // KafkaPublisher adalah struct untuk publish event ke Kafka.
 type KafkaPublisher struct {
-	brokers []string                 // Daftar alamat broker Kafka
-	writers map[string]*kafka.Writer // Map writer Kafka berdasarkan topik
-	mu      sync.Mutex               // Mutex untuk menghindari race condition
+	brokers  []string                 // Daftar alamat broker Kafka
+	writers  map[string]*kafka.Writer // Map writer Kafka berdasarkan topik
+	mu       sync.Mutex               // Mutex untuk menghindari race condition
+	envelope Envelope                 // Pembungkus CloudEvents untuk setiap pesan
+	producer config.ProducerConfig    // Codec, acks, batching, retry dan mode async writer
+	tracer   trace.Tracer             // Span producer per pesan, trace context ikut di header
 }
 
 // NewKafkaPublisher menginisialisasi KafkaPublisher dan membuat topik jika belum ada.
-func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+// Setiap event dikirim dalam envelope CloudEvents sesuai content mode di envelope,
+// dengan writer yang dikonfigurasi oleh producer (lihat NewWriter).
+func NewKafkaPublisher(brokers []string, topic string, envelope Envelope, producer config.ProducerConfig) *KafkaPublisher {
 	return &KafkaPublisher{
-		brokers: brokers,
+		brokers:  brokers,
+		envelope: envelope,
+		producer: producer,
+		tracer:   otel.Tracer("kafka-publisher"),
 		writers: map[string]*kafka.Writer{
-			topic: &kafka.Writer{
-				Addr:     kafka.TCP(brokers...), // Alamat broker Kafka
-				Topic:    topic,                 // Nama topik
-				Balancer: &kafka.LeastBytes{},   // Load balancing berdasarkan ukuran pesan
-			},
+			topic: NewWriter(brokers, topic, producer),
 		},
 	}
 }
@@ -38,36 +46,83 @@ func (p *KafkaPublisher) getWriter(topic string) *kafka.Writer {
 
 	writer, exists := p.writers[topic]
 	if !exists {
-		writer = &kafka.Writer{
-			Addr:     kafka.TCP(p.brokers...),
-			Topic:    topic,
-			Balancer: &kafka.LeastBytes{},
-		}
+		writer = NewWriter(p.brokers, topic, p.producer)
 		p.writers[topic] = writer
 	}
 	return writer
 }
 
-// Publish mengirimkan pesan ke Kafka dengan topik, key, dan value.
-// Value akan di-encode menjadi JSON sebelum dikirim.
+// Publish mengirimkan satu event ke topic Kafka. Argumen key adalah tipe event, bukan key partisi:
+// key pesan Kafka selalu MessageKey(ce), yaitu id agregat, agar event satu entitas tetap berurutan.
+// value di-encode menjadi JSON sebagai data CloudEvent.
 func (p *KafkaPublisher) Publish(ctx context.Context, topic string, key string, value interface{}) error {
+	return p.PublishBatch(ctx, topic, []Message{{Key: key, Value: value}})
+}
+
+// PublishBatch mengirimkan banyak pesan ke satu topik dalam satu WriteMessages.
+// Setiap pesan mendapat span producer sendiri yang di-inject ke header (W3C traceparent/baggage).
+func (p *KafkaPublisher) PublishBatch(ctx context.Context, topic string, messages []Message) error {
 	writer := p.getWriter(topic)
 
-	// Konversi value ke format JSON
-	bytes, err := json.Marshal(value)
+	msgs := make([]kafka.Message, 0, len(messages))
+	spans := make([]trace.Span, 0, len(messages))
+	defer func() {
+		for _, span := range spans {
+			span.End()
+		}
+	}()
+
+	for _, m := range messages {
+		msg, span, err := p.encode(ctx, topic, m.Key, m.Value)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+		spans = append(spans, span)
+	}
+
+	// Kirim semua pesan ke Kafka sekaligus
+	err := writer.WriteMessages(ctx, msgs...)
+	for _, span := range spans {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "publish failed")
+		} else {
+			span.SetStatus(codes.Ok, "published")
+		}
+	}
+	return err
+}
+
+// encode membungkus value dalam CloudEvent bertipe key lalu mengubahnya menjadi pesan Kafka
+// beserta span producer-nya. Jika ctx tidak membawa span (relay outbox), parent span diambil
+// dari ekstensi traceparent yang tersimpan saat event dibuat.
+func (p *KafkaPublisher) encode(ctx context.Context, topic, key string, value interface{}) (kafka.Message, trace.Span, error) {
+	ce, err := p.envelope.Wrap(ctx, key, value)
 	if err != nil {
-		return fmt.Errorf("failed to marshal value: %w", err)
+		return kafka.Message{}, nil, err
 	}
 
-	// Buat pesan Kafka
-	msg := kafka.Message{
-		Key:   []byte(key),
-		Value: bytes,
-		Time:  time.Now(),
+	if !hasSpan(ctx) {
+		ctx = ce.TraceContext(ctx)
 	}
+	ctx, span := p.tracer.Start(ctx, "publish "+topic, trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination.name", topic),
+		attribute.String("cloudevents.event_id", ce.ID),
+		attribute.String("cloudevents.event_type", ce.Type),
+	)
 
-	// Kirim pesan ke Kafka
-	return writer.WriteMessages(ctx, msg)
+	msg, err := p.envelope.Encode(ce, MessageKey(ce))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "encode failed")
+		span.End()
+		return kafka.Message{}, nil, err
+	}
+	InjectTraceContext(ctx, &msg)
+	return msg, span, nil
 }
 
 // Close menutup semua writer Kafka untuk membebaskan resource.
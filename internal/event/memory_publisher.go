@@ -0,0 +1,177 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// RecordedMessage adalah event yang dipublikasikan ke MemoryBroker
+type RecordedMessage struct {
+	Topic string
+	Key   string // key pesan, sama dengan KafkaPublisher (id agregat)
+	Event CloudEvent
+}
+
+// Subscriber menerima event dari MemoryBroker; tipenya sama dengan kafka.HandlerFunc
+// sehingga Router.Dispatch bisa langsung didaftarkan
+type Subscriber func(ctx context.Context, ce CloudEvent) error
+
+// memoryAttempts adalah jumlah percobaan handler sebelum event dianggap gagal di MemoryBroker
+const memoryAttempts = 3
+
+// memoryMember adalah satu subscriber di dalam group
+type memoryMember struct {
+	id      int
+	handler Subscriber
+}
+
+// memoryGroup membagi event ke anggotanya secara round-robin
+type memoryGroup struct {
+	members []memoryMember
+	next    int
+}
+
+// MemoryBroker adalah Bus in-process untuk unit test dan development tanpa Kafka
+// (EVENT_BACKEND=memory). Setiap event dibungkus CloudEvent seperti KafkaPublisher, dicatat
+// untuk assertion, lalu dikirim secara sinkron ke satu subscriber di setiap group topic tersebut.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	envelope Envelope
+	messages []RecordedMessage
+	groups   map[string]map[string]*memoryGroup // topic -> group -> anggota
+	nextID   int
+}
+
+// NewMemoryBroker membuat MemoryBroker kosong
+func NewMemoryBroker(envelope Envelope) *MemoryBroker {
+	return &MemoryBroker{
+		envelope: envelope,
+		groups:   map[string]map[string]*memoryGroup{},
+	}
+}
+
+// Publish mencatat event lalu meneruskannya ke satu subscriber di setiap group topic.
+// Handler yang gagal dicoba ulang sampai memoryAttempts kali, setelah itu error-nya hanya
+// dicatat di log, seperti consumer Kafka yang terpisah dari publisher.
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, key string, value interface{}) error {
+	ce, err := b.envelope.Wrap(ctx, key, value)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.messages = append(b.messages, RecordedMessage{Topic: topic, Key: MessageKey(ce), Event: ce})
+	subscribers := make([]Subscriber, 0, len(b.groups[topic]))
+	for _, g := range b.groups[topic] {
+		if len(g.members) == 0 {
+			continue
+		}
+		subscribers = append(subscribers, g.members[g.next%len(g.members)].handler)
+		g.next++
+	}
+	b.mu.Unlock()
+
+	// Lock dilepas sebelum deliver agar subscriber boleh mempublikasikan event lain
+	for _, s := range subscribers {
+		deliver(ctx, s, ce)
+	}
+	return nil
+}
+
+// deliver memanggil subscriber sampai berhasil atau memoryAttempts habis
+func deliver(ctx context.Context, s Subscriber, ce CloudEvent) {
+	var err error
+	for attempt := 1; attempt <= memoryAttempts; attempt++ {
+		if err = s(ctx, ce); err == nil {
+			return
+		}
+	}
+	log.Printf("❌ In-memory subscriber failed for %s (%s) after %d attempts: %v", ce.Type, ce.ID, memoryAttempts, err)
+}
+
+// PublishBatch mempublikasikan messages satu per satu sesuai urutan
+func (b *MemoryBroker) PublishBatch(ctx context.Context, topic string, messages []Message) error {
+	for _, m := range messages {
+		if err := b.Publish(ctx, topic, m.Key, m.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe mendaftarkan subscriber yang menerima semua event topic (group sendiri)
+// dan mengembalikan fungsi untuk berhenti berlangganan
+func (b *MemoryBroker) Subscribe(topic string, s Subscriber) (unsubscribe func()) {
+	return b.join(topic, "", s)
+}
+
+// Consume mendaftarkan handler ke group topic sampai ctx selesai. Anggota group yang sama
+// menerima event secara bergiliran.
+func (b *MemoryBroker) Consume(ctx context.Context, topic, group string, handler Subscriber) error {
+	leave := b.join(topic, group, handler)
+	defer leave()
+	<-ctx.Done()
+	return nil
+}
+
+// join menambahkan handler sebagai anggota group dan mengembalikan fungsi untuk keluar dari group.
+// Group kosong berarti group sendiri yang namanya diambil dari id anggota, dialokasikan di bawah lock
+// yang sama agar dua Subscribe bersamaan tidak mendapat group yang sama.
+func (b *MemoryBroker) join(topic, group string, handler Subscriber) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	if group == "" {
+		group = fmt.Sprintf("subscriber-%d", id)
+	}
+	if b.groups[topic] == nil {
+		b.groups[topic] = map[string]*memoryGroup{}
+	}
+	g := b.groups[topic][group]
+	if g == nil {
+		g = &memoryGroup{}
+		b.groups[topic][group] = g
+	}
+	g.members = append(g.members, memoryMember{id: id, handler: handler})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, m := range g.members {
+			if m.id == id {
+				g.members = append(g.members[:i], g.members[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Messages mengembalikan salinan event yang sudah dipublikasikan ke topic, atau semua topic jika kosong
+func (b *MemoryBroker) Messages(topic string) []RecordedMessage {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var out []RecordedMessage
+	for _, m := range b.messages {
+		if topic == "" || m.Topic == topic {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Reset menghapus semua event yang tercatat, subscriber tetap terdaftar
+func (b *MemoryBroker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = nil
+}
+
+// Close memenuhi interface Bus
+func (b *MemoryBroker) Close() error {
+	return nil
+}
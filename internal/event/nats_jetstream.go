@@ -0,0 +1,286 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-crud-clean-arch/config"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header pesan JetStream yang ditulis JetStreamBus
+const (
+	natsHeaderKey   = "X-Message-Key" // key pesan (id agregat), sama dengan key Kafka
+	natsHeaderError = "X-Dead-Letter-Reason"
+)
+
+// JetStreamSubject mengembalikan subject NATS untuk topic, mis. events.user-events
+func JetStreamSubject(topic string) string {
+	return "events." + topic
+}
+
+// JetStreamName mengembalikan nama stream JetStream untuk topic. Nama stream tidak boleh
+// mengandung titik, wildcard, spasi atau pemisah path.
+func JetStreamName(topic string) string {
+	return "EVENTS_" + strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_", "/", "_", "\\", "_").Replace(topic)
+}
+
+// JetStreamBus adalah Bus di atas NATS JetStream (EVENT_BACKEND=nats).
+// Setiap topic adalah satu stream dengan subject events.{topic}; event dipublikasikan dengan
+// Nats-Msg-Id = id CloudEvent sehingga publish ulang dalam duplicate window JetStream diabaikan.
+// Setiap consumer group adalah satu durable pull consumer, anggota group yang sama berbagi pesan.
+// Pesan di-ack setelah handler berhasil; yang gagal di-NAK dan dikirim ulang setelah RetryDelay,
+// pesan milik consumer yang mati dikirim ulang setelah AckWait. Setelah MaxDeliveries pesan
+// dipindah ke subject events.{topic}.dlq di stream yang sama.
+//
+// Seperti Redis Streams, urutan per key hanya terjamin selama tidak ada redelivery.
+type JetStreamBus struct {
+	js       jetstream.JetStream
+	envelope Envelope
+	cfg      config.NATSConfig
+	tracer   trace.Tracer
+
+	mu      sync.Mutex
+	streams map[string]bool // topic yang stream-nya sudah dipastikan ada
+}
+
+// NewJetStreamBus membuat JetStreamBus. Koneksi dimiliki pemanggil dan tidak ditutup oleh Close.
+func NewJetStreamBus(conn *nats.Conn, envelope Envelope, cfg config.NATSConfig) (*JetStreamBus, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+	return &JetStreamBus{
+		js:       js,
+		envelope: envelope,
+		cfg:      cfg,
+		tracer:   otel.Tracer("nats-jetstream-consumer"),
+		streams:  map[string]bool{},
+	}, nil
+}
+
+// Publish membungkus value sebagai CloudEvent lalu mempublikasikannya ke subject topic
+func (b *JetStreamBus) Publish(ctx context.Context, topic string, key string, value interface{}) error {
+	return b.PublishBatch(ctx, topic, []Message{{Key: key, Value: value}})
+}
+
+// PublishBatch mempublikasikan semua messages secara async lalu menunggu ack JetStream untuk semuanya
+func (b *JetStreamBus) PublishBatch(ctx context.Context, topic string, messages []Message) error {
+	if err := b.ensureStream(ctx, topic); err != nil {
+		return err
+	}
+
+	futures := make([]jetstream.PubAckFuture, 0, len(messages))
+	for _, m := range messages {
+		ce, err := b.envelope.Wrap(ctx, m.Key, m.Value)
+		if err != nil {
+			return err
+		}
+		body, err := json.Marshal(ce)
+		if err != nil {
+			return fmt.Errorf("failed to marshal cloudevent: %w", err)
+		}
+
+		msg := nats.NewMsg(JetStreamSubject(topic))
+		msg.Data = body
+		msg.Header.Set(natsHeaderKey, MessageKey(ce))
+		msg.Header.Set("Content-Type", ContentTypeCloudEventsJSON)
+		future, err := b.js.PublishMsgAsync(msg, jetstream.WithMsgID(ce.ID))
+		if err != nil {
+			return fmt.Errorf("failed to publish event to '%s': %w", topic, err)
+		}
+		futures = append(futures, future)
+	}
+
+	for _, future := range futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			return fmt.Errorf("failed to publish %d event(s) to '%s': %w", len(messages), topic, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// ensureStream membuat stream topic jika belum ada. Subject dead-letter disimpan di stream yang sama.
+func (b *JetStreamBus) ensureStream(ctx context.Context, topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.streams[topic] {
+		return nil
+	}
+
+	subject := JetStreamSubject(topic)
+	_, err := b.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     JetStreamName(topic),
+		Subjects: []string{subject, subject + ".dlq"},
+		MaxAge:   b.cfg.MaxAge,
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create stream for '%s': %w", topic, err)
+	}
+	b.streams[topic] = true
+	return nil
+}
+
+// Consume membaca stream topic lewat durable consumer group sampai ctx selesai.
+// Group baru membaca stream dari awal, sama seperti consumer group Kafka baru.
+func (b *JetStreamBus) Consume(ctx context.Context, topic, group string, handler Subscriber) error {
+	if handler == nil {
+		return errors.New("nats jetstream consumer: handler is required")
+	}
+	if err := b.ensureStream(ctx, topic); err != nil {
+		return err
+	}
+
+	subject := JetStreamSubject(topic)
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, JetStreamName(topic), jetstream.ConsumerConfig{
+		Durable:       JetStreamName(group),
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		AckWait:       b.cfg.AckWait,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer group %s on '%s': %w", group, subject, err)
+	}
+	log.Printf("📥 NATS JetStream consumer started for '%s' (group %s)", subject, group)
+
+	for ctx.Err() == nil {
+		batch, err := consumer.Fetch(b.cfg.FetchBatch, jetstream.FetchMaxWait(b.cfg.FetchTimeout))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("❌ Error fetching from '%s': %v", subject, err)
+			if err := sleepCtx(ctx, time.Second); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		for msg := range batch.Messages() {
+			b.process(ctx, topic, group, msg, handler)
+		}
+		if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) && ctx.Err() == nil {
+			log.Printf("❌ Error fetching from '%s': %v", subject, err)
+		}
+	}
+	return nil
+}
+
+// process menjalankan handler untuk satu pesan. Pesan di-ack jika berhasil atau sudah dipindah ke
+// dead-letter subject; selain itu pesan di-NAK agar dikirim ulang setelah RetryDelay.
+func (b *JetStreamBus) process(ctx context.Context, topic, group string, msg jetstream.Msg, handler Subscriber) {
+	var delivery uint64 = 1
+	if meta, err := msg.Metadata(); err == nil {
+		delivery = meta.NumDelivered
+	}
+
+	var ce CloudEvent
+	err := json.Unmarshal(msg.Data(), &ce)
+	if err == nil {
+		err = ce.validate()
+	}
+	if err != nil {
+		// Pesan yang tidak bisa di-decode tidak akan berhasil di-retry
+		log.Printf("⚠️ Invalid event in '%s': %v", msg.Subject(), err)
+		b.deadLetter(ctx, topic, msg, err)
+		return
+	}
+
+	// Span consumer per pesan, melanjutkan trace dari ekstensi traceparent event
+	ctx, span := b.startProcessSpan(ctx, group, msg, delivery, ce)
+	defer span.End()
+
+	if err := handler(ctx, ce); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "handler failed")
+		if delivery >= uint64(b.cfg.MaxDeliveries) {
+			log.Printf("❌ Failed to handle event %s (%s) after %d deliveries: %v", ce.ID, ce.Type, delivery, err)
+			b.deadLetter(ctx, topic, msg, err)
+			return
+		}
+		log.Printf("⚠️ Failed to handle event %s (%s), delivery %d: %v (retry after %s)", ce.ID, ce.Type, delivery, err, b.cfg.RetryDelay)
+		if err := msg.NakWithDelay(b.cfg.RetryDelay); err != nil {
+			log.Printf("❌ Failed to nak event %s of '%s': %v", ce.ID, msg.Subject(), err)
+		}
+		return
+	}
+	span.SetStatus(codes.Ok, "processed")
+
+	if err := msg.Ack(); err != nil {
+		// Pesan akan dikirim ulang setelah AckWait, middleware Dedup melewatinya
+		log.Printf("❌ Failed to ack event %s of '%s': %v", ce.ID, msg.Subject(), err)
+		return
+	}
+	log.Printf("✅ Message processed from subject '%s': %s %s", msg.Subject(), ce.Type, ce.ID)
+}
+
+// deadLetter menyalin pesan ke events.{topic}.dlq beserta alasannya lalu meng-ack pesan aslinya
+func (b *JetStreamBus) deadLetter(ctx context.Context, topic string, msg jetstream.Msg, cause error) {
+	dlq := JetStreamSubject(topic) + ".dlq"
+	out := nats.NewMsg(dlq)
+	out.Data = msg.Data()
+	for k, v := range msg.Headers() {
+		if k != jetstream.MsgIDHeader {
+			out.Header[k] = v
+		}
+	}
+	out.Header.Set(natsHeaderError, cause.Error())
+
+	if _, err := b.js.PublishMsg(ctx, out); err != nil {
+		// Tanpa ack pesan dikirim ulang dan dicoba dipindah lagi pada pengiriman berikutnya
+		log.Printf("❌ Failed to move message of '%s' to %s: %v", msg.Subject(), dlq, err)
+		_ = msg.NakWithDelay(b.cfg.RetryDelay)
+		return
+	}
+	if err := msg.Ack(); err != nil {
+		log.Printf("❌ Failed to ack message of '%s': %v", msg.Subject(), err)
+		return
+	}
+	log.Printf("↪️ Message of '%s' moved to %s", msg.Subject(), dlq)
+}
+
+// startProcessSpan membuat span consumer untuk satu pesan JetStream
+func (b *JetStreamBus) startProcessSpan(ctx context.Context, group string, msg jetstream.Msg, delivery uint64, ce CloudEvent) (context.Context, trace.Span) {
+	ctx = ce.TraceContext(ctx)
+
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.destination.name", msg.Subject()),
+			attribute.String("messaging.consumer.group.name", group),
+			attribute.String("messaging.nats.message.key", msg.Headers().Get(natsHeaderKey)),
+			attribute.Int64("messaging.nats.delivery_count", int64(delivery)),
+			attribute.String("cloudevents.event_id", ce.ID),
+			attribute.String("cloudevents.event_type", ce.Type),
+		),
+	}
+	if remote := trace.SpanContextFromContext(ctx); remote.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: remote}))
+	}
+	return b.tracer.Start(ctx, "process "+msg.Subject(), opts...)
+}
+
+// Close memenuhi interface Bus; koneksi NATS ditutup oleh pemiliknya
+func (b *JetStreamBus) Close() error {
+	return nil
+}
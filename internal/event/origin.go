@@ -0,0 +1,36 @@
+package event
+
+import "context"
+
+// Nama backend untuk ekstensi CloudEvent backend dan origin
+const (
+	BackendPostgres = "postgres"
+	BackendMongo    = "mongo"
+)
+
+type originKey struct{}
+
+// WithOrigin menandai bahwa perubahan yang ditulis dengan ctx berasal dari backend lain.
+// Dipakai oleh sync worker agar event hasil sinkronisasi tidak disinkronkan balik.
+func WithOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// OriginFrom mengembalikan origin yang disimpan di ctx, atau string kosong
+func OriginFrom(ctx context.Context) string {
+	origin, _ := ctx.Value(originKey{}).(string)
+	return origin
+}
+
+func originOr(ctx context.Context, fallback string) string {
+	if origin := OriginFrom(ctx); origin != "" {
+		return origin
+	}
+	return fallback
+}
+
+// IsReplica melaporkan apakah event dipublikasikan oleh backend yang bukan asal perubahannya,
+// yaitu event dari penulisan sync worker
+func (e CloudEvent) IsReplica() bool {
+	return e.Backend != "" && e.Origin != "" && e.Backend != e.Origin
+}
@@ -0,0 +1,89 @@
+package event
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+
+	"golang-crud-clean-arch/config"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// compressionCodecs memetakan KAFKA_PRODUCER_COMPRESSION ke codec kafka-go
+var compressionCodecs = map[string]kafka.Compression{
+	"gzip":   kafka.Gzip,
+	"snappy": kafka.Snappy,
+	"lz4":    kafka.Lz4,
+	"zstd":   kafka.Zstd,
+}
+
+// requiredAcks memetakan KAFKA_PRODUCER_ACKS ke level ack kafka-go
+var requiredAcks = map[string]kafka.RequiredAcks{
+	"all":  kafka.RequireAll,
+	"one":  kafka.RequireOne,
+	"none": kafka.RequireNone,
+}
+
+// ErrIdempotentUnsupported dikembalikan ValidateProducerConfig jika KAFKA_PRODUCER_IDEMPOTENT=true
+var ErrIdempotentUnsupported = errors.New("KAFKA_PRODUCER_IDEMPOTENT=true is not supported: kafka-go has no idempotent producer; " +
+	"retried writes can be duplicated and are dropped by the consumer-side Dedup middleware instead")
+
+// ValidateProducerConfig menolak setting producer yang tidak bisa dipenuhi kafka-go.
+// Dipanggil saat startup agar idempotent producer tidak diam-diam diabaikan.
+func ValidateProducerConfig(cfg config.ProducerConfig) error {
+	if cfg.Idempotent {
+		return ErrIdempotentUnsupported
+	}
+	return nil
+}
+
+// NewWriter membuat kafka.Writer sesuai ProducerConfig. Semua jalur publish (KafkaPublisher,
+// kafka.PublishEvent, Forwarder) memakai writer ini sehingga pesan yang dihasilkan seragam.
+// Kompresi dilakukan per record batch oleh kafka-go dan di-dekompresi otomatis oleh reader,
+// jadi consumer tidak perlu tahu codec yang dipakai. Topic boleh kosong jika setiap pesan
+// menentukan topic-nya sendiri.
+//
+// kafka-go tidak mendukung idempotent producer (producer id/epoch), sehingga retry bisa
+// menghasilkan duplikat. Penggantinya adalah deduplikasi di sisi consumer: setiap event membawa
+// id CloudEvents yang sama di semua percobaan, dan middleware Dedup (kafka.Dedup) melewati id
+// yang sudah diproses selama CONSUMER_DEDUP_TTL. Jaminannya at-least-once di log Kafka dengan
+// pemrosesan sekali per consumer group dalam jendela TTL tersebut.
+//
+// Writer selalu sinkron: mode async (cfg.Async) diatur oleh AsyncPublisher di depan publisher,
+// sehingga hasil setiap pengiriman bisa dilaporkan lewat callback.
+func NewWriter(brokers []string, topic string, cfg config.ProducerConfig) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{}, // Partition berdasarkan key (id agregat)
+		Compression:  compressionCodecs[cfg.Compression],
+		RequiredAcks: requiredAcks[cfg.RequiredAcks],
+		BatchSize:    cfg.BatchSize,
+		BatchTimeout: cfg.BatchTimeout,
+		MaxAttempts:  cfg.MaxAttempts,
+	}
+}
+
+// gzipMagic adalah dua byte pertama data gzip
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompressLegacy membuka payload yang di-gzip manual oleh versi lama kafka.PublishEvent.
+// Payload lain (termasuk yang dikompresi oleh codec Kafka) dikembalikan apa adanya.
+func decompressLegacy(value []byte) []byte {
+	if !bytes.HasPrefix(value, gzipMagic) {
+		return value
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return value
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return value
+	}
+	return decompressed
+}
@@ -0,0 +1,27 @@
+package event
+
+import (
+	"errors"
+	"testing"
+
+	"golang-crud-clean-arch/config"
+)
+
+func TestValidateProducerConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProducerConfig
+		want error
+	}{
+		{name: "defaults", cfg: config.ProducerConfig{Compression: "none", RequiredAcks: "all"}},
+		{name: "async with compression", cfg: config.ProducerConfig{Compression: "zstd", RequiredAcks: "one", Async: true}},
+		{name: "idempotent", cfg: config.ProducerConfig{Compression: "none", RequiredAcks: "all", Idempotent: true}, want: ErrIdempotentUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateProducerConfig(tt.cfg); !errors.Is(err, tt.want) {
+				t.Fatalf("ValidateProducerConfig() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"golang-crud-clean-arch/config"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field entry stream yang ditulis RedisStreamBus
+const (
+	streamFieldKey   = "key"   // key pesan (id agregat), sama dengan key Kafka
+	streamFieldEvent = "event" // CloudEvent dalam structured mode (JSON)
+	streamFieldError = "error" // alasan event dipindah ke dead-letter stream
+)
+
+// StreamKey mengembalikan key Redis untuk stream topic, mis. events:user-events
+func StreamKey(topic string) string {
+	return "events:" + topic
+}
+
+// RedisStreamBus adalah Bus di atas Redis Streams (EVENT_BACKEND=redis).
+// Setiap topic adalah satu stream; event ditulis dengan XADD dan dibaca lewat consumer group
+// (XREADGROUP). Pesan baru di-ack (XACK) setelah handler berhasil, pesan yang gagal tetap
+// pending dan dikirim ulang ke consumer mana pun lewat XCLAIM setelah ClaimMinIdle, termasuk
+// pesan milik consumer yang mati di tengah proses. Setelah MaxDeliveries pesan dipindah ke
+// stream {topic}.dlq. Consumer dihapus dari group (XGROUP DELCONSUMER) saat Consume selesai,
+// dan consumer yang mati tanpa sempat berhenti dihapus setelah ConsumerIdleTimeout; keduanya
+// hanya jika tidak punya pesan pending, karena DELCONSUMER ikut membuang pesan pending-nya.
+//
+// Satu stream tidak dipartisi, jadi urutan per key hanya terjamin selama tidak ada redelivery.
+type RedisStreamBus struct {
+	client   *redis.Client
+	envelope Envelope
+	cfg      config.RedisStreamConfig
+	tracer   trace.Tracer
+}
+
+// NewRedisStreamBus membuat RedisStreamBus. Client dimiliki pemanggil dan tidak ditutup oleh Close.
+func NewRedisStreamBus(client *redis.Client, envelope Envelope, cfg config.RedisStreamConfig) *RedisStreamBus {
+	return &RedisStreamBus{
+		client:   client,
+		envelope: envelope,
+		cfg:      cfg,
+		tracer:   otel.Tracer("redis-stream-consumer"),
+	}
+}
+
+// Publish membungkus value sebagai CloudEvent lalu menambahkannya ke stream topic
+func (b *RedisStreamBus) Publish(ctx context.Context, topic string, key string, value interface{}) error {
+	return b.PublishBatch(ctx, topic, []Message{{Key: key, Value: value}})
+}
+
+// PublishBatch menulis semua messages dalam satu pipeline XADD
+func (b *RedisStreamBus) PublishBatch(ctx context.Context, topic string, messages []Message) error {
+	entries := make([]map[string]interface{}, 0, len(messages))
+	for _, m := range messages {
+		ce, err := b.envelope.Wrap(ctx, m.Key, m.Value)
+		if err != nil {
+			return err
+		}
+		body, err := json.Marshal(ce)
+		if err != nil {
+			return fmt.Errorf("failed to marshal cloudevent: %w", err)
+		}
+		entries = append(entries, map[string]interface{}{
+			streamFieldKey:   MessageKey(ce),
+			streamFieldEvent: body,
+		})
+	}
+
+	_, err := b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, values := range entries {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: StreamKey(topic),
+				MaxLen: b.cfg.MaxLen,
+				Approx: true,
+				Values: values,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish %d event(s) to stream '%s': %w", len(entries), topic, err)
+	}
+	return nil
+}
+
+// Consume membaca stream topic sebagai anggota group sampai ctx selesai.
+// Nama consumer unik per pemanggilan, sehingga beberapa Consume dengan group yang sama berbagi event.
+func (b *RedisStreamBus) Consume(ctx context.Context, topic, group string, handler Subscriber) error {
+	if handler == nil {
+		return errors.New("redis stream consumer: handler is required")
+	}
+	stream := StreamKey(topic)
+	if err := b.ensureGroup(ctx, stream, group); err != nil {
+		return err
+	}
+	consumer := consumerName()
+	log.Printf("📥 Redis Stream consumer %s started for '%s' (group %s)", consumer, stream, group)
+	defer b.removeConsumer(stream, group, consumer)
+
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		// Ambil alih pesan yang terlalu lama pending (handler gagal atau consumer mati),
+		// lalu hapus consumer mati yang sudah tidak punya pesan pending
+		if time.Since(lastClaim) >= b.cfg.ClaimInterval {
+			b.claimStuck(ctx, stream, group, consumer, handler)
+			b.pruneConsumers(ctx, stream, group, consumer)
+			lastClaim = time.Now()
+		}
+
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{stream, ">"},
+			Count:    b.cfg.BatchSize,
+			Block:    b.cfg.BlockTimeout,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("❌ Error reading stream '%s': %v", stream, err)
+			// Stream atau group bisa terhapus (mis. FLUSHALL), buat ulang sebelum membaca lagi
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				_ = b.ensureGroup(ctx, stream, group)
+			}
+			if err := sleepCtx(ctx, time.Second); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				b.process(ctx, stream, group, msg, 1, handler)
+			}
+		}
+	}
+	return nil
+}
+
+// ensureGroup membuat stream dan consumer group jika belum ada. Group baru membaca stream dari awal,
+// sama seperti consumer group Kafka baru.
+func (b *RedisStreamBus) ensureGroup(ctx context.Context, stream, group string) error {
+	err := b.client.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s on '%s': %w", group, stream, err)
+	}
+	return nil
+}
+
+// claimStuck memproses ulang pesan pending yang idle lebih lama dari ClaimMinIdle
+func (b *RedisStreamBus) claimStuck(ctx context.Context, stream, group, consumer string, handler Subscriber) {
+	pending, err := b.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  group,
+		Idle:   b.cfg.ClaimMinIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  b.cfg.BatchSize,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("❌ Failed to list pending messages of '%s': %v", stream, err)
+		}
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(pending))
+	deliveries := make(map[string]int64, len(pending))
+	for _, p := range pending {
+		ids = append(ids, p.ID)
+		deliveries[p.ID] = p.RetryCount + 1 // XCLAIM menambah delivery count
+	}
+
+	// XCLAIM dengan MinIdle yang sama: pesan yang sudah diambil consumer lain dilewati
+	claimed, err := b.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  b.cfg.ClaimMinIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		log.Printf("❌ Failed to claim pending messages of '%s': %v", stream, err)
+		return
+	}
+	for _, msg := range claimed {
+		log.Printf("🔁 Claimed pending message %s of '%s' (delivery %d)", msg.ID, stream, deliveries[msg.ID])
+		b.process(ctx, stream, group, msg, deliveries[msg.ID], handler)
+	}
+}
+
+// removeConsumer menghapus consumer dari group saat Consume selesai. Consumer yang masih punya
+// pesan pending dibiarkan agar pesan itu di-claim consumer lain; pruneConsumers menghapusnya nanti.
+func (b *RedisStreamBus) removeConsumer(stream, group, consumer string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pending, err := b.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   stream,
+		Group:    group,
+		Start:    "-",
+		End:      "+",
+		Count:    1,
+		Consumer: consumer,
+	}).Result()
+	if err != nil {
+		log.Printf("⚠️ Failed to check pending messages of consumer %s on '%s': %v", consumer, stream, err)
+		return
+	}
+	if len(pending) > 0 {
+		log.Printf("⚠️ Consumer %s left pending messages on '%s', kept until they are claimed", consumer, stream)
+		return
+	}
+	if err := b.client.XGroupDelConsumer(ctx, stream, group, consumer).Err(); err != nil {
+		log.Printf("⚠️ Failed to remove consumer %s from group %s on '%s': %v", consumer, group, stream, err)
+	}
+}
+
+// pruneConsumers menghapus consumer lain di group yang tidak punya pesan pending dan idle lebih
+// lama dari ConsumerIdleTimeout, mis. consumer dari instance yang mati tanpa sempat berhenti.
+// XINFO CONSUMERS dibaca sebagai reply mentah karena jumlah field-nya berbeda antar versi Redis.
+func (b *RedisStreamBus) pruneConsumers(ctx context.Context, stream, group, self string) {
+	reply, err := b.client.Do(ctx, "XINFO", "CONSUMERS", stream, group).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("❌ Failed to list consumers of group %s on '%s': %v", group, stream, err)
+		}
+		return
+	}
+
+	for _, c := range parseStreamConsumers(reply) {
+		if c.name == self || c.pending > 0 || c.idle < b.cfg.ConsumerIdleTimeout {
+			continue
+		}
+		if err := b.client.XGroupDelConsumer(ctx, stream, group, c.name).Err(); err != nil {
+			log.Printf("❌ Failed to remove idle consumer %s from group %s on '%s': %v", c.name, group, stream, err)
+			continue
+		}
+		log.Printf("🧹 Removed idle consumer %s from group %s on '%s' (idle %s)", c.name, group, stream, c.idle)
+	}
+}
+
+// streamConsumer adalah satu baris XINFO CONSUMERS
+type streamConsumer struct {
+	name    string
+	pending int64
+	idle    time.Duration
+}
+
+// parseStreamConsumers membaca reply XINFO CONSUMERS: daftar pasangan field-nilai per consumer.
+// Field selain name, pending dan idle (mis. inactive di Redis 7.2) diabaikan.
+func parseStreamConsumers(reply interface{}) []streamConsumer {
+	rows, _ := reply.([]interface{})
+	consumers := make([]streamConsumer, 0, len(rows))
+	for _, row := range rows {
+		fields, _ := row.([]interface{})
+		var c streamConsumer
+		for i := 0; i+1 < len(fields); i += 2 {
+			key, _ := fields[i].(string)
+			switch key {
+			case "name":
+				c.name, _ = fields[i+1].(string)
+			case "pending":
+				c.pending, _ = fields[i+1].(int64)
+			case "idle":
+				idle, _ := fields[i+1].(int64)
+				c.idle = time.Duration(idle) * time.Millisecond
+			}
+		}
+		if c.name != "" {
+			consumers = append(consumers, c)
+		}
+	}
+	return consumers
+}
+
+// process menjalankan handler untuk satu entry stream. Pesan di-ack jika berhasil atau sudah
+// dipindah ke dead-letter stream; selain itu pesan tetap pending untuk di-claim ulang.
+func (b *RedisStreamBus) process(ctx context.Context, stream, group string, msg redis.XMessage, delivery int64, handler Subscriber) {
+	raw, _ := msg.Values[streamFieldEvent].(string)
+	var ce CloudEvent
+	err := json.Unmarshal([]byte(raw), &ce)
+	if err == nil {
+		err = ce.validate()
+	}
+	if err != nil {
+		// Pesan yang tidak bisa di-decode tidak akan berhasil di-retry
+		log.Printf("⚠️ Invalid event %s in '%s': %v", msg.ID, stream, err)
+		b.deadLetter(ctx, stream, group, msg, err)
+		return
+	}
+
+	// Span consumer per pesan, melanjutkan trace dari ekstensi traceparent event
+	ctx, span := b.startProcessSpan(ctx, stream, group, msg, delivery, ce)
+	defer span.End()
+
+	if err := handler(ctx, ce); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "handler failed")
+		if delivery >= b.cfg.MaxDeliveries {
+			log.Printf("❌ Failed to handle event %s (%s) after %d deliveries: %v", ce.ID, ce.Type, delivery, err)
+			b.deadLetter(ctx, stream, group, msg, err)
+			return
+		}
+		log.Printf("⚠️ Failed to handle event %s (%s), delivery %d: %v (retry after %s)", ce.ID, ce.Type, delivery, err, b.cfg.ClaimMinIdle)
+		return
+	}
+	span.SetStatus(codes.Ok, "processed")
+
+	if err := b.client.XAck(ctx, stream, group, msg.ID).Err(); err != nil {
+		// Pesan akan dikirim ulang, middleware Dedup melewatinya
+		log.Printf("❌ Failed to ack message %s of '%s': %v", msg.ID, stream, err)
+		return
+	}
+	log.Printf("✅ Message processed from stream '%s': %s %s", stream, ce.Type, ce.ID)
+}
+
+// deadLetter menyalin entry ke {stream}.dlq beserta alasannya lalu meng-ack entry aslinya
+func (b *RedisStreamBus) deadLetter(ctx context.Context, stream, group string, msg redis.XMessage, cause error) {
+	values := make(map[string]interface{}, len(msg.Values)+1)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values[streamFieldError] = cause.Error()
+
+	dlq := stream + ".dlq"
+	if err := b.client.XAdd(ctx, &redis.XAddArgs{Stream: dlq, Values: values}).Err(); err != nil {
+		// Tanpa ack pesan tetap pending dan dicoba dipindah lagi pada putaran claim berikutnya
+		log.Printf("❌ Failed to move message %s of '%s' to %s: %v", msg.ID, stream, dlq, err)
+		return
+	}
+	if err := b.client.XAck(ctx, stream, group, msg.ID).Err(); err != nil {
+		log.Printf("❌ Failed to ack message %s of '%s': %v", msg.ID, stream, err)
+		return
+	}
+	log.Printf("↪️ Message %s of '%s' moved to %s", msg.ID, stream, dlq)
+}
+
+// startProcessSpan membuat span consumer untuk satu entry stream
+func (b *RedisStreamBus) startProcessSpan(ctx context.Context, stream, group string, msg redis.XMessage, delivery int64, ce CloudEvent) (context.Context, trace.Span) {
+	ctx = ce.TraceContext(ctx)
+	key, _ := msg.Values[streamFieldKey].(string)
+
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.destination.name", stream),
+			attribute.String("messaging.consumer.group.name", group),
+			attribute.String("messaging.message.id", msg.ID),
+			attribute.String("messaging.redis.message.key", key),
+			attribute.Int64("messaging.redis.delivery_count", delivery),
+			attribute.String("cloudevents.event_id", ce.ID),
+			attribute.String("cloudevents.event_type", ce.Type),
+		),
+	}
+	if remote := trace.SpanContextFromContext(ctx); remote.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: remote}))
+	}
+	return b.tracer.Start(ctx, "process "+stream, opts...)
+}
+
+// Close memenuhi interface Bus; client Redis ditutup oleh pemiliknya
+func (b *RedisStreamBus) Close() error {
+	return nil
+}
+
+// consumerName membuat nama consumer unik: hostname-pid-acak
+func consumerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "consumer"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+}
+
+// sleepCtx menunggu d atau sampai ctx selesai
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseStreamConsumers(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply interface{}
+		want  []streamConsumer
+	}{
+		{
+			name: "redis 7.0 reply",
+			reply: []interface{}{
+				[]interface{}{"name", "host-1-a", "pending", int64(2), "idle", int64(1500)},
+			},
+			want: []streamConsumer{{name: "host-1-a", pending: 2, idle: 1500 * time.Millisecond}},
+		},
+		{
+			name: "redis 7.2 reply with inactive",
+			reply: []interface{}{
+				[]interface{}{"name", "host-1-a", "pending", int64(0), "idle", int64(7200000), "inactive", int64(7200000)},
+				[]interface{}{"name", "host-2-b", "pending", int64(1), "idle", int64(10), "inactive", int64(-1)},
+			},
+			want: []streamConsumer{
+				{name: "host-1-a", pending: 0, idle: 2 * time.Hour},
+				{name: "host-2-b", pending: 1, idle: 10 * time.Millisecond},
+			},
+		},
+		{
+			name:  "empty group",
+			reply: []interface{}{},
+			want:  []streamConsumer{},
+		},
+		{
+			name: "rows without a name are skipped",
+			reply: []interface{}{
+				[]interface{}{"pending", int64(0), "idle", int64(1)},
+				"garbage",
+			},
+			want: []streamConsumer{},
+		},
+		{
+			name:  "unexpected reply",
+			reply: "OK",
+			want:  []streamConsumer{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseStreamConsumers(tt.reply); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStreamConsumers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
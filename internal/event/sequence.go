@@ -0,0 +1,65 @@
+package event
+
+import (
+	"context"
+	"strings"
+)
+
+// Sequencer memberi nomor urut yang terus naik per agregat (misalnya satu user).
+// Implementasi PostgreSQL ikut transaksi di ctx sehingga urutan tidak berlubang saat rollback.
+type Sequencer interface {
+	Next(ctx context.Context, aggregateType, aggregateID string) (int64, error)
+}
+
+// WithSequencer mengembalikan salinan Envelope yang mengisi ekstensi sequence setiap event baru
+func (e Envelope) WithSequencer(s Sequencer) Envelope {
+	e.sequencer = s
+	return e
+}
+
+// AggregateType mengambil jenis agregat dari tipe event, "user.created" menjadi "user"
+func AggregateType(eventType string) string {
+	if i := strings.IndexByte(eventType, '.'); i > 0 {
+		return eventType[:i]
+	}
+	return eventType
+}
+
+// MatchType melaporkan apakah eventType cocok dengan salah satu pola: tipe persis ("user.created"),
+// semua tipe satu agregat ("user.*") atau semua event ("*"). Pola kosong cocok dengan semua event.
+func MatchType(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if p == eventType || p == "*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, ".*"); ok && strings.HasPrefix(eventType, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// MessageKey adalah key pesan Kafka untuk ce: id agregat (subject) agar semua event satu entitas
+// masuk ke partition yang sama dan tetap berurutan. Event tanpa subject memakai tipenya.
+func MessageKey(ce CloudEvent) string {
+	if ce.Subject != "" {
+		return ce.Subject
+	}
+	return ce.Type
+}
+
+// assignSequence mengisi ce.Sequence dari sequencer envelope jika event punya subject
+func (e Envelope) assignSequence(ctx context.Context, ce *CloudEvent) error {
+	if e.sequencer == nil || ce.Subject == "" {
+		return nil
+	}
+	seq, err := e.sequencer.Next(ctx, AggregateType(ce.Type), ce.Subject)
+	if err != nil {
+		return err
+	}
+	ce.Sequence = seq
+	return nil
+}
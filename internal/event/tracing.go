@@ -0,0 +1,80 @@
+package event
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderCarrier adalah propagation.TextMapCarrier di atas header pesan Kafka,
+// dipakai untuk menulis dan membaca header W3C traceparent/tracestate/baggage
+type HeaderCarrier struct {
+	Headers *[]kafka.Header
+}
+
+// Get mengembalikan nilai header key, string kosong jika tidak ada
+func (c HeaderCarrier) Get(key string) string {
+	for _, h := range *c.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set mengganti header key jika sudah ada, atau menambahkannya
+func (c HeaderCarrier) Set(key, value string) {
+	for i, h := range *c.Headers {
+		if h.Key == key {
+			(*c.Headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.Headers = append(*c.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys mengembalikan semua key header
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.Headers))
+	for _, h := range *c.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// InjectTraceContext menulis trace context dan baggage dari ctx ke header msg
+// memakai propagator global (lihat otel.SetTextMapPropagator)
+func InjectTraceContext(ctx context.Context, msg *kafka.Message) {
+	otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier{Headers: &msg.Headers})
+}
+
+// ExtractTraceContext membaca trace context dan baggage dari header msg ke ctx
+func ExtractTraceContext(ctx context.Context, msg kafka.Message) context.Context {
+	headers := msg.Headers
+	return otel.GetTextMapPropagator().Extract(ctx, HeaderCarrier{Headers: &headers})
+}
+
+// traceContextOf mengambil traceparent/tracestate (ekstensi CloudEvents distributed tracing) dari span aktif di ctx
+func traceContextOf(ctx context.Context) (string, string) {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent"), carrier.Get("tracestate")
+}
+
+// TraceContext mengembalikan ctx dengan span context remote dari ekstensi traceparent event.
+// Dipakai jika event dikirim di luar request asalnya (misalnya oleh relay outbox).
+func (e CloudEvent) TraceContext(ctx context.Context) context.Context {
+	if e.TraceParent == "" {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{"traceparent": e.TraceParent, "tracestate": e.TraceState}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// hasSpan melaporkan apakah ctx membawa span yang valid
+func hasSpan(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
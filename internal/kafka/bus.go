@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"context"
+
+	"golang-crud-clean-arch/internal/event"
+)
+
+// Bus menggabungkan KafkaPublisher dan KafkaConsumer menjadi event.Bus, sehingga Kafka bisa
+// dipakai di tempat yang sama dengan backend lain (mis. conformance suite). Consume hanya membaca
+// topic utama; retry topic dan DLQ tetap dijalankan terpisah (lihat startConsumers di cmd).
+type Bus struct {
+	*event.KafkaPublisher
+	brokers   []string
+	retry     RetryPolicy
+	forwarder *Forwarder
+}
+
+// NewBus membuat Bus Kafka. Forwarder boleh nil: event yang tetap gagal hanya dicatat di log.
+func NewBus(brokers []string, publisher *event.KafkaPublisher, retry RetryPolicy, forwarder *Forwarder) *Bus {
+	return &Bus{
+		KafkaPublisher: publisher,
+		brokers:        brokers,
+		retry:          retry,
+		forwarder:      forwarder,
+	}
+}
+
+// Consume menjalankan KafkaConsumer untuk topic dalam group sampai ctx selesai
+func (b *Bus) Consume(ctx context.Context, topic, group string, handler event.Subscriber) error {
+	consumer := &KafkaConsumer{
+		Brokers:   b.brokers,
+		Topic:     topic,
+		GroupID:   group,
+		Handler:   HandlerFunc(handler),
+		Retry:     b.retry,
+		Forwarder: b.forwarder,
+	}
+	return consumer.Start(ctx)
+}
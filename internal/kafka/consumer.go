@@ -2,22 +2,50 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
 
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
+// KafkaConsumer membaca pesan dari satu topic. Pesan di-decode sebagai CloudEvent
+// (binary maupun structured mode) lalu diteruskan ke Handler, biasanya Router.Dispatch.
+// Event yang tetap gagal setelah Retry.MaxAttempts dipindah ke retry topic berikutnya
+// atau ke DLQ lewat Forwarder. Topic bisa berupa retry topic ({topic}.retry.N), dalam hal
+// ini pesan baru diproses setelah waktu di header x-retry-at.
+//
+// FailureTopic mengganti basis nama retry topic dan DLQ untuk pesan dari Topic. Consumer group
+// yang membaca topic yang sama memakai basis sendiri ({topic}.{group}, lihat GroupFailureTopic)
+// agar event yang gagal di satu group tidak diambil oleh retry consumer group lain.
+//
+// Offset di-commit setelah pesan selesai diproses (berhasil, atau sudah dipindah ke retry
+// topic/DLQ), sehingga pesan yang sedang diproses saat crash akan dikirim ulang (at-least-once).
+// Gunakan middleware Dedup agar handler aman menerima event yang sama lebih dari sekali.
 type KafkaConsumer struct {
-	Brokers []string
-	Topic   string
-	GroupID string
-	Handler func(message kafka.Message)
+	Brokers   []string
+	Topic     string
+	GroupID   string
+	Handler   HandlerFunc
+	Retry     RetryPolicy
+	Forwarder *Forwarder // nil: event yang gagal hanya dicatat di log
+
+	FailureTopic string // basis retry topic dan DLQ, kosong berarti Topic
 }
 
 func (kc *KafkaConsumer) Start(ctx context.Context) error {
+	if kc.Handler == nil {
+		return errors.New("kafka consumer: handler is required")
+	}
+
 	// Create Kafka reader
 	r := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:        kc.Brokers,
@@ -36,20 +64,148 @@ func (kc *KafkaConsumer) Start(ctx context.Context) error {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Start consumer loop
+	readFailures := 0
 	for {
 		select {
 		case <-sigChan:
 			log.Println("Graceful shutdown initiated")
 			return nil
 		default:
-			m, err := r.ReadMessage(ctx)
+			m, err := r.FetchMessage(ctx)
 			if err != nil {
-				log.Printf("❌ Error reading message from topic '%s': %v", kc.Topic, err)
+				if ctx.Err() != nil {
+					return nil
+				}
+				// Error baca (broker tidak tersedia, rebalance, ...) tidak menghentikan consumer
+				readFailures++
+				wait := kc.Retry.backoff(readFailures)
+				if wait < time.Second {
+					wait = time.Second
+				}
+				log.Printf("❌ Error reading message from topic '%s': %v (retry in %s)", kc.Topic, err, wait)
+				if err := sleep(ctx, wait); err != nil {
+					return nil
+				}
+				continue
+			}
+			readFailures = 0
+
+			if err := kc.process(ctx, m); err != nil {
+				// Offset tidak di-commit: pesan dikirim ulang setelah restart/rebalance
+				log.Printf("⚠️ Message %s[%d]@%d not committed: %v", kc.Topic, m.Partition, m.Offset, err)
+				return nil
+			}
+			if err := r.CommitMessages(ctx, m); err != nil {
+				log.Printf("❌ Failed to commit offset %s[%d]@%d: %v", kc.Topic, m.Partition, m.Offset, err)
+			}
+		}
+	}
+}
+
+// process menjalankan handler untuk satu pesan beserta retry dan dead-lettering-nya.
+// Error berarti pesan belum selesai diproses dan offset-nya tidak boleh di-commit.
+func (kc *KafkaConsumer) process(ctx context.Context, m kafka.Message) error {
+	source, stage := SourceTopic(kc.Topic)
+	lane := source
+	if stage == 0 && kc.FailureTopic != "" {
+		lane = kc.FailureTopic
+	}
+
+	// Pesan di retry topic menunggu sampai jadwal retry-nya
+	if stage > 0 {
+		if retryAt, err := time.Parse(time.RFC3339Nano, headerValue(m, HeaderRetryAt)); err == nil {
+			if err := sleep(ctx, time.Until(retryAt)); err != nil {
 				return err
 			}
+		}
+	}
+
+	// Span consumer per pesan, melanjutkan trace dari header traceparent publisher
+	ctx, span := startProcessSpan(ctx, kc.GroupID, m)
+	defer span.End()
 
-			kc.Handler(m)
-			log.Printf("✅ Message processed from topic '%s'", kc.Topic)
+	ce, err := event.Decode(m)
+	if err != nil {
+		// Pesan yang tidak bisa di-decode tidak akan berhasil di-retry
+		log.Printf("⚠️ Invalid event at %s[%d]@%d: %v", kc.Topic, m.Partition, m.Offset, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid event")
+		return kc.forward(ctx, m, DLQTopic(lane), failureHeadersFor(m, source, 1, err))
+	}
+	span.SetAttributes(
+		attribute.String("cloudevents.event_id", ce.ID),
+		attribute.String("cloudevents.event_type", ce.Type),
+	)
+
+	attempts, err := kc.handle(ctx, ce)
+	span.SetAttributes(attribute.Int("messaging.kafka.attempts", attempts))
+	if err == nil {
+		log.Printf("✅ Message processed from topic '%s': %s %s", kc.Topic, ce.Type, ce.ID)
+		span.SetStatus(codes.Ok, "processed")
+		return nil
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "handler failed")
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	log.Printf("❌ Failed to handle event %s (%s) after %d attempts: %v", ce.ID, ce.Type, attempts, err)
+
+	headers := failureHeadersFor(m, source, attempts, err)
+	if next := stage + 1; next <= len(kc.Retry.RetryDelays) {
+		retryAt := time.Now().Add(kc.Retry.RetryDelays[next-1])
+		headers = append(headers,
+			kafka.Header{Key: HeaderRetryStage, Value: []byte(strconv.Itoa(next))},
+			kafka.Header{Key: HeaderRetryAt, Value: []byte(retryAt.UTC().Format(time.RFC3339Nano))},
+		)
+		return kc.forward(ctx, m, RetryTopic(lane, next), headers)
+	}
+	return kc.forward(ctx, m, DLQTopic(lane), headers)
+}
+
+// handle memanggil Handler sampai berhasil atau Retry.MaxAttempts habis
+func (kc *KafkaConsumer) handle(ctx context.Context, ce event.CloudEvent) (int, error) {
+	maxAttempts := kc.Retry.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = kc.Handler(ctx, ce); err == nil {
+			return attempt, nil
+		}
+		if attempt >= maxAttempts {
+			return attempt, err
+		}
+		if sleepErr := sleep(ctx, kc.Retry.backoff(attempt)); sleepErr != nil {
+			return attempt, err
+		}
+	}
+}
+
+// forward memindahkan pesan ke retry topic atau DLQ beserta header kegagalannya.
+// Penulisan diulang sampai berhasil karena offset baru di-commit setelah pesan dipindahkan.
+func (kc *KafkaConsumer) forward(ctx context.Context, m kafka.Message, topic string, headers []kafka.Header) error {
+	if kc.Forwarder == nil {
+		log.Printf("⚠️ No forwarder configured, dropping failed message %s[%d]@%d", kc.Topic, m.Partition, m.Offset)
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := kc.Forwarder.Forward(ctx, topic, m, headers...)
+		if err == nil {
+			log.Printf("↪️ Message %s[%d]@%d moved to %s", kc.Topic, m.Partition, m.Offset, topic)
+			return nil
+		}
+		log.Printf("❌ Failed to forward message %s[%d]@%d to %s: %v", kc.Topic, m.Partition, m.Offset, topic, err)
+
+		wait := kc.Retry.backoff(attempt)
+		if wait < time.Second {
+			wait = time.Second
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
 		}
 	}
 }
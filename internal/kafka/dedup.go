@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DedupStore mencatat id event yang sudah berhasil diproses
+type DedupStore interface {
+	Seen(ctx context.Context, eventID string) (bool, error)
+	Mark(ctx context.Context, eventID string) error
+}
+
+// RedisDedupStore menyimpan id event di Redis sebagai key "dedup:{group}:{id}" dengan TTL.
+// TTL harus lebih lama dari jarak maksimum pengiriman ulang (retry topic, rebalance, re-drive).
+type RedisDedupStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisDedupStore membuat store deduplikasi untuk satu consumer group
+func NewRedisDedupStore(client *redis.Client, group string, ttl time.Duration) *RedisDedupStore {
+	return &RedisDedupStore{
+		client: client,
+		prefix: "dedup:" + group + ":",
+		ttl:    ttl,
+	}
+}
+
+// Seen melaporkan apakah event sudah pernah diproses
+func (s *RedisDedupStore) Seen(ctx context.Context, eventID string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.prefix+eventID).Result()
+	return n > 0, err
+}
+
+// Mark menandai event sebagai sudah diproses
+func (s *RedisDedupStore) Mark(ctx context.Context, eventID string) error {
+	return s.client.Set(ctx, s.prefix+eventID, time.Now().UTC().Format(time.RFC3339), s.ttl).Err()
+}
+
+// Dedup melewati event yang id-nya sudah tercatat di store dan mencatat event yang berhasil diproses.
+// Jika Redis tidak tersedia event tetap diproses (lebih baik duplikat daripada hilang).
+func Dedup(store DedupStore) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, ce event.CloudEvent) error {
+			// Pesan lama tanpa envelope tidak punya id
+			if ce.ID == "" {
+				return next(ctx, ce)
+			}
+
+			seen, err := store.Seen(ctx, ce.ID)
+			if err != nil {
+				log.Printf("⚠️ Dedup lookup failed for event %s: %v", ce.ID, err)
+			} else if seen {
+				log.Printf("♻️ Duplicate event %s (%s) skipped", ce.ID, ce.Type)
+				return nil
+			}
+
+			if err := next(ctx, ce); err != nil {
+				return err
+			}
+
+			if err := store.Mark(ctx, ce.ID); err != nil {
+				log.Printf("⚠️ Failed to record processed event %s: %v", ce.ID, err)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// testRedis membaca REDIS_ADDR seperti conformance suite: di CI Redis wajib ada, di lokal test di-skip
+func testRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("REDIS_ADDR is not set; CI must start Redis for the kafka store tests")
+		}
+		t.Skip("REDIS_ADDR is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// memoryDedupStore adalah DedupStore di memori untuk menguji middleware Dedup
+type memoryDedupStore struct {
+	seen    map[string]bool
+	seenErr error
+	markErr error
+}
+
+func (s *memoryDedupStore) Seen(_ context.Context, eventID string) (bool, error) {
+	return s.seen[eventID], s.seenErr
+}
+
+func (s *memoryDedupStore) Mark(_ context.Context, eventID string) error {
+	if s.markErr != nil {
+		return s.markErr
+	}
+	s.seen[eventID] = true
+	return nil
+}
+
+func TestDedup(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name       string
+		eventID    string
+		seen       []string
+		seenErr    error
+		markErr    error
+		handlerErr error
+		wantCalls  int
+		wantErr    error
+		wantMarked bool
+	}{
+		{name: "new event is processed and marked", eventID: "e1", wantCalls: 1, wantMarked: true},
+		{name: "seen event is skipped", eventID: "e1", seen: []string{"e1"}, wantCalls: 0, wantMarked: true},
+		{name: "failed event is not marked", eventID: "e1", handlerErr: errHandler, wantCalls: 1, wantErr: errHandler},
+		{name: "lookup failure still processes", eventID: "e1", seenErr: errors.New("redis down"), wantCalls: 1, wantMarked: true},
+		{name: "mark failure does not fail the event", eventID: "e1", markErr: errors.New("redis down"), wantCalls: 1},
+		{name: "event without id is passed through", eventID: "", wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryDedupStore{seen: map[string]bool{}, seenErr: tt.seenErr, markErr: tt.markErr}
+			for _, id := range tt.seen {
+				store.seen[id] = true
+			}
+
+			calls := 0
+			handler := Dedup(store)(func(context.Context, event.CloudEvent) error {
+				calls++
+				return tt.handlerErr
+			})
+
+			err := handler(context.Background(), event.CloudEvent{ID: tt.eventID, Type: "user.created"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			if store.seen[tt.eventID] != tt.wantMarked {
+				t.Fatalf("marked = %t, want %t", store.seen[tt.eventID], tt.wantMarked)
+			}
+		})
+	}
+}
+
+func TestRedisDedupStore(t *testing.T) {
+	client := testRedis(t)
+	ctx := context.Background()
+	group := fmt.Sprintf("dedup-test-%d", time.Now().UnixNano())
+
+	store := NewRedisDedupStore(client, group, time.Minute)
+	other := NewRedisDedupStore(client, group+"-other", time.Minute)
+	t.Cleanup(func() { client.Del(ctx, "dedup:"+group+":e1", "dedup:"+group+"-other:e1") })
+
+	tests := []struct {
+		name  string
+		store *RedisDedupStore
+		mark  bool
+		want  bool
+	}{
+		{name: "unknown event", store: store, want: false},
+		{name: "marked event", store: store, mark: true, want: true},
+		{name: "other group has its own keys", store: other, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mark {
+				if err := tt.store.Mark(ctx, "e1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := tt.store.Seen(ctx, "e1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Seen = %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	ttl, err := client.TTL(ctx, "dedup:"+group+":e1").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("TTL = %v, want the store ttl", ttl)
+	}
+}
@@ -0,0 +1,241 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	// ErrNotDeadLetterTopic dikembalikan jika topic yang diminta bukan dead-letter topic (*.dlq)
+	ErrNotDeadLetterTopic = errors.New("not a dead-letter topic")
+
+	// ErrDeadLetterNotFound dikembalikan jika tidak ada pesan pada partition/offset tersebut
+	ErrDeadLetterNotFound = errors.New("dead-letter message not found")
+)
+
+// dlqReadTimeout membatasi waktu membaca satu partition DLQ
+const dlqReadTimeout = 5 * time.Second
+
+// DeadLetter adalah satu pesan di dead-letter topic beserta alasan kegagalannya
+type DeadLetter struct {
+	Topic         string            `json:"topic"`
+	Partition     int               `json:"partition"`
+	Offset        int64             `json:"offset"`
+	Key           string            `json:"key"`
+	EventID       string            `json:"event_id,omitempty"`
+	EventType     string            `json:"event_type,omitempty"`
+	OriginalTopic string            `json:"original_topic"`
+	FailureReason string            `json:"failure_reason"`
+	FailureCount  int               `json:"failure_count"`
+	FailedAt      *time.Time        `json:"failed_at,omitempty"`
+	Headers       map[string]string `json:"headers"`
+	Value         json.RawMessage   `json:"value"`
+}
+
+// MessageRef menunjuk satu pesan di dead-letter topic
+type MessageRef struct {
+	Partition int   `json:"partition"`
+	Offset    int64 `json:"offset"`
+}
+
+// RedriveResult adalah hasil re-drive satu pesan
+type RedriveResult struct {
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Target    string `json:"target,omitempty"`
+	Status    string `json:"status"` // "redriven" atau "failed"
+	Error     string `json:"error,omitempty"`
+}
+
+// DeadLetterQueue membaca dead-letter topic dan mengirim ulang pesannya ke topic asal
+type DeadLetterQueue struct {
+	brokers   []string
+	forwarder *Forwarder
+}
+
+// NewDeadLetterQueue membuat instance baru dari DeadLetterQueue
+func NewDeadLetterQueue(brokers []string, forwarder *Forwarder) *DeadLetterQueue {
+	return &DeadLetterQueue{brokers: brokers, forwarder: forwarder}
+}
+
+// List mengembalikan maksimal limit pesan terakhir di setiap partition dead-letter topic
+func (q *DeadLetterQueue) List(ctx context.Context, topic string, limit int) ([]DeadLetter, error) {
+	if !strings.HasSuffix(topic, ".dlq") {
+		return nil, fmt.Errorf("%w: %s", ErrNotDeadLetterTopic, topic)
+	}
+
+	partitions, err := q.partitions(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	letters := []DeadLetter{}
+	for _, partition := range partitions {
+		first, last, err := q.offsets(ctx, topic, partition)
+		if err != nil {
+			return nil, err
+		}
+		if start := last - int64(limit); start > first {
+			first = start
+		}
+		if first >= last {
+			continue
+		}
+
+		msgs, err := q.read(ctx, topic, partition, first, last)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			letters = append(letters, toDeadLetter(m))
+		}
+	}
+	return letters, nil
+}
+
+// Redrive mengirim ulang pesan ke basis dead-letter topic: topic sumber untuk {topic}.dlq, atau
+// topic milik consumer group ({topic}.{group}) untuk DLQ group sehingga hanya group itu yang
+// memprosesnya lagi.
+// Pesan di DLQ tidak dihapus; hasil per pesan dikembalikan sesuai urutan refs.
+func (q *DeadLetterQueue) Redrive(ctx context.Context, topic string, refs []MessageRef) ([]RedriveResult, error) {
+	if !strings.HasSuffix(topic, ".dlq") {
+		return nil, fmt.Errorf("%w: %s", ErrNotDeadLetterTopic, topic)
+	}
+
+	results := make([]RedriveResult, len(refs))
+	for i, ref := range refs {
+		result := RedriveResult{Partition: ref.Partition, Offset: ref.Offset, Status: "redriven"}
+		target, err := q.redriveOne(ctx, topic, ref)
+		result.Target = target
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+func (q *DeadLetterQueue) redriveOne(ctx context.Context, topic string, ref MessageRef) (string, error) {
+	msgs, err := q.read(ctx, topic, ref.Partition, ref.Offset, ref.Offset+1)
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 || msgs[0].Offset != ref.Offset {
+		return "", ErrDeadLetterNotFound
+	}
+
+	m := msgs[0]
+	target := strings.TrimSuffix(topic, ".dlq")
+
+	from := fmt.Sprintf("%s/%d/%d", topic, ref.Partition, ref.Offset)
+	err = q.forwarder.Forward(ctx, target, m, kafka.Header{Key: HeaderRedrivenFrom, Value: []byte(from)})
+	return target, err
+}
+
+// partitions mengembalikan id partition dari topic
+func (q *DeadLetterQueue) partitions(ctx context.Context, topic string) ([]int, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", q.brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(partitions))
+	for _, p := range partitions {
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
+
+// offsets mengembalikan offset pertama dan offset setelah pesan terakhir di partition
+func (q *DeadLetterQueue) offsets(ctx context.Context, topic string, partition int) (int64, int64, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", q.brokers[0], topic, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+	return conn.ReadOffsets()
+}
+
+// read membaca pesan pada rentang offset [from, to) dari satu partition
+func (q *DeadLetterQueue) read(ctx context.Context, topic string, partition int, from, to int64) ([]kafka.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, dlqReadTimeout)
+	defer cancel()
+
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   q.brokers,
+		Topic:     topic,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+	})
+	defer r.Close()
+
+	if err := r.SetOffset(from); err != nil {
+		return nil, err
+	}
+
+	var msgs []kafka.Message
+	for offset := from; offset < to; {
+		m, err := r.ReadMessage(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			break // offset sudah terhapus oleh retention atau partition kosong
+		}
+		if err != nil {
+			return nil, err
+		}
+		if m.Offset >= to {
+			break
+		}
+		msgs = append(msgs, m)
+		offset = m.Offset + 1
+	}
+	return msgs, nil
+}
+
+// toDeadLetter menerjemahkan pesan DLQ ke DeadLetter
+func toDeadLetter(m kafka.Message) DeadLetter {
+	letter := DeadLetter{
+		Topic:         m.Topic,
+		Partition:     m.Partition,
+		Offset:        m.Offset,
+		Key:           string(m.Key),
+		OriginalTopic: headerValue(m, HeaderOriginalTopic),
+		FailureReason: headerValue(m, HeaderFailureReason),
+		Headers:       map[string]string{},
+	}
+	letter.FailureCount, _ = strconv.Atoi(headerValue(m, HeaderFailureCount))
+	if t, err := time.Parse(time.RFC3339Nano, headerValue(m, HeaderFailedAt)); err == nil {
+		letter.FailedAt = &t
+	}
+	for _, h := range m.Headers {
+		letter.Headers[h.Key] = string(h.Value)
+	}
+
+	if ce, err := event.Decode(m); err == nil {
+		letter.EventID = ce.ID
+		letter.EventType = ce.Type
+	}
+
+	// Value yang bukan JSON dikirim sebagai string JSON
+	if json.Valid(m.Value) {
+		letter.Value = m.Value
+	} else {
+		letter.Value, _ = json.Marshal(string(m.Value))
+	}
+	return letter
+}
@@ -1,14 +1,11 @@
 package kafka
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
 	"fmt"
+	"golang-crud-clean-arch/config"
 	"golang-crud-clean-arch/internal/event"
 	"log"
-
-	"github.com/segmentio/kafka-go"
 )
 
 // KafkaEventPublisher adalah instance global untuk publisher Kafka.
@@ -17,7 +14,7 @@ var kafkaEventPublisher event.EventPublisher
 // InitKafkaPublisher menginisialisasi Kafka publisher dengan broker dan topik default.
 // Fungsi ini bisa dipanggil saat aplikasi start (misalnya di main.go).
 func InitKafkaPublisher(brokers []string, topic string) {
-	kafkaEventPublisher = event.NewKafkaPublisher(brokers, topic)
+	kafkaEventPublisher = event.NewKafkaPublisher(brokers, topic, event.NewEnvelope(config.LoadEventConfig()), config.LoadProducerConfig())
 }
 
 // GetKafkaPublisher mengembalikan instance global dari Kafka publisher.
@@ -26,48 +23,14 @@ func GetKafkaPublisher() event.EventPublisher {
 	return kafkaEventPublisher
 }
 
-// Compress menggunakan GZIP untuk mengompresi data.
-func Compress(data []byte) ([]byte, error) {
-	var buf bytes.Buffer
-	gzipWriter := gzip.NewWriter(&buf)
-	_, err := gzipWriter.Write(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compress data: %w", err)
-	}
-	if err := gzipWriter.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close gzip writer: %w", err)
-	}
-	return buf.Bytes(), nil
-}
-
-// PublishEvent mengirimkan event yang sudah terkompresi ke Kafka menggunakan kafka-go.
+// PublishEvent mengirimkan satu event ke Kafka lewat KafkaPublisher, sehingga pesannya sama dengan
+// jalur publish lain (CloudEvents, key id agregat, kompresi sesuai KAFKA_PRODUCER_COMPRESSION).
 func PublishEvent(topic string, eventType string, data interface{}, brokers []string) error {
-	// Convert event data menjadi byte slice
-	eventData, err := event.MarshalData(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event data: %w", err)
-	}
-
-	// Kompresi data menggunakan GZIP
-	compressedData, err := Compress(eventData)
-	if err != nil {
-		return fmt.Errorf("failed to compress event data: %w", err)
-	}
-
-	// Membuat koneksi Kafka menggunakan kafka-go
-	writer := kafka.NewWriter(kafka.WriterConfig{
-		Brokers: brokers,
-		Topic:   topic,
-	})
+	publisher := event.NewKafkaPublisher(brokers, topic, event.NewEnvelope(config.LoadEventConfig()), config.LoadProducerConfig())
+	defer publisher.Close()
 
 	// Kirim pesan ke Kafka
-	err = writer.WriteMessages(
-		context.Background(),
-		kafka.Message{
-			Value: compressedData, // Mengirimkan data terkompresi
-		},
-	)
-	if err != nil {
+	if err := publisher.Publish(context.Background(), topic, eventType, data); err != nil {
 		return fmt.Errorf("failed to send message to Kafka: %w", err)
 	}
 
@@ -0,0 +1,207 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// replayReadTimeout membatasi waktu menunggu pesan berikutnya saat replay
+const replayReadTimeout = 10 * time.Second
+
+// ReplayOptions menentukan pesan yang dibaca ulang dari sebuah topic.
+// Jika FromTime diisi, replay dimulai dari offset pertama dengan timestamp >= FromTime
+// di setiap partition; jika tidak, dimulai dari FromOffset (offset pertama yang tersedia
+// jika FromOffset lebih kecil). Replay berhenti di offset terakhir saat replay dimulai.
+type ReplayOptions struct {
+	Topic      string
+	FromOffset int64
+	FromTime   time.Time
+	Types      []string // kosong: semua tipe event
+	EntityID   string   // dicocokkan dengan atribut subject CloudEvent
+	Apply      bool     // false: dry-run, event hanya dicatat di log
+	Limit      int      // jumlah maksimum event yang cocok, 0 berarti tanpa batas
+}
+
+// ReplayStats adalah ringkasan hasil replay
+type ReplayStats struct {
+	Scanned int `json:"scanned"`
+	Invalid int `json:"invalid"`
+	Matched int `json:"matched"`
+	Applied int `json:"applied"`
+	Failed  int `json:"failed"`
+}
+
+// Replayer membaca ulang histori topic tanpa consumer group (offset group tidak berubah)
+// dan meneruskan event yang cocok ke handler yang sama dengan KafkaConsumer
+type Replayer struct {
+	brokers  []string
+	consumer *KafkaConsumer
+}
+
+// NewReplayer membuat Replayer; handler dipanggil dengan retry in-process sesuai policy,
+// event yang tetap gagal hanya dihitung dan dicatat di log (tidak dipindah ke retry topic/DLQ)
+func NewReplayer(brokers []string, handler HandlerFunc, retry RetryPolicy) *Replayer {
+	return &Replayer{
+		brokers:  brokers,
+		consumer: &KafkaConsumer{Brokers: brokers, Handler: handler, Retry: retry},
+	}
+}
+
+// Replay membaca semua partition topic sesuai opts
+func (r *Replayer) Replay(ctx context.Context, opts ReplayOptions) (ReplayStats, error) {
+	var stats ReplayStats
+	if opts.Apply && r.consumer.Handler == nil {
+		return stats, errors.New("kafka replay: handler is required in apply mode")
+	}
+
+	partitions, err := r.partitions(ctx, opts.Topic)
+	if err != nil {
+		return stats, err
+	}
+
+	types := map[string]bool{}
+	for _, t := range opts.Types {
+		types[t] = true
+	}
+
+	for _, partition := range partitions {
+		from, to, err := r.bounds(ctx, opts, partition)
+		if err != nil {
+			return stats, err
+		}
+		if from >= to {
+			continue
+		}
+		log.Printf("⏪ Replaying %s[%d] offsets %d..%d", opts.Topic, partition, from, to-1)
+
+		done, err := r.replayPartition(ctx, opts, partition, from, to, types, &stats)
+		if err != nil {
+			return stats, err
+		}
+		if done {
+			break
+		}
+	}
+	return stats, nil
+}
+
+// replayPartition memproses pesan pada rentang offset [from, to) dan melaporkan apakah Limit sudah tercapai
+func (r *Replayer) replayPartition(ctx context.Context, opts ReplayOptions, partition int, from, to int64, types map[string]bool, stats *ReplayStats) (bool, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     opts.Topic,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(from); err != nil {
+		return false, err
+	}
+
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, replayReadTimeout)
+		m, err := reader.ReadMessage(readCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				// Sisa offset sudah terhapus oleh retention/compaction
+				return false, nil
+			}
+			return false, err
+		}
+		if m.Offset >= to {
+			return false, nil
+		}
+		stats.Scanned++
+
+		ce, err := event.Decode(m)
+		if err != nil {
+			stats.Invalid++
+			log.Printf("⚠️ Invalid event at %s[%d]@%d: %v", opts.Topic, m.Partition, m.Offset, err)
+		} else if (len(types) == 0 || types[ce.Type]) && (opts.EntityID == "" || ce.Subject == opts.EntityID) {
+			stats.Matched++
+			r.replayEvent(ctx, opts, m, ce, stats)
+			if opts.Limit > 0 && stats.Matched >= opts.Limit {
+				return true, nil
+			}
+		}
+
+		if m.Offset >= to-1 {
+			return false, nil
+		}
+	}
+}
+
+// replayEvent mencatat event (dry-run) atau meneruskannya ke handler (apply)
+func (r *Replayer) replayEvent(ctx context.Context, opts ReplayOptions, m kafka.Message, ce event.CloudEvent, stats *ReplayStats) {
+	ref := fmt.Sprintf("%s[%d]@%d", opts.Topic, m.Partition, m.Offset)
+	if !opts.Apply {
+		log.Printf("🔍 [dry-run] %s %s %s subject=%s time=%s", ref, ce.Type, ce.ID, ce.Subject, ce.Time.Format(time.RFC3339))
+		return
+	}
+
+	attempts, err := r.consumer.handle(ctx, ce)
+	if err != nil {
+		stats.Failed++
+		log.Printf("❌ Replay of %s (%s %s) failed after %d attempts: %v", ref, ce.Type, ce.ID, attempts, err)
+		return
+	}
+	stats.Applied++
+	log.Printf("✅ Replayed %s %s %s", ref, ce.Type, ce.ID)
+}
+
+// partitions mengembalikan id partition dari topic
+func (r *Replayer) partitions(ctx context.Context, topic string) ([]int, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", r.brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(partitions))
+	for _, p := range partitions {
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
+
+// bounds mengembalikan offset awal replay dan offset setelah pesan terakhir di partition
+func (r *Replayer) bounds(ctx context.Context, opts ReplayOptions, partition int) (int64, int64, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", r.brokers[0], opts.Topic, partition)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	from := opts.FromOffset
+	if !opts.FromTime.IsZero() {
+		if from, err = conn.ReadOffset(opts.FromTime); err != nil {
+			return 0, 0, err
+		}
+		if from < 0 {
+			from = last // tidak ada pesan setelah FromTime
+		}
+	}
+	if from < first {
+		from = first
+	}
+	return from, last, nil
+}
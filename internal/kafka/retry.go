@@ -0,0 +1,186 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-crud-clean-arch/config"
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Header yang ditambahkan ke pesan yang dipindah ke retry topic atau DLQ
+const (
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderFailureReason     = "x-failure-reason"
+	HeaderFailureCount      = "x-failure-count"
+	HeaderFailedAt          = "x-failed-at"
+	HeaderRetryStage        = "x-retry-stage"
+	HeaderRetryAt           = "x-retry-at"
+	HeaderRedrivenFrom      = "x-redriven-from"
+)
+
+// failureHeaders dihapus saat pesan diteruskan ulang agar tidak terduplikasi
+var failureHeaders = map[string]bool{
+	HeaderOriginalTopic:     true,
+	HeaderOriginalPartition: true,
+	HeaderOriginalOffset:    true,
+	HeaderFailureReason:     true,
+	HeaderFailureCount:      true,
+	HeaderFailedAt:          true,
+	HeaderRetryStage:        true,
+	HeaderRetryAt:           true,
+	HeaderRedrivenFrom:      true,
+}
+
+// RetryPolicy mengatur retry event yang gagal diproses:
+// MaxAttempts kali di dalam proses dengan backoff eksponensial, lalu satu kali di setiap
+// retry topic sesuai RetryDelays, dan terakhir dipindah ke dead-letter topic.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	RetryDelays    []time.Duration
+}
+
+// NewRetryPolicy membuat RetryPolicy dari konfigurasi CONSUMER_*
+func NewRetryPolicy(cfg config.ConsumerConfig) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		RetryDelays:    cfg.RetryDelays,
+	}
+}
+
+// backoff menghitung jeda setelah percobaan ke-attempt (mulai dari 1)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// RetryTopic mengembalikan nama retry topic ke-stage untuk topic sumber, mis. user-events.retry.1
+func RetryTopic(topic string, stage int) string {
+	return fmt.Sprintf("%s.retry.%d", topic, stage)
+}
+
+// DLQTopic mengembalikan nama dead-letter topic untuk topic sumber, mis. user-events.dlq
+func DLQTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// GroupFailureTopic mengembalikan basis retry topic dan DLQ milik consumer group untuk topic sumber,
+// mis. user-events.sync-group sehingga retry topic-nya user-events.sync-group.retry.1 dan DLQ-nya
+// user-events.sync-group.dlq. Header x-original-topic tetap berisi topic sumber.
+func GroupFailureTopic(topic, group string) string {
+	return topic + "." + group
+}
+
+// SourceTopic mengurai nama retry topic menjadi topic sumber dan stage-nya.
+// Topic biasa mengembalikan dirinya sendiri dengan stage 0.
+func SourceTopic(topic string) (string, int) {
+	i := strings.LastIndex(topic, ".retry.")
+	if i < 0 {
+		return topic, 0
+	}
+	stage, err := strconv.Atoi(topic[i+len(".retry."):])
+	if err != nil || stage <= 0 {
+		return topic, 0
+	}
+	return topic[:i], stage
+}
+
+// Forwarder menulis ulang pesan ke topic lain (retry topic, DLQ, atau topic asal saat re-drive)
+type Forwarder struct {
+	writer *kafka.Writer
+}
+
+// NewForwarder membuat Forwarder; topic tujuan ditentukan per pesan.
+// Writer sinkron (lihat NewWriter) karena offset baru di-commit setelah pesan berhasil dipindahkan.
+func NewForwarder(brokers []string, producer config.ProducerConfig) *Forwarder {
+	writer := event.NewWriter(brokers, "", producer)
+	writer.AllowAutoTopicCreation = true
+	return &Forwarder{writer: writer}
+}
+
+// Forward mengirim key, value dan header pesan ke topic; header kegagalan lama diganti dengan extra
+func (f *Forwarder) Forward(ctx context.Context, topic string, m kafka.Message, extra ...kafka.Header) error {
+	headers := make([]kafka.Header, 0, len(m.Headers)+len(extra))
+	for _, h := range m.Headers {
+		if !failureHeaders[h.Key] {
+			headers = append(headers, h)
+		}
+	}
+	headers = append(headers, extra...)
+
+	return f.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   topic,
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+	})
+}
+
+// Close menutup writer
+func (f *Forwarder) Close() error {
+	return f.writer.Close()
+}
+
+// failureHeadersFor menyusun header untuk pesan yang gagal diproses. Topic, partition dan offset
+// asal dipertahankan dari header sebelumnya jika pesan sudah pernah melewati retry topic.
+func failureHeadersFor(m kafka.Message, source string, attempts int, cause error) []kafka.Header {
+	originTopic, originPartition, originOffset := source, strconv.Itoa(m.Partition), strconv.FormatInt(m.Offset, 10)
+	if v := headerValue(m, HeaderOriginalTopic); v != "" {
+		originTopic = v
+		originPartition = headerValue(m, HeaderOriginalPartition)
+		originOffset = headerValue(m, HeaderOriginalOffset)
+	}
+	previous, _ := strconv.Atoi(headerValue(m, HeaderFailureCount))
+
+	return []kafka.Header{
+		{Key: HeaderOriginalTopic, Value: []byte(originTopic)},
+		{Key: HeaderOriginalPartition, Value: []byte(originPartition)},
+		{Key: HeaderOriginalOffset, Value: []byte(originOffset)},
+		{Key: HeaderFailureReason, Value: []byte(cause.Error())},
+		{Key: HeaderFailureCount, Value: []byte(strconv.Itoa(previous + attempts))},
+		{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+	}
+}
+
+// headerValue mengembalikan nilai header terakhir dengan key tersebut
+func headerValue(m kafka.Message, key string) string {
+	value := ""
+	for _, h := range m.Headers {
+		if h.Key == key {
+			value = string(h.Value)
+		}
+	}
+	return value
+}
+
+// sleep menunggu d atau sampai ctx dibatalkan
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HandlerFunc memproses satu CloudEvent. Error dikembalikan ke consumer untuk dicatat.
+type HandlerFunc func(ctx context.Context, ce event.CloudEvent) error
+
+// Middleware membungkus HandlerFunc, misalnya untuk logging, tracing atau recovery
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Router meneruskan event ke handler sesuai atribut type (user.created, repo.deleted, ...).
+// Event dengan type yang tidak terdaftar diteruskan ke handler default.
+type Router struct {
+	mu         sync.RWMutex
+	handlers   map[string]HandlerFunc
+	fallback   HandlerFunc
+	middleware []Middleware
+}
+
+// NewRouter membuat Router kosong; handler default hanya mencatat event yang tidak dikenal
+func NewRouter() *Router {
+	return &Router{
+		handlers: map[string]HandlerFunc{},
+		fallback: func(ctx context.Context, ce event.CloudEvent) error {
+			log.Printf("⚠️ No handler for event type %q (id %s), skipped", ce.Type, ce.ID)
+			return nil
+		},
+	}
+}
+
+// Handle mendaftarkan handler untuk satu tipe event, menggantikan handler sebelumnya
+func (r *Router) Handle(eventType string, h HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = h
+}
+
+// Default mengganti handler untuk tipe event yang tidak terdaftar
+func (r *Router) Default(h HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = h
+}
+
+// Use menambahkan middleware; middleware pertama menjadi lapisan terluar
+func (r *Router) Use(mw ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, mw...)
+}
+
+// Dispatch menjalankan handler untuk ce melewati semua middleware.
+// Method ini dipakai sebagai KafkaConsumer.Handler.
+func (r *Router) Dispatch(ctx context.Context, ce event.CloudEvent) error {
+	r.mu.RLock()
+	h, ok := r.handlers[ce.Type]
+	if !ok {
+		h = r.fallback
+	}
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	r.mu.RUnlock()
+
+	return h(ctx, ce)
+}
+
+// Typed membuat HandlerFunc yang men-decode data event ke T sebelum memanggil fn
+func Typed[T any](fn func(ctx context.Context, ce event.CloudEvent, data T) error) HandlerFunc {
+	return func(ctx context.Context, ce event.CloudEvent) error {
+		var data T
+		if err := ce.DataAs(&data); err != nil {
+			return fmt.Errorf("decode %s data: %w", ce.Type, err)
+		}
+		return fn(ctx, ce, data)
+	}
+}
+
+// Logging mencatat durasi dan hasil setiap event
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, ce event.CloudEvent) error {
+			start := time.Now()
+			err := next(ctx, ce)
+			if err != nil {
+				log.Printf("❌ Event %s (%s) failed after %s: %v", ce.Type, ce.ID, time.Since(start), err)
+			} else {
+				log.Printf("📨 Event %s (%s) handled in %s", ce.Type, ce.ID, time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// Tracing membuat span untuk setiap event yang diproses
+func Tracing(tracer trace.Tracer) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, ce event.CloudEvent) error {
+			ctx, span := tracer.Start(ctx, "consume "+ce.Type, trace.WithSpanKind(trace.SpanKindConsumer))
+			defer span.End()
+
+			span.SetAttributes(
+				attribute.String("messaging.system", "kafka"),
+				attribute.String("cloudevents.event_id", ce.ID),
+				attribute.String("cloudevents.event_type", ce.Type),
+				attribute.String("cloudevents.event_source", ce.Source),
+				attribute.String("cloudevents.event_subject", ce.Subject),
+			)
+
+			err := next(ctx, ce)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "Event handling failed")
+			} else {
+				span.SetStatus(codes.Ok, "Event handled")
+			}
+			return err
+		}
+	}
+}
+
+// Recovery mengubah panic di handler menjadi error agar consumer tetap berjalan
+func Recovery() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, ce event.CloudEvent) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("🔥 Panic while handling event %s (%s): %v\n%s", ce.Type, ce.ID, p, debug.Stack())
+					err = fmt.Errorf("panic in handler for %s: %v", ce.Type, p)
+				}
+			}()
+			return next(ctx, ce)
+		}
+	}
+}
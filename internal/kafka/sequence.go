@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SequenceStore mencatat nomor urut terakhir yang sudah diproses per agregat
+type SequenceStore interface {
+	Last(ctx context.Context, aggregateType, aggregateID string) (int64, error)
+	Advance(ctx context.Context, aggregateType, aggregateID string, seq int64) error
+}
+
+// advanceScript hanya menaikkan nilai tersimpan, sehingga consumer paralel tidak memundurkannya
+var advanceScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if tonumber(ARGV[1]) > current then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+end
+return current`)
+
+// RedisSequenceStore menyimpan nomor urut sebagai key "seq:{group}:{type}:{id}" dengan TTL
+type RedisSequenceStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisSequenceStore membuat store nomor urut untuk satu consumer group
+func NewRedisSequenceStore(client *redis.Client, group string, ttl time.Duration) *RedisSequenceStore {
+	return &RedisSequenceStore{
+		client: client,
+		prefix: "seq:" + group + ":",
+		ttl:    ttl,
+	}
+}
+
+// Last mengembalikan nomor urut terakhir yang diproses, 0 jika belum ada
+func (s *RedisSequenceStore) Last(ctx context.Context, aggregateType, aggregateID string) (int64, error) {
+	seq, err := s.client.Get(ctx, s.prefix+aggregateType+":"+aggregateID).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// Advance mencatat seq sebagai nomor urut terakhir jika lebih besar dari yang tersimpan
+func (s *RedisSequenceStore) Advance(ctx context.Context, aggregateType, aggregateID string, seq int64) error {
+	key := s.prefix + aggregateType + ":" + aggregateID
+	return advanceScript.Run(ctx, s.client, []string{key}, strconv.FormatInt(seq, 10), s.ttl.Milliseconds()).Err()
+}
+
+// Sequence mendeteksi event yang datang tidak berurutan berdasarkan ekstensi sequence:
+//   - sequence <= terakhir: event terlambat (sudah ada event lebih baru yang diproses)
+//   - sequence > terakhir+1: ada event yang hilang/tertunda (gap)
+//
+// Keduanya hanya dicatat (log dan span event), event tetap diteruskan ke handler. Event dari
+// retry topic, DLQ yang di-redrive atau replay memang datang setelah event yang lebih baru,
+// dan membuangnya berarti kehilangan event tersebut.
+//
+// Event tanpa sequence atau subject (pesan lama) diteruskan apa adanya.
+// Jika Redis tidak tersedia event tetap diproses tanpa pengecekan.
+func Sequence(store SequenceStore) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, ce event.CloudEvent) error {
+			if ce.Sequence == 0 || ce.Subject == "" {
+				return next(ctx, ce)
+			}
+
+			span := trace.SpanFromContext(ctx)
+			aggregate := event.AggregateType(ce.Type)
+			last, err := store.Last(ctx, aggregate, ce.Subject)
+			switch {
+			case err != nil:
+				log.Printf("⚠️ Sequence lookup failed for %s %s: %v", aggregate, ce.Subject, err)
+			case last > 0 && ce.Sequence <= last:
+				log.Printf("⏮️ Out-of-order event %s (%s) for %s %s: sequence %d, already at %d",
+					ce.ID, ce.Type, aggregate, ce.Subject, ce.Sequence, last)
+				span.AddEvent("out-of-order event", trace.WithAttributes(
+					attribute.Int64("event.sequence", ce.Sequence),
+					attribute.Int64("event.last_sequence", last),
+				))
+			case last > 0 && ce.Sequence > last+1:
+				log.Printf("⚠️ Sequence gap for %s %s: expected %d, got %d (%s %s)",
+					aggregate, ce.Subject, last+1, ce.Sequence, ce.Type, ce.ID)
+				span.AddEvent("sequence gap", trace.WithAttributes(
+					attribute.Int64("event.sequence", ce.Sequence),
+					attribute.Int64("event.expected_sequence", last+1),
+				))
+			}
+
+			if err := next(ctx, ce); err != nil {
+				return err
+			}
+
+			if err := store.Advance(ctx, aggregate, ce.Subject, ce.Sequence); err != nil {
+				log.Printf("⚠️ Failed to record sequence %d for %s %s: %v", ce.Sequence, aggregate, ce.Subject, err)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// memorySequenceStore adalah SequenceStore di memori untuk menguji middleware Sequence
+type memorySequenceStore struct {
+	last    map[string]int64
+	lastErr error
+}
+
+func (s *memorySequenceStore) Last(_ context.Context, aggregateType, aggregateID string) (int64, error) {
+	return s.last[aggregateType+":"+aggregateID], s.lastErr
+}
+
+func (s *memorySequenceStore) Advance(_ context.Context, aggregateType, aggregateID string, seq int64) error {
+	key := aggregateType + ":" + aggregateID
+	if seq > s.last[key] {
+		s.last[key] = seq
+	}
+	return nil
+}
+
+func TestSequence(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name       string
+		last       int64
+		lastErr    error
+		sequence   int64
+		subject    string
+		handlerErr error
+		wantEvent  string
+		wantLast   int64
+		wantErr    error
+	}{
+		{name: "first event", last: 0, sequence: 1, subject: "u1", wantLast: 1},
+		{name: "next in order", last: 4, sequence: 5, subject: "u1", wantLast: 5},
+		{name: "gap", last: 4, sequence: 7, subject: "u1", wantEvent: "sequence gap", wantLast: 7},
+		{name: "duplicate", last: 4, sequence: 4, subject: "u1", wantEvent: "out-of-order event", wantLast: 4},
+		{name: "late event", last: 4, sequence: 2, subject: "u1", wantEvent: "out-of-order event", wantLast: 4},
+		{name: "first event seen mid-stream", last: 0, sequence: 9, subject: "u1", wantLast: 9},
+		{name: "failed handler does not advance", last: 4, sequence: 5, subject: "u1", handlerErr: errHandler, wantLast: 4, wantErr: errHandler},
+		{name: "lookup failure still processes", last: 4, lastErr: errors.New("redis down"), sequence: 9, subject: "u1", wantLast: 9},
+		{name: "event without sequence", last: 4, sequence: 0, subject: "u1", wantLast: 4},
+		{name: "event without subject", last: 4, sequence: 9, subject: "", wantLast: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memorySequenceStore{last: map[string]int64{"user:u1": tt.last}, lastErr: tt.lastErr}
+
+			recorder := tracetest.NewSpanRecorder()
+			ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "consume")
+
+			calls := 0
+			handler := Sequence(store)(func(context.Context, event.CloudEvent) error {
+				calls++
+				return tt.handlerErr
+			})
+
+			err := handler(ctx, event.CloudEvent{ID: "e1", Type: "user.updated", Subject: tt.subject, Sequence: tt.sequence})
+			span.End()
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			// Event terlambat atau dengan gap tetap diteruskan ke handler
+			if calls != 1 {
+				t.Fatalf("handler called %d times, want 1", calls)
+			}
+			if got := store.last["user:u1"]; got != tt.wantLast {
+				t.Fatalf("last sequence = %d, want %d", got, tt.wantLast)
+			}
+
+			var events []string
+			for _, e := range recorder.Ended()[0].Events() {
+				events = append(events, e.Name)
+			}
+			switch {
+			case tt.wantEvent == "" && len(events) > 0:
+				t.Fatalf("span events = %v, want none", events)
+			case tt.wantEvent != "" && (len(events) != 1 || events[0] != tt.wantEvent):
+				t.Fatalf("span events = %v, want [%s]", events, tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestRedisSequenceStore(t *testing.T) {
+	client := testRedis(t)
+	ctx := context.Background()
+	group := fmt.Sprintf("seq-test-%d", time.Now().UnixNano())
+
+	store := NewRedisSequenceStore(client, group, time.Minute)
+	t.Cleanup(func() { client.Del(ctx, "seq:"+group+":user:u1") })
+
+	tests := []struct {
+		name    string
+		advance int64
+		want    int64
+	}{
+		{name: "unknown aggregate starts at zero", want: 0},
+		{name: "advance", advance: 3, want: 3},
+		{name: "gap moves forward", advance: 7, want: 7},
+		{name: "duplicate keeps value", advance: 7, want: 7},
+		{name: "late event does not move back", advance: 5, want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.advance > 0 {
+				if err := store.Advance(ctx, "user", "u1", tt.advance); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := store.Last(ctx, "user", "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Last = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package kafka
+
+import (
+	"context"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startProcessSpan membuat span consumer untuk satu pesan. Trace context di header pesan
+// (traceparent/baggage dari KafkaPublisher) menjadi parent sekaligus link span ini,
+// sehingga alur request HTTP → publish → consume terlihat utuh di Jaeger.
+func startProcessSpan(ctx context.Context, group string, m kafka.Message) (context.Context, trace.Span) {
+	ctx = event.ExtractTraceContext(ctx, m)
+
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.destination.name", m.Topic),
+			attribute.String("messaging.consumer.group.name", group),
+			attribute.Int("messaging.destination.partition.id", m.Partition),
+			attribute.Int64("messaging.kafka.offset", m.Offset),
+			attribute.String("messaging.kafka.message.key", string(m.Key)),
+		),
+	}
+	if remote := trace.SpanContextFromContext(ctx); remote.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: remote}))
+	}
+	return otel.Tracer("kafka-consumer").Start(ctx, "process "+m.Topic, opts...)
+}
@@ -0,0 +1,334 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockKey adalah key pg_advisory_lock agar hanya satu proses yang menjalankan migrasi pada satu waktu
+const lockKey int64 = 0x6d69677261746531 // "migrate1"
+
+var (
+	// ErrChecksumMismatch dikembalikan jika file migrasi yang sudah diterapkan berubah isinya
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+	// ErrNoDownMigration dikembalikan jika migrasi yang akan di-rollback tidak punya file .down.sql
+	ErrNoDownMigration = errors.New("migration has no down file")
+
+	// ErrPending dikembalikan Check jika masih ada migrasi yang belum diterapkan
+	ErrPending = errors.New("pending migrations")
+)
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration adalah satu versi skema beserta SQL up/down-nya
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // sha256 dari SQL up
+}
+
+// Status adalah keadaan satu migrasi dibandingkan dengan tabel schema_migrations
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Modified  bool       `json:"modified"` // checksum file berbeda dengan saat diterapkan
+	Missing   bool       `json:"missing"`  // tercatat di database tetapi file-nya tidak ada
+}
+
+// appliedMigration adalah satu baris di tabel schema_migrations
+type appliedMigration struct {
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator menjalankan migrasi PostgreSQL dari file SQL yang di-embed
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New membaca semua file migrasi di dir pada fsys dan mengurutkannya berdasarkan versi
+func New(db *sql.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := load(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up menerapkan semua migrasi yang belum diterapkan, berurutan, masing-masing dalam satu transaksi
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range pending(m.migrations, applied) {
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+					mig.Version, mig.Name, mig.Checksum, time.Now(),
+				)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down me-rollback steps migrasi terakhir yang sudah diterapkan
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, ErrNoDownMigration)
+			}
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status membandingkan file migrasi dengan tabel schema_migrations
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := readApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		known := map[int64]bool{}
+		for _, mig := range m.migrations {
+			known[mig.Version] = true
+			s := Status{Version: mig.Version, Name: mig.Name}
+			if row, ok := applied[mig.Version]; ok {
+				appliedAt := row.AppliedAt
+				s.Applied = true
+				s.AppliedAt = &appliedAt
+				s.Modified = row.Checksum != mig.Checksum
+			}
+			statuses = append(statuses, s)
+		}
+
+		for version, row := range applied {
+			if !known[version] {
+				appliedAt := row.AppliedAt
+				statuses = append(statuses, Status{Version: version, Name: row.Name, Applied: true, AppliedAt: &appliedAt, Missing: true})
+			}
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+		return nil
+	})
+	return statuses, err
+}
+
+// Check memastikan skema database sesuai file migrasi: tidak ada migrasi yang tertunda (ErrPending)
+// atau diubah setelah diterapkan (ErrChecksumMismatch). Dipakai saat startup tanpa MIGRATE_ON_STARTUP.
+func (m *Migrator) Check(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+		todo := pending(m.migrations, applied)
+		if len(todo) == 0 {
+			return nil
+		}
+		names := make([]string, len(todo))
+		for i, mig := range todo {
+			names[i] = fmt.Sprintf("%04d_%s", mig.Version, mig.Name)
+		}
+		return fmt.Errorf("%w: %s", ErrPending, strings.Join(names, ", "))
+	})
+}
+
+// pending mengembalikan migrasi yang belum tercatat di applied, berurutan berdasarkan versi
+func pending(migrations []Migration, applied map[int64]appliedMigration) []Migration {
+	var todo []Migration
+	for _, mig := range migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			todo = append(todo, mig)
+		}
+	}
+	return todo
+}
+
+// verify memastikan migrasi yang sudah diterapkan tidak diubah sejak diterapkan
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	applied, err := readApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := compareChecksums(m.migrations, applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// compareChecksums mengembalikan ErrChecksumMismatch untuk migrasi pertama yang isinya berbeda
+// dengan saat diterapkan
+func compareChecksums(migrations []Migration, applied map[int64]appliedMigration) error {
+	for _, mig := range migrations {
+		if row, ok := applied[mig.Version]; ok && row.Checksum != mig.Checksum {
+			return fmt.Errorf("%w: %d_%s was modified after it was applied", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+// withLock menjalankan fn pada satu koneksi yang memegang advisory lock,
+// sehingga beberapa instance yang start bersamaan tidak menjalankan migrasi yang sama
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func readApplied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var (
+			version int64
+			row     appliedMigration
+		)
+		if err := rows.Scan(&version, &row.Name, &row.Checksum, &row.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = row
+	}
+	return applied, rows.Err()
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,167 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func checksum(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int64
+		wantErr  bool
+	}{
+		{
+			name: "ordered by numeric version",
+			files: fstest.MapFS{
+				"migrations/0010_add_outbox_failed.up.sql": {Data: []byte("SELECT 10;")},
+				"migrations/0002_add_soft_delete.up.sql":   {Data: []byte("SELECT 2;")},
+				"migrations/0002_add_soft_delete.down.sql": {Data: []byte("SELECT -2;")},
+				"migrations/0009_create_retries.up.sql":    {Data: []byte("SELECT 9;")},
+				"migrations/0001_create_users.up.sql":      {Data: []byte("SELECT 1;")},
+			},
+			versions: []int64{1, 2, 9, 10},
+		},
+		{
+			name: "other files ignored",
+			files: fstest.MapFS{
+				"migrations/0001_create_users.up.sql": {Data: []byte("SELECT 1;")},
+				"migrations/README.md":                {Data: []byte("docs")},
+				"migrations/0002_no_direction.sql":    {Data: []byte("SELECT 2;")},
+			},
+			versions: []int64{1},
+		},
+		{
+			name: "down without up",
+			files: fstest.MapFS{
+				"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting names for one version",
+			files: fstest.MapFS{
+				"migrations/0001_create_users.up.sql":    {Data: []byte("SELECT 1;")},
+				"migrations/0001_create_people.down.sql": {Data: []byte("SELECT -1;")},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing directory",
+			files:   fstest.MapFS{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.files, "migrations")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(migrations) != len(tt.versions) {
+				t.Fatalf("load() returned %d migrations, want %d", len(migrations), len(tt.versions))
+			}
+			for i, mig := range migrations {
+				if mig.Version != tt.versions[i] {
+					t.Errorf("migrations[%d].Version = %d, want %d", i, mig.Version, tt.versions[i])
+				}
+				if want := checksum(mig.Up); mig.Checksum != want {
+					t.Errorf("migrations[%d].Checksum = %s, want sha256 of the up file %s", i, mig.Checksum, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareChecksums(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "create_users", Up: "SELECT 1;", Checksum: checksum("SELECT 1;")},
+		{Version: 2, Name: "add_version", Up: "SELECT 2;", Checksum: checksum("SELECT 2;")},
+	}
+
+	tests := []struct {
+		name    string
+		applied map[int64]appliedMigration
+		wantErr error
+	}{
+		{name: "nothing applied", applied: map[int64]appliedMigration{}},
+		{
+			name: "applied unchanged",
+			applied: map[int64]appliedMigration{
+				1: {Name: "create_users", Checksum: checksum("SELECT 1;")},
+				2: {Name: "add_version", Checksum: checksum("SELECT 2;")},
+			},
+		},
+		{
+			name: "applied file modified",
+			applied: map[int64]appliedMigration{
+				1: {Name: "create_users", Checksum: checksum("SELECT 1;")},
+				2: {Name: "add_version", Checksum: checksum("SELECT 2; -- edited")},
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "applied file no longer present",
+			applied: map[int64]appliedMigration{
+				1: {Name: "create_users", Checksum: checksum("SELECT 1;")},
+				7: {Name: "removed", Checksum: checksum("SELECT 7;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compareChecksums(migrations, tt.applied)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("compareChecksums() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPending(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}}
+
+	tests := []struct {
+		name    string
+		applied []int64
+		want    []int64
+	}{
+		{name: "fresh database", applied: nil, want: []int64{1, 2, 3, 4}},
+		{name: "partially applied", applied: []int64{1, 2}, want: []int64{3, 4}},
+		{name: "gap is filled in order", applied: []int64{1, 3}, want: []int64{2, 4}},
+		{name: "up to date", applied: []int64{1, 2, 3, 4}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied := map[int64]appliedMigration{}
+			for _, v := range tt.applied {
+				applied[v] = appliedMigration{}
+			}
+
+			got := pending(migrations, applied)
+			if len(got) != len(tt.want) {
+				t.Fatalf("pending() returned %d migrations, want %d", len(got), len(tt.want))
+			}
+			for i, mig := range got {
+				if mig.Version != tt.want[i] {
+					t.Errorf("pending()[%d].Version = %d, want %d", i, mig.Version, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/event"
+)
+
+// Store adalah penyimpanan outbox, diimplementasikan oleh repository.OutboxRepositoryPostgres.
+// Add harus ikut transaksi yang dibawa ctx agar event tersimpan atomik dengan perubahan entitas.
+type Store interface {
+	Add(ctx context.Context, msgs ...entity.OutboxMessage) error
+	TryLock(ctx context.Context) (bool, error)
+	Claim(ctx context.Context, limit int, ttl time.Duration) ([]entity.OutboxMessage, error)
+	Release(ctx context.Context, ids []int64) error
+	MarkPublished(ctx context.Context, ids []int64) error
+	MarkFailed(ctx context.Context, ids []int64, reason string, nextAttempt time.Time) error
+	MarkDead(ctx context.Context, ids []int64, reason string) error
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// FailedStore membaca dan mengembalikan event yang gagal permanen (OUTBOX_MAX_ATTEMPTS habis),
+// dipakai endpoint /admin/outbox/failed. Diimplementasikan oleh repository.OutboxRepositoryPostgres.
+type FailedStore interface {
+	ListFailed(ctx context.Context, limit int) ([]entity.OutboxMessage, error)
+	Requeue(ctx context.Context, ids []int64) (int64, error)
+}
+
+// Publisher mengimplementasikan event.EventPublisher dengan menulis event ke tabel outbox,
+// bukan langsung ke Kafka. Event baru dikirim oleh Relay setelah transaksi di-commit.
+// Event disimpan sudah dalam envelope CloudEvents sehingga id dan time tetap sama saat retry.
+type Publisher struct {
+	store    Store
+	envelope event.Envelope
+}
+
+// NewPublisher membuat instance baru dari Publisher
+func NewPublisher(store Store, envelope event.Envelope) *Publisher {
+	return &Publisher{store: store, envelope: envelope}
+}
+
+// Publish menyimpan satu event ke outbox
+func (p *Publisher) Publish(ctx context.Context, topic string, key string, value interface{}) error {
+	msg, err := p.newMessage(ctx, topic, key, value)
+	if err != nil {
+		return err
+	}
+	return p.store.Add(ctx, msg)
+}
+
+// PublishBatch menyimpan banyak event ke outbox dalam satu INSERT
+func (p *Publisher) PublishBatch(ctx context.Context, topic string, messages []event.Message) error {
+	msgs := make([]entity.OutboxMessage, 0, len(messages))
+	for _, m := range messages {
+		msg, err := p.newMessage(ctx, topic, m.Key, m.Value)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return p.store.Add(ctx, msgs...)
+}
+
+func (p *Publisher) newMessage(ctx context.Context, topic, key string, value interface{}) (entity.OutboxMessage, error) {
+	ce, err := p.envelope.Wrap(ctx, key, value)
+	if err != nil {
+		return entity.OutboxMessage{}, err
+	}
+	payload, err := event.MarshalData(ce)
+	if err != nil {
+		return entity.OutboxMessage{}, err
+	}
+	return entity.OutboxMessage{
+		Topic:       topic,
+		AggregateID: ce.Subject,
+		Key:         key,
+		Payload:     payload,
+	}, nil
+}
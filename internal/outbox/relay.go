@@ -0,0 +1,226 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"golang-crud-clean-arch/config"
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/event"
+)
+
+// Transactor menjalankan fn dalam satu transaksi database, lihat repository.PostgresTransactor
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Relay membaca event pending dari outbox dan mengirimkannya ke broker (Kafka).
+// Pengiriman bersifat at-least-once: event bisa terkirim ulang jika relay mati
+// setelah publish tetapi sebelum outbox ditandai terkirim.
+type Relay struct {
+	store      Store
+	transactor Transactor
+	publisher  event.EventPublisher
+	cfg        config.OutboxConfig
+	lastPrune  time.Time
+}
+
+// NewRelay membuat instance baru dari Relay
+func NewRelay(store Store, transactor Transactor, publisher event.EventPublisher, cfg config.OutboxConfig) *Relay {
+	return &Relay{
+		store:      store,
+		transactor: transactor,
+		publisher:  publisher,
+		cfg:        cfg,
+	}
+}
+
+// Run memproses outbox setiap PollInterval sampai ctx dibatalkan
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	log.Printf("📤 Outbox relay started (interval %s, batch %d)", r.cfg.PollInterval, r.cfg.BatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("🛑 Outbox relay stopped")
+			return
+		case <-ticker.C:
+			if err := r.RunOnce(ctx); err != nil {
+				log.Printf("❌ Outbox relay error: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce mengirim satu batch event pending dalam tiga langkah:
+//  1. transaksi singkat: advisory lock lalu klaim batch dengan lease ClaimTTL
+//  2. publish ke broker di luar transaksi, sehingga koneksi dan lock tidak tertahan selama broker lambat
+//  3. transaksi kedua: tandai terkirim/gagal lalu lepas klaim
+//
+// Selama klaim masih berlaku instance lain tidak mengklaim apa pun, jadi urutan per aggregate tetap terjaga.
+// Jika relay mati sebelum langkah 3, batch dikirim ulang setelah lease habis (at-least-once).
+func (r *Relay) RunOnce(ctx context.Context) error {
+	var msgs []entity.OutboxMessage
+	err := r.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		locked, err := r.store.TryLock(ctx)
+		if err != nil || !locked {
+			return err
+		}
+		msgs, err = r.store.Claim(ctx, r.cfg.BatchSize, r.cfg.ClaimTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(msgs) > 0 {
+		result := r.deliver(ctx, msgs)
+		if err := r.transactor.WithinTx(ctx, result.apply(r.store, msgs)); err != nil {
+			return err
+		}
+	}
+	return r.prune(ctx)
+}
+
+// deliveryFailure adalah event yang gagal dikirim beserta jadwal retry-nya.
+// dead berarti MaxAttempts sudah habis dan event ditandai gagal permanen.
+type deliveryFailure struct {
+	id     int64
+	reason string
+	next   time.Time
+	dead   bool
+}
+
+// deliveryResult mengumpulkan hasil publish agar bisa dicatat dalam satu transaksi
+type deliveryResult struct {
+	published []int64
+	failed    []deliveryFailure
+}
+
+// apply mencatat hasil publish ke outbox lalu melepas klaim seluruh batch
+func (d deliveryResult) apply(store Store, claimed []entity.OutboxMessage) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := store.MarkPublished(ctx, d.published); err != nil {
+			return err
+		}
+		for _, f := range d.failed {
+			if f.dead {
+				if err := store.MarkDead(ctx, []int64{f.id}, f.reason); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := store.MarkFailed(ctx, []int64{f.id}, f.reason, f.next); err != nil {
+				return err
+			}
+		}
+
+		ids := make([]int64, len(claimed))
+		for i, msg := range claimed {
+			ids[i] = msg.ID
+		}
+		return store.Release(ctx, ids)
+	}
+}
+
+// deliver mengirim event per topic sesuai urutan id. Claim hanya mengembalikan event yang sudah
+// jatuh tempo dan tidak didahului event tertahan dari aggregate yang sama, jadi semua event dikirim.
+// Event yang gagal untuk ke-MaxAttempts kalinya ditandai gagal permanen dan dicatat di log; event
+// berikutnya dari aggregate yang sama tidak lagi menunggunya.
+func (r *Relay) deliver(ctx context.Context, msgs []entity.OutboxMessage) deliveryResult {
+	now := time.Now()
+	byTopic := map[string][]entity.OutboxMessage{}
+	var topics []string
+
+	for _, msg := range msgs {
+		if _, ok := byTopic[msg.Topic]; !ok {
+			topics = append(topics, msg.Topic)
+		}
+		byTopic[msg.Topic] = append(byTopic[msg.Topic], msg)
+	}
+
+	var result deliveryResult
+	for _, topic := range topics {
+		batch := byTopic[topic]
+		messages := make([]event.Message, len(batch))
+		for i, msg := range batch {
+			messages[i] = event.Message{Key: msg.Key, Value: payloadValue(msg.Payload)}
+		}
+
+		if err := event.PublishAll(ctx, r.publisher, topic, messages); err != nil {
+			log.Printf("⚠️ Outbox publish to %s failed (%d events): %v", topic, len(batch), err)
+			for _, msg := range batch {
+				dead := r.cfg.MaxAttempts > 0 && msg.Attempts+1 >= r.cfg.MaxAttempts
+				if dead {
+					log.Printf("❌ Outbox event %d (%s) to %s failed %d times, marked failed: %v",
+						msg.ID, aggregateKey(msg), topic, msg.Attempts+1, err)
+				}
+				result.failed = append(result.failed, deliveryFailure{
+					id:     msg.ID,
+					reason: err.Error(),
+					next:   now.Add(r.backoff(msg.Attempts)),
+					dead:   dead,
+				})
+			}
+			continue
+		}
+
+		for _, msg := range batch {
+			result.published = append(result.published, msg.ID)
+		}
+		log.Printf("✅ Outbox published %d events to %s", len(batch), topic)
+	}
+	return result
+}
+
+// prune menghapus event terkirim yang lebih tua dari Retention, paling sering sekali per menit
+func (r *Relay) prune(ctx context.Context) error {
+	if time.Since(r.lastPrune) < time.Minute {
+		return nil
+	}
+	n, err := r.store.Prune(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		return err
+	}
+	r.lastPrune = time.Now()
+	if n > 0 {
+		log.Printf("🧹 Outbox pruned %d published events", n)
+	}
+	return nil
+}
+
+// backoff menghitung jeda retry eksponensial: PollInterval * 2^attempts, dibatasi MaxBackoff
+func (r *Relay) backoff(attempts int) time.Duration {
+	if attempts > 20 {
+		return r.cfg.MaxBackoff
+	}
+	d := r.cfg.PollInterval << attempts
+	if d <= 0 || d > r.cfg.MaxBackoff {
+		return r.cfg.MaxBackoff
+	}
+	return d
+}
+
+// payloadValue mengembalikan CloudEvent yang tersimpan di outbox. Baris lama yang
+// disimpan sebelum envelope CloudEvents dikirim sebagai data mentah.
+func payloadValue(payload []byte) interface{} {
+	var ce event.CloudEvent
+	if err := json.Unmarshal(payload, &ce); err == nil && ce.SpecVersion != "" {
+		return ce
+	}
+	return json.RawMessage(payload)
+}
+
+// aggregateKey mengelompokkan event yang urutannya harus dijaga.
+// Event tanpa aggregate id tidak saling menunggu.
+func aggregateKey(msg entity.OutboxMessage) string {
+	if msg.AggregateID == "" {
+		return msg.Topic + "#" + strconv.FormatInt(msg.ID, 10)
+	}
+	return msg.Topic + "/" + msg.AggregateID
+}
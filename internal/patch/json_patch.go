@@ -0,0 +1,272 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONPatch adalah daftar operasi JSON Patch (RFC 6902) yang diterapkan berurutan
+type JSONPatch []Operation
+
+// Operation adalah satu operasi JSON Patch: add, remove, replace, move, copy atau test
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func (p JSONPatch) apply(doc interface{}) (interface{}, error) {
+	for i, op := range p {
+		var err error
+		doc, err = op.apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func (op Operation) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return put(doc, path, value, true)
+
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+
+	case "replace":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return put(doc, path, value, false)
+
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("%w: cannot move %q into its own child", ErrInvalidPatch, op.From)
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return put(doc, path, value, true)
+
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		// Salin nilai agar perubahan berikutnya tidak ikut mengubah sumbernya
+		value, err = toDocument(value)
+		if err != nil {
+			return nil, err
+		}
+		return put(doc, path, value, true)
+
+	case "test":
+		expected, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// value men-decode field "value"; operasi add, replace dan test wajib memilikinya
+func (op Operation) value() (interface{}, error) {
+	if op.Value == nil {
+		return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+	}
+	var value interface{}
+	if err := decode(op.Value, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+// parsePointer memecah JSON Pointer (RFC 6901) menjadi token, "" berarti seluruh dokumen
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path not found at %q", ErrInvalidPatch, token)
+			}
+			doc = child
+		case []interface{}:
+			idx, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[idx]
+		default:
+			return nil, fmt.Errorf("%w: cannot traverse into %q", ErrInvalidPatch, token)
+		}
+	}
+	return doc, nil
+}
+
+// put menulis value di path. insert=true mengikuti semantik "add" (menyisipkan ke array),
+// insert=false mengikuti "replace" (target harus sudah ada).
+func put(doc interface{}, path []string, value interface{}, insert bool) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, last := path[0], len(path) == 1
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, exists := node[token]
+		if last {
+			if !insert && !exists {
+				return nil, fmt.Errorf("%w: path not found at %q", ErrInvalidPatch, token)
+			}
+			node[token] = value
+			return node, nil
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: path not found at %q", ErrInvalidPatch, token)
+		}
+		updated, err := put(child, path[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = updated
+		return node, nil
+
+	case []interface{}:
+		if last && insert {
+			idx := len(node)
+			if token != "-" {
+				var err error
+				if idx, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		}
+
+		idx, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			node[idx] = value
+			return node, nil
+		}
+		updated, err := put(node[idx], path[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = updated
+		return node, nil
+
+	default:
+		return nil, fmt.Errorf("%w: cannot traverse into %q", ErrInvalidPatch, token)
+	}
+}
+
+// remove menghapus nilai di path dan mengembalikan dokumen baru beserta nilai yang dihapus
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	token, last := path[0], len(path) == 1
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, exists := node[token]
+		if !exists {
+			return nil, nil, fmt.Errorf("%w: path not found at %q", ErrInvalidPatch, token)
+		}
+		if last {
+			delete(node, token)
+			return node, child, nil
+		}
+		updated, removed, err := remove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = updated
+		return node, removed, nil
+
+	case []interface{}:
+		idx, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := node[idx]
+			return append(node[:idx], node[idx+1:]...), removed, nil
+		}
+		updated, removed, err := remove(node[idx], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[idx] = updated
+		return node, removed, nil
+
+	default:
+		return nil, nil, fmt.Errorf("%w: cannot traverse into %q", ErrInvalidPatch, token)
+	}
+}
+
+// arrayIndex mem-parse token index array dan memastikan 0 <= index <= max
+func arrayIndex(token string, max int) (int, error) {
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return idx, nil
+}
@@ -0,0 +1,205 @@
+package patch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Contoh dari RFC 6902 Appendix A
+func TestJSONPatchRFC6902Examples(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "A.1 adding an object member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name:  "A.2 adding an array element",
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name:  "A.3 removing an object member",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`,
+		},
+		{
+			name:  "A.4 removing an array element",
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name:  "A.5 replacing a value",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name:  "A.6 moving a value",
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name:  "A.7 moving an array element",
+			doc:   `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			name: "A.8 testing a value: success",
+			doc:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[
+				{"op": "test", "path": "/baz", "value": "qux"},
+				{"op": "test", "path": "/foo/1", "value": 2}
+			]`,
+			want: `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name:    "A.9 testing a value: error",
+			doc:     `{"baz": "qux"}`,
+			patch:   `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.10 adding a nested member object",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name:  "A.11 ignoring unrecognized elements",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			want:  `{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			name:    "A.12 adding to a nonexistent target",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name: "A.14 ~ escape ordering",
+			doc:  `{"/": 9, "~1": 10}`,
+			patch: `[
+				{"op": "test", "path": "/~01", "value": 10},
+				{"op": "test", "path": "/~1", "value": 9}
+			]`,
+			want: `{"/": 9, "~1": 10}`,
+		},
+		{
+			name:    "A.15 comparing strings and numbers",
+			doc:     `{"/": 9, "~1": 10}`,
+			patch:   `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:  "A.16 adding an array value",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+		{
+			name:  "copy keeps the source",
+			doc:   `{"foo": {"bar": 1}}`,
+			patch: `[{"op": "copy", "from": "/foo", "path": "/baz"}, {"op": "replace", "path": "/baz/bar", "value": 2}]`,
+			want:  `{"foo": {"bar": 1}, "baz": {"bar": 2}}`,
+		},
+		{
+			name:    "replace of a missing member",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "replace", "path": "/baz", "value": "qux"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "move into its own child",
+			doc:     `{"foo": {"bar": 1}}`,
+			patch:   `[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "array index with leading zero",
+			doc:     `{"foo": ["a", "b"]}`,
+			patch:   `[{"op": "remove", "path": "/foo/01"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "unknown op",
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "frobnicate", "path": "/foo"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(MediaTypeJSONPatch, []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var doc interface{}
+			if err := decode([]byte(tt.doc), &doc); err != nil {
+				t.Fatalf("decode doc: %v", err)
+			}
+
+			got, err := p.apply(doc)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+
+			var want interface{}
+			if err := decode([]byte(tt.want), &want); err != nil {
+				t.Fatalf("decode want: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+		wantErr bool
+	}{
+		{pointer: "", want: nil},
+		{pointer: "/foo", want: []string{"foo"}},
+		{pointer: "/foo/0", want: []string{"foo", "0"}},
+		{pointer: "/", want: []string{""}},
+		{pointer: "/a~1b", want: []string{"a/b"}},
+		{pointer: "/m~0n", want: []string{"m~n"}},
+		{pointer: "/~01", want: []string{"~1"}},
+		{pointer: "foo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePointer(tt.pointer)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePointer(%q) error = %v, wantErr %v", tt.pointer, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePointer(%q) = %q, want %q", tt.pointer, got, tt.want)
+		}
+	}
+}
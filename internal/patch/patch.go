@@ -0,0 +1,179 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+)
+
+// Media type yang didukung oleh endpoint PATCH
+const (
+	MediaTypeMergePatch = "application/merge-patch+json" // RFC 7396
+	MediaTypeJSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+var (
+	// ErrUnsupportedMediaType dikembalikan jika Content-Type bukan salah satu format patch yang didukung
+	ErrUnsupportedMediaType = errors.New("unsupported patch media type")
+
+	// ErrInvalidPatch dikembalikan jika dokumen patch rusak atau tidak bisa diterapkan
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrTestFailed dikembalikan jika operasi "test" pada JSON Patch tidak terpenuhi
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// Patch adalah perubahan parsial yang bisa diterapkan ke dokumen JSON
+type Patch interface {
+	apply(doc interface{}) (interface{}, error)
+}
+
+// Parse memilih format patch berdasarkan Content-Type.
+// "application/json" diperlakukan sebagai merge patch agar client sederhana tetap bisa memakai PATCH.
+func Parse(contentType string, body []byte) (Patch, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, contentType)
+	}
+
+	switch mediaType {
+	case MediaTypeMergePatch, "application/json":
+		var doc interface{}
+		if err := decode(body, &doc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return MergePatch{doc: doc}, nil
+	case MediaTypeJSONPatch:
+		var ops JSONPatch
+		if err := decode(body, &ops); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return ops, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, mediaType)
+	}
+}
+
+// ApplyTo menerapkan patch ke entitas current dan men-decode hasilnya ke dest.
+// Field (nama JSON top-level) di readOnly tidak boleh diubah oleh patch.
+func ApplyTo(current interface{}, p Patch, dest interface{}, readOnly ...string) error {
+	before, err := toDocument(current)
+	if err != nil {
+		return err
+	}
+
+	// Terapkan ke salinan agar dokumen before tetap utuh untuk pengecekan read-only
+	working, err := toDocument(current)
+	if err != nil {
+		return err
+	}
+	after, err := p.apply(working)
+	if err != nil {
+		return err
+	}
+
+	beforeMap, _ := before.(map[string]interface{})
+	afterMap, ok := after.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%w: patched document must be a JSON object", ErrInvalidPatch)
+	}
+	for _, field := range readOnly {
+		if !reflect.DeepEqual(beforeMap[field], afterMap[field]) {
+			return fmt.Errorf("%w: field %q is read-only", ErrInvalidPatch, field)
+		}
+	}
+
+	raw, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return nil
+}
+
+// Changes membandingkan dua entitas dan mengembalikan field top-level yang berubah beserta nilai barunya.
+// Field yang hilang pada after dilaporkan dengan nilai nil.
+func Changes(before, after interface{}, ignore ...string) (map[string]interface{}, error) {
+	beforeDoc, err := toDocument(before)
+	if err != nil {
+		return nil, err
+	}
+	afterDoc, err := toDocument(after)
+	if err != nil {
+		return nil, err
+	}
+
+	beforeMap, _ := beforeDoc.(map[string]interface{})
+	afterMap, _ := afterDoc.(map[string]interface{})
+	skip := make(map[string]bool, len(ignore))
+	for _, field := range ignore {
+		skip[field] = true
+	}
+
+	changes := map[string]interface{}{}
+	for field, value := range afterMap {
+		if !skip[field] && !reflect.DeepEqual(beforeMap[field], value) {
+			changes[field] = value
+		}
+	}
+	for field := range beforeMap {
+		if _, ok := afterMap[field]; !ok && !skip[field] {
+			changes[field] = nil
+		}
+	}
+	return changes, nil
+}
+
+// MergePatch adalah JSON Merge Patch (RFC 7396): field bernilai null dihapus, object digabung secara rekursif
+type MergePatch struct {
+	doc interface{}
+}
+
+func (m MergePatch) apply(doc interface{}) (interface{}, error) {
+	return mergeValue(doc, m.doc), nil
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = map[string]interface{}{}
+	}
+	for key, value := range patchMap {
+		if value == nil {
+			delete(targetMap, key)
+			continue
+		}
+		targetMap[key] = mergeValue(targetMap[key], value)
+	}
+	return targetMap
+}
+
+// toDocument mengubah nilai Go menjadi dokumen JSON generik (map, slice, json.Number, ...)
+func toDocument(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := decode(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// decode memakai json.Number agar angka besar (misalnya version) tidak kehilangan presisi
+func decode(raw []byte, dest interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(dest)
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"golang-crud-clean-arch/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errAtomicBatchFailed membatalkan transaksi MongoDB ketika ada operasi batch atomic yang gagal
+var errAtomicBatchFailed = errors.New("atomic batch failed")
+
+// runBatchPostgres menjalankan n operasi dalam satu transaksi dan mengembalikan error per operasi.
+// Jika ctx sudah membawa transaksi (lihat PostgresTransactor), batch ikut transaksi itu lewat SAVEPOINT.
+// atomic=true: operasi pertama yang gagal membatalkan seluruh batch.
+// atomic=false: setiap operasi dibungkus SAVEPOINT sehingga kegagalannya tidak membatalkan operasi lain.
+func runBatchPostgres(ctx context.Context, db *sql.DB, n int, atomic bool, apply func(q sqlExecutor, i int) error) ([]error, error) {
+	state, joined := ctx.Value(txKey{}).(*txState)
+
+	var tx *sql.Tx
+	if joined {
+		tx = state.tx
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT batch"); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if tx, err = db.BeginTx(ctx, nil); err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+	}
+
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		if atomic {
+			if errs[i] = apply(tx, i); errs[i] != nil {
+				if joined {
+					if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch"); err != nil {
+						return nil, err
+					}
+				}
+				return errs, nil
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_item"); err != nil {
+			return nil, err
+		}
+		if errs[i] = apply(tx, i); errs[i] != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_item"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_item"); err != nil {
+			return nil, err
+		}
+	}
+
+	if joined {
+		_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch")
+		return errs, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// batchFailed melaporkan apakah ada operasi yang gagal
+func batchFailed(errs []error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// mongoBatchBuilder menerjemahkan satu operasi batch menjadi WriteModel.
+// current adalah versi dokumen yang tersimpan (0 untuk create).
+type mongoBatchBuilder[T any] func(op entity.BatchOperation[T], id primitive.ObjectID, current int64) mongo.WriteModel
+
+// runBatchMongo menjalankan operasi batch dengan satu BulkWrite dan mengembalikan error per operasi.
+// Versi dokumen dibaca lebih dulu agar not found / version conflict bisa dilaporkan per operasi,
+// lalu dipakai sebagai filter sehingga perubahan bersamaan tidak tertimpa.
+// Mode atomic berjalan di dalam transaksi MongoDB (butuh replica set, misalnya Atlas).
+func runBatchMongo[T any](ctx context.Context, collection *mongo.Collection, fields mongoFields, ops []entity.BatchOperation[T], atomic bool, build mongoBatchBuilder[T]) ([]error, error) {
+	errs := make([]error, len(ops))
+	ids := make([]primitive.ObjectID, len(ops))
+	seen := map[primitive.ObjectID]bool{}
+	var lookup []primitive.ObjectID
+	for i, op := range ops {
+		if op.Op == entity.BatchCreate {
+			continue
+		}
+		oid, err := primitive.ObjectIDFromHex(op.ID)
+		if err != nil {
+			errs[i] = fmt.Errorf("%w: invalid id %q", entity.ErrNotFound, op.ID)
+			continue
+		}
+		// Filter versi tidak bisa membedakan dua operasi pada dokumen yang sama dalam satu BulkWrite
+		if seen[oid] {
+			errs[i] = fmt.Errorf("%w: duplicate id %q", entity.ErrInvalidBatch, op.ID)
+			continue
+		}
+		seen[oid] = true
+		ids[i] = oid
+		lookup = append(lookup, oid)
+	}
+
+	versions := map[primitive.ObjectID]int64{}
+	if len(lookup) > 0 {
+		var err error
+		versions, err = mongoVersions(ctx, collection, fields, bson.M{fields.ID: bson.M{"$in": lookup}, fields.DeletedAt: nil})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		models    []mongo.WriteModel
+		positions []int // index operasi asal untuk setiap model
+		expected  = map[int]int64{}
+	)
+	for i, op := range ops {
+		if errs[i] != nil {
+			continue
+		}
+
+		var current int64
+		if op.Op != entity.BatchCreate {
+			v, ok := versions[ids[i]]
+			switch {
+			case !ok:
+				errs[i] = entity.ErrNotFound
+				continue
+			case op.Version > 0 && op.Version != v:
+				errs[i] = entity.ErrVersionConflict
+				continue
+			}
+			current = v
+			expected[i] = v + 1
+		}
+
+		models = append(models, build(op, ids[i], current))
+		positions = append(positions, i)
+	}
+
+	if (atomic && batchFailed(errs)) || len(models) == 0 {
+		return errs, nil
+	}
+
+	precheck := append([]error(nil), errs...)
+	write := func(ctx context.Context) error {
+		copy(errs, precheck)
+
+		result, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(atomic))
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) {
+			for _, writeErr := range bulkErr.WriteErrors {
+				errs[positions[writeErr.Index]] = errors.New(writeErr.Message)
+			}
+		} else if err != nil {
+			return err
+		}
+
+		// Jika ada update yang tidak match, cari operasi mana yang kalah oleh perubahan bersamaan
+		if len(expected) > 0 && (result == nil || result.MatchedCount < int64(len(expected))) {
+			var changed []primitive.ObjectID
+			for i := range expected {
+				changed = append(changed, ids[i])
+			}
+			after, err := mongoVersions(ctx, collection, fields, bson.M{fields.ID: bson.M{"$in": changed}})
+			if err != nil {
+				return err
+			}
+			for i, v := range expected {
+				if errs[i] == nil && after[ids[i]] != v {
+					errs[i] = entity.ErrVersionConflict
+				}
+			}
+		}
+
+		if atomic && batchFailed(errs) {
+			return errAtomicBatchFailed
+		}
+		return nil
+	}
+
+	if !atomic {
+		if err := write(ctx); err != nil {
+			return nil, err
+		}
+		return errs, nil
+	}
+
+	session, err := collection.Database().Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, write(sc)
+	})
+	if err != nil && !errors.Is(err, errAtomicBatchFailed) {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// mongoVersions membaca field version dokumen yang cocok dengan filter
+func mongoVersions(ctx context.Context, collection *mongo.Collection, fields mongoFields, filter bson.M) (map[primitive.ObjectID]int64, error) {
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{fields.ID: 1, "version": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	versions := map[primitive.ObjectID]int64{}
+	for cursor.Next(ctx) {
+		id, ok := cursor.Current.Lookup(fields.ID).ObjectIDOK()
+		if !ok {
+			continue
+		}
+		version, ok := cursor.Current.Lookup("version").AsInt64OK()
+		if !ok {
+			continue
+		}
+		versions[id] = version
+	}
+	return versions, cursor.Err()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EventSequenceRepositoryPostgres mengimplementasikan event.Sequencer dengan tabel event_sequences.
+// Next memakai transaksi dari ctx: baris agregat terkunci sampai commit, sehingga penulisan
+// paralel ke entitas yang sama mendapat nomor berurutan dan rollback tidak meninggalkan lubang.
+type EventSequenceRepositoryPostgres struct {
+	db *sql.DB
+}
+
+// NewEventSequenceRepositoryPostgres membuat instance baru dari EventSequenceRepositoryPostgres
+func NewEventSequenceRepositoryPostgres(db *sql.DB) *EventSequenceRepositoryPostgres {
+	return &EventSequenceRepositoryPostgres{db: db}
+}
+
+// Next menaikkan dan mengembalikan nomor urut agregat
+func (r *EventSequenceRepositoryPostgres) Next(ctx context.Context, aggregateType, aggregateID string) (int64, error) {
+	query := `INSERT INTO event_sequences (aggregate_type, aggregate_id, sequence) VALUES ($1, $2, 1)
+			  ON CONFLICT (aggregate_type, aggregate_id)
+			  DO UPDATE SET sequence = event_sequences.sequence + 1, updated_at = NOW()
+			  RETURNING sequence`
+	var seq int64
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, aggregateType, aggregateID).Scan(&seq)
+	return seq, err
+}
+
+// EventSequenceRepositoryMongo mengimplementasikan event.Sequencer dengan koleksi event_sequences.
+// MongoDB tidak memakai transaksi di sini, jadi publish yang gagal setelah Next meninggalkan lubang
+// yang akan terdeteksi consumer sebagai gap.
+type EventSequenceRepositoryMongo struct {
+	db     *mongo.Client
+	dbName string
+}
+
+// NewEventSequenceRepositoryMongo membuat instance baru dari EventSequenceRepositoryMongo
+func NewEventSequenceRepositoryMongo(db *mongo.Client, dbName string) *EventSequenceRepositoryMongo {
+	return &EventSequenceRepositoryMongo{db: db, dbName: dbName}
+}
+
+// Next menaikkan dan mengembalikan nomor urut agregat secara atomik
+func (r *EventSequenceRepositoryMongo) Next(ctx context.Context, aggregateType, aggregateID string) (int64, error) {
+	collection := r.db.Database(r.dbName).Collection("event_sequences")
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": aggregateType + ":" + aggregateID},
+		bson.M{"$inc": bson.M{"sequence": 1}},
+		opts,
+	).Decode(&doc)
+	return doc.Sequence, err
+}
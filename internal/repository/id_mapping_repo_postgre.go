@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/event"
+)
+
+// IDMappingRepositoryPostgres menyimpan pasangan id PostgreSQL ↔ MongoDB di tabel id_mappings
+type IDMappingRepositoryPostgres struct {
+	db *sql.DB
+}
+
+// NewIDMappingRepositoryPostgres membuat instance baru dari IDMappingRepositoryPostgres
+func NewIDMappingRepositoryPostgres(db *sql.DB) *IDMappingRepositoryPostgres {
+	return &IDMappingRepositoryPostgres{db: db}
+}
+
+// Lookup mengembalikan id pasangan dari id milik backend (event.BackendPostgres/BackendMongo).
+// entity.ErrNotFound dikembalikan jika belum ada pemetaan.
+func (r *IDMappingRepositoryPostgres) Lookup(ctx context.Context, entityName, backend, id string) (string, error) {
+	query := `SELECT mongo_id FROM id_mappings WHERE entity = $1 AND pg_id = $2`
+	if backend == event.BackendMongo {
+		query = `SELECT pg_id::text FROM id_mappings WHERE entity = $1 AND mongo_id = $2`
+	}
+
+	var counterpart string
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, entityName, id).Scan(&counterpart)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", entity.ErrNotFound
+	}
+	return counterpart, err
+}
+
+// Save menyimpan pemetaan baru, pemetaan yang sudah ada tidak diubah
+func (r *IDMappingRepositoryPostgres) Save(ctx context.Context, m entity.IDMapping) error {
+	query := `INSERT INTO id_mappings (entity, pg_id, mongo_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, m.Entity, m.PgID, m.MongoID)
+	return err
+}
+
+// Delete menghapus pemetaan berdasarkan id milik backend
+func (r *IDMappingRepositoryPostgres) Delete(ctx context.Context, entityName, backend, id string) error {
+	query := `DELETE FROM id_mappings WHERE entity = $1 AND pg_id = $2`
+	if backend == event.BackendMongo {
+		query = `DELETE FROM id_mappings WHERE entity = $1 AND mongo_id = $2`
+	}
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, entityName, id)
+	return err
+}
+
+// List mengembalikan semua pemetaan untuk satu entitas
+func (r *IDMappingRepositoryPostgres) List(ctx context.Context, entityName string) ([]entity.IDMapping, error) {
+	query := `SELECT entity, pg_id::text, mongo_id, created_at FROM id_mappings WHERE entity = $1 ORDER BY created_at`
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, entityName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []entity.IDMapping
+	for rows.Next() {
+		var m entity.IDMapping
+		if err := rows.Scan(&m.Entity, &m.PgID, &m.MongoID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
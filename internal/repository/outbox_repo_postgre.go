@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"golang-crud-clean-arch/internal/entity"
+)
+
+// outboxLockKey adalah key advisory lock agar hanya satu relay yang mengirim outbox pada satu waktu
+const outboxLockKey int64 = 0x6f7574626f78 // "outbox"
+
+// OutboxRepositoryPostgres menyimpan dan membaca tabel outbox.
+// Semua method memakai transaksi dari ctx jika ada (lihat PostgresTransactor).
+type OutboxRepositoryPostgres struct {
+	db *sql.DB
+}
+
+// NewOutboxRepositoryPostgres membuat instance baru dari OutboxRepositoryPostgres
+func NewOutboxRepositoryPostgres(db *sql.DB) *OutboxRepositoryPostgres {
+	return &OutboxRepositoryPostgres{db: db}
+}
+
+// Add menyimpan satu atau lebih event ke outbox dalam satu INSERT
+func (r *OutboxRepositoryPostgres) Add(ctx context.Context, msgs ...entity.OutboxMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(msgs))
+	args := make([]interface{}, 0, len(msgs)*4)
+	for i, msg := range msgs {
+		n := i * 4
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4))
+		args = append(args, msg.Topic, msg.AggregateID, msg.Key, string(msg.Payload))
+	}
+
+	query := `INSERT INTO outbox (topic, aggregate_id, event_key, payload) VALUES ` + strings.Join(values, ", ")
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, args...)
+	return err
+}
+
+// TryLock mengambil advisory lock relay untuk sisa transaksi di ctx, false jika dipegang instance lain
+func (r *OutboxRepositoryPostgres) TryLock(ctx context.Context) (bool, error) {
+	var locked bool
+	err := executor(ctx, r.db).QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey).Scan(&locked)
+	return locked, err
+}
+
+// Claim mengklaim event yang belum terkirim dan sudah jatuh tempo, berurutan dari yang paling lama,
+// dengan lease selama ttl. Event hanya diklaim jika tidak ada event lebih lama dari aggregate yang
+// sama yang masih menunggu retry, sehingga event yang tertahan tidak memenuhi batch dan tidak
+// menahan aggregate lain. Event yang sudah gagal permanen (failed_at) dilewati.
+// Tidak ada yang diklaim selama klaim relay lain masih berlaku, sehingga urutan per aggregate
+// tetap terjaga meskipun publish dilakukan di luar transaksi.
+// Panggil di dalam transaksi yang sama dengan TryLock.
+func (r *OutboxRepositoryPostgres) Claim(ctx context.Context, limit int, ttl time.Duration) ([]entity.OutboxMessage, error) {
+	query := `WITH batch AS (
+				SELECT o.id FROM outbox o
+				WHERE o.published_at IS NULL AND o.failed_at IS NULL
+				  AND o.next_attempt_at <= NOW()
+				  AND NOT EXISTS (SELECT 1 FROM outbox WHERE published_at IS NULL AND claimed_until > NOW())
+				  AND (o.aggregate_id = '' OR NOT EXISTS (
+					SELECT 1 FROM outbox p
+					WHERE p.topic = o.topic AND p.aggregate_id = o.aggregate_id AND p.id < o.id
+					  AND p.published_at IS NULL AND p.failed_at IS NULL
+					  AND p.next_attempt_at > NOW()
+				  ))
+				ORDER BY o.id LIMIT $1
+			  )
+			  UPDATE outbox o SET claimed_until = NOW() + $2 * INTERVAL '1 millisecond'
+			  FROM batch WHERE o.id = batch.id
+			  RETURNING o.id, o.topic, o.aggregate_id, o.event_key, o.payload, o.created_at, o.attempts, o.next_attempt_at, o.last_error, o.failed_at`
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, limit, ttl.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs, err := scanOutboxMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING tidak menjamin urutan, relay membutuhkan urutan id
+	slices.SortFunc(msgs, func(a, b entity.OutboxMessage) int { return cmp.Compare(a.ID, b.ID) })
+	return msgs, nil
+}
+
+// Release melepas klaim event agar bisa diambil lagi pada putaran berikutnya
+func (r *OutboxRepositoryPostgres) Release(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := executor(ctx, r.db).ExecContext(ctx,
+		`UPDATE outbox SET claimed_until = NULL WHERE id = ANY($1)`, ids)
+	return err
+}
+
+// MarkPublished menandai event sebagai terkirim
+func (r *OutboxRepositoryPostgres) MarkPublished(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := executor(ctx, r.db).ExecContext(ctx,
+		`UPDATE outbox SET published_at = $1, last_error = NULL WHERE id = ANY($2)`, time.Now(), ids)
+	return err
+}
+
+// MarkFailed mencatat kegagalan kirim dan menjadwalkan percobaan berikutnya
+func (r *OutboxRepositoryPostgres) MarkFailed(ctx context.Context, ids []int64, reason string, nextAttempt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := executor(ctx, r.db).ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = ANY($3)`,
+		reason, nextAttempt, ids)
+	return err
+}
+
+// MarkDead mencatat kegagalan terakhir dan menandai event gagal permanen; relay tidak mencobanya lagi
+func (r *OutboxRepositoryPostgres) MarkDead(ctx context.Context, ids []int64, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := executor(ctx, r.db).ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $1, failed_at = $2 WHERE id = ANY($3)`,
+		reason, time.Now(), ids)
+	return err
+}
+
+// ListFailed mengembalikan event yang gagal permanen, paling baru lebih dulu
+func (r *OutboxRepositoryPostgres) ListFailed(ctx context.Context, limit int) ([]entity.OutboxMessage, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx,
+		`SELECT id, topic, aggregate_id, event_key, payload, created_at, attempts, next_attempt_at, last_error, failed_at
+		 FROM outbox WHERE failed_at IS NOT NULL AND published_at IS NULL
+		 ORDER BY failed_at DESC, id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanOutboxMessages(rows)
+}
+
+// Requeue mengembalikan event yang gagal permanen ke antrian dengan jumlah percobaan dari nol.
+// ids kosong berarti semua event yang gagal permanen.
+func (r *OutboxRepositoryPostgres) Requeue(ctx context.Context, ids []int64) (int64, error) {
+	query := `UPDATE outbox SET failed_at = NULL, attempts = 0, next_attempt_at = NOW()
+			  WHERE failed_at IS NOT NULL AND published_at IS NULL`
+	args := []interface{}{}
+	if len(ids) > 0 {
+		query += ` AND id = ANY($1)`
+		args = append(args, ids)
+	}
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Prune menghapus event yang sudah terkirim sebelum waktu tertentu
+func (r *OutboxRepositoryPostgres) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result, err := executor(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM outbox WHERE published_at IS NOT NULL AND published_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// scanOutboxMessages membaca baris outbox dengan kolom id, topic, aggregate_id, event_key, payload,
+// created_at, attempts, next_attempt_at, last_error dan failed_at
+func scanOutboxMessages(rows *sql.Rows) ([]entity.OutboxMessage, error) {
+	var msgs []entity.OutboxMessage
+	for rows.Next() {
+		var (
+			msg     entity.OutboxMessage
+			payload []byte
+		)
+		err := rows.Scan(&msg.ID, &msg.Topic, &msg.AggregateID, &msg.Key, &payload,
+			&msg.CreatedAt, &msg.Attempts, &msg.NextAttemptAt, &msg.LastError, &msg.FailedAt)
+		if err != nil {
+			return nil, err
+		}
+		msg.Payload = payload
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang-crud-clean-arch/internal/entity"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// keysetCursor adalah isi cursor untuk PostgreSQL (keyset pada created_at, id)
+type keysetCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// objectIDCursor adalah isi cursor untuk MongoDB (range pada _id)
+type objectIDCursor struct {
+	ID string `json:"id"`
+}
+
+// encodeCursor mengubah posisi terakhir sebuah halaman menjadi cursor opaque (base64 JSON)
+func encodeCursor(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor membaca cursor opaque ke dalam v
+func decodeCursor(cursor string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("%w: %v", entity.ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", entity.ErrInvalidCursor, err)
+	}
+	return nil
+}
+
+// pageCacheField membuat field hash Redis untuk satu halaman listing,
+// sehingga invalidasi "users:all" / "repositories:all" menghapus semua halaman sekaligus
+func pageCacheField(page entity.PageRequest, filter fmt.Stringer) string {
+	return fmt.Sprintf("limit=%d&cursor=%s&total=%t&%s", page.Limit, page.Cursor, page.WithTotal, filter)
+}
+
+// keysetPageQuery menambahkan kondisi keyset (created_at, id) dan ORDER BY/LIMIT ke query PostgreSQL.
+// LIMIT diambil limit+1 untuk mengetahui apakah masih ada halaman berikutnya.
+func keysetPageQuery(query string, b *whereBuilder, page entity.PageRequest) (string, []interface{}, error) {
+	if page.Cursor != "" {
+		var cursor keysetCursor
+		if err := decodeCursor(page.Cursor, &cursor); err != nil {
+			return "", nil, err
+		}
+		if _, err := uuid.Parse(cursor.ID); err != nil {
+			return "", nil, fmt.Errorf("%w: %v", entity.ErrInvalidCursor, err)
+		}
+		b.add(fmt.Sprintf("(created_at, id) > (%s, %s)", b.arg(cursor.CreatedAt), b.arg(cursor.ID)))
+	}
+	query += b.sql() + fmt.Sprintf(` ORDER BY created_at, id LIMIT %d`, page.Limit+1)
+	return query, b.args, nil
+}
+
+// nextKeysetCursor membuat cursor halaman berikutnya dari item terakhir
+func nextKeysetCursor(createdAt time.Time, id interface{}) string {
+	return encodeCursor(keysetCursor{CreatedAt: createdAt, ID: fmt.Sprintf("%v", id)})
+}
+
+// objectIDPageQuery menambahkan range _id ke kondisi filter dan membuat opsi find (sort _id, limit+1) untuk satu halaman MongoDB
+func objectIDPageQuery(conds []bson.M, page entity.PageRequest) (bson.M, *options.FindOptions, error) {
+	if page.Cursor != "" {
+		var cursor objectIDCursor
+		if err := decodeCursor(page.Cursor, &cursor); err != nil {
+			return nil, nil, err
+		}
+		oid, err := primitive.ObjectIDFromHex(cursor.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", entity.ErrInvalidCursor, err)
+		}
+		conds = append(conds, bson.M{"_id": bson.M{"$gt": oid}})
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(page.Limit + 1))
+	return andFilterMongo(conds), opts, nil
+}
+
+// nextObjectIDCursor membuat cursor halaman berikutnya dari _id item terakhir
+func nextObjectIDCursor(lastID interface{}) string {
+	oid, ok := objectIDOrRaw(lastID).(primitive.ObjectID)
+	if !ok {
+		return ""
+	}
+	return encodeCursor(objectIDCursor{ID: oid.Hex()})
+}
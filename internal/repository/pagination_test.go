@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang-crud-clean-arch/internal/entity"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	oid := primitive.NewObjectID()
+
+	tests := []struct {
+		name string
+		in   interface{}
+		out  interface{}
+	}{
+		{
+			name: "keyset",
+			in:   &keysetCursor{CreatedAt: createdAt, ID: "0b6f3c1e-8f4a-4d4e-9a57-3f2f8c0f1a11"},
+			out:  &keysetCursor{},
+		},
+		{
+			name: "object id",
+			in:   &objectIDCursor{ID: oid.Hex()},
+			out:  &objectIDCursor{},
+		},
+		{
+			name: "sort",
+			in:   &sortCursor{Sort: "-name,created_at", Values: nil, ID: oid.Hex()},
+			out:  &sortCursor{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := encodeCursor(tt.in)
+			if raw == "" {
+				t.Fatal("encodeCursor returned an empty cursor")
+			}
+			if err := decodeCursor(raw, tt.out); err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if !reflect.DeepEqual(tt.in, tt.out) {
+				t.Fatalf("round trip = %+v, want %+v", tt.out, tt.in)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampered(t *testing.T) {
+	valid := encodeCursor(keysetCursor{CreatedAt: time.Now(), ID: uuid.NewString()})
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "%%%not-base64%%%"},
+		{name: "standard base64 padding", cursor: base64.StdEncoding.EncodeToString([]byte(`{"id":"x"}`))},
+		{name: "truncated", cursor: valid[:len(valid)/2]},
+		{name: "not json", cursor: base64.RawURLEncoding.EncodeToString([]byte("created_at=1"))},
+		{name: "wrong field type", cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"t":"yesterday","id":"x"}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cursor keysetCursor
+			if err := decodeCursor(tt.cursor, &cursor); !errors.Is(err, entity.ErrInvalidCursor) {
+				t.Fatalf("decodeCursor(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
+
+func TestKeysetPageQuery(t *testing.T) {
+	id := uuid.NewString()
+	createdAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		cursor    string
+		wantQuery string
+		wantArgs  int
+		wantErr   error
+	}{
+		{
+			name:      "first page",
+			wantQuery: "SELECT * FROM users WHERE deleted_at IS NULL ORDER BY created_at, id LIMIT 11",
+		},
+		{
+			name:      "next page",
+			cursor:    nextKeysetCursor(createdAt, id),
+			wantQuery: "SELECT * FROM users WHERE deleted_at IS NULL AND (created_at, id) > ($1, $2) ORDER BY created_at, id LIMIT 11",
+			wantArgs:  2,
+		},
+		{
+			name:    "id is not a uuid",
+			cursor:  encodeCursor(keysetCursor{CreatedAt: createdAt, ID: "1 OR 1=1"}),
+			wantErr: entity.ErrInvalidCursor,
+		},
+		{
+			name:    "garbage",
+			cursor:  "garbage!",
+			wantErr: entity.ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &whereBuilder{}
+			b.addDeletedScope(entity.DeletedExcluded)
+			query, args, err := keysetPageQuery("SELECT * FROM users", b, entity.PageRequest{Limit: 10, Cursor: tt.cursor})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if query != tt.wantQuery {
+				t.Fatalf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if len(args) != tt.wantArgs {
+				t.Fatalf("args = %v, want %d args", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestObjectIDPageQuery(t *testing.T) {
+	oid := primitive.NewObjectID()
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    bson.M
+		wantErr error
+	}{
+		{name: "first page", want: bson.M{}},
+		{
+			name:   "next page",
+			cursor: nextObjectIDCursor(oid),
+			want:   bson.M{"$and": []bson.M{{"_id": bson.M{"$gt": oid}}}},
+		},
+		{
+			name:    "id is not an object id",
+			cursor:  encodeCursor(objectIDCursor{ID: "zzzz"}),
+			wantErr: entity.ErrInvalidCursor,
+		},
+		{
+			name:    "garbage",
+			cursor:  "garbage!",
+			wantErr: entity.ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, opts, err := objectIDPageQuery(nil, entity.PageRequest{Limit: 10, Cursor: tt.cursor})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !reflect.DeepEqual(filter, tt.want) {
+				t.Fatalf("filter = %v, want %v", filter, tt.want)
+			}
+			if *opts.Limit != 11 {
+				t.Fatalf("limit = %d, want 11", *opts.Limit)
+			}
+		})
+	}
+}
+
+func TestDecodeSortCursor(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	last := &entity.Repository{ID: "42", Name: "api", UpdatedAt: updatedAt, AIEnabled: true}
+	sort := []entity.SortField{{Field: "name", Desc: true}, {Field: "updated_at"}, {Field: "ai_enabled"}}
+	valid := nextRepoCursor(sort, last)
+
+	tamper := func(edit func(c *sortCursor)) string {
+		var c sortCursor
+		if err := decodeCursor(valid, &c); err != nil {
+			t.Fatal(err)
+		}
+		edit(&c)
+		return encodeCursor(c)
+	}
+
+	tests := []struct {
+		name       string
+		cursor     string
+		sort       []entity.SortField
+		wantValues []interface{}
+		wantErr    error
+	}{
+		{
+			name:       "round trip",
+			cursor:     valid,
+			sort:       sort,
+			wantValues: []interface{}{"api", updatedAt, true},
+		},
+		{
+			name:    "different sort order",
+			cursor:  valid,
+			sort:    []entity.SortField{{Field: "name"}, {Field: "updated_at"}, {Field: "ai_enabled"}},
+			wantErr: entity.ErrInvalidCursor,
+		},
+		{
+			name:    "sort rewritten to match request",
+			cursor:  tamper(func(c *sortCursor) { c.Sort = "name" }),
+			sort:    []entity.SortField{{Field: "name"}},
+			wantErr: entity.ErrInvalidCursor,
+		},
+		{
+			name:    "value of the wrong type",
+			cursor:  tamper(func(c *sortCursor) { c.Values[1] = []byte(`"yesterday"`) }),
+			sort:    sort,
+			wantErr: entity.ErrInvalidCursor,
+		},
+		{
+			name:    "value dropped",
+			cursor:  tamper(func(c *sortCursor) { c.Values = c.Values[:2] }),
+			sort:    sort,
+			wantErr: entity.ErrInvalidCursor,
+		},
+		{
+			name:    "garbage",
+			cursor:  "garbage!",
+			sort:    sort,
+			wantErr: entity.ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, id, err := decodeSortCursor(tt.cursor, tt.sort)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if id != "42" {
+				t.Fatalf("id = %q, want 42", id)
+			}
+			if !reflect.DeepEqual(values, tt.wantValues) {
+				t.Fatalf("values = %#v, want %#v", values, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestRepoPageQueryPostgresRejectsTamperedID(t *testing.T) {
+	sort := []entity.SortField{{Field: "name"}}
+	cursor := nextRepoCursor(sort, &entity.Repository{ID: "1) OR (1=1", Name: "api"})
+
+	_, _, err := repoPageQueryPostgres("SELECT * FROM repositories", entity.RepositoryFilter{Sort: sort}, entity.PageRequest{Limit: 10, Cursor: cursor})
+	if !errors.Is(err, entity.ErrInvalidCursor) {
+		t.Fatalf("error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestRepoPageQueryMongoUsesStoredFieldNames(t *testing.T) {
+	oid := primitive.NewObjectID()
+	sort := []entity.SortField{{Field: "created_at", Desc: true}}
+	createdAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	cursor := nextRepoCursor(sort, &entity.Repository{ID: oid, CreatedAt: createdAt})
+
+	filter, opts, err := repoPageQueryMongo(entity.RepositoryFilter{Sort: sort}, entity.PageRequest{Limit: 10, Cursor: cursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := bson.M{"$and": []bson.M{{"deletedat": nil}, {"$or": []bson.M{
+		{"createdat": bson.M{"$lt": createdAt}},
+		{"createdat": createdAt, "id": bson.M{"$gt": oid}},
+	}}}}
+	if !reflect.DeepEqual(filter, want) {
+		t.Fatalf("filter = %v, want %v", filter, want)
+	}
+	wantSort := bson.D{{Key: "createdat", Value: -1}, {Key: "id", Value: 1}}
+	if !reflect.DeepEqual(opts.Sort, wantSort) {
+		t.Fatalf("sort = %v, want %v", opts.Sort, wantSort)
+	}
+
+	// id yang diubah di dalam cursor tidak boleh lolos ke filter
+	var c sortCursor
+	if err := decodeCursor(cursor, &c); err != nil {
+		t.Fatal(err)
+	}
+	c.ID = `{"$ne": null}`
+	tampered := encodeCursor(c)
+	if _, _, err := repoPageQueryMongo(entity.RepositoryFilter{Sort: sort}, entity.PageRequest{Limit: 10, Cursor: tampered}); !errors.Is(err, entity.ErrInvalidCursor) {
+		t.Fatalf("tampered cursor error = %v, want ErrInvalidCursor", err)
+	}
+}
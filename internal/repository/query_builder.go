@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"golang-crud-clean-arch/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// whereBuilder menyusun klausa WHERE PostgreSQL dengan placeholder $n berurutan
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+func (b *whereBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *whereBuilder) add(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+func (b *whereBuilder) sql() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// addDeletedScope menambahkan kondisi soft delete PostgreSQL sesuai scope
+func (b *whereBuilder) addDeletedScope(scope entity.DeletedScope) {
+	switch scope {
+	case entity.DeletedExcluded:
+		b.add("deleted_at IS NULL")
+	case entity.DeletedOnly:
+		b.add("deleted_at IS NOT NULL")
+	}
+}
+
+// mongoFields adalah nama field BSON untuk ID dan waktu soft delete pada satu koleksi MongoDB
+type mongoFields struct {
+	ID        string
+	DeletedAt string
+}
+
+// userMongoFields mengikuti tag bson pada entity.User
+var userMongoFields = mongoFields{ID: "_id", DeletedAt: "deleted_at"}
+
+// repoMongoFields mengikuti nama default driver untuk entity.Repository yang tidak punya tag bson
+// (nama field Go dalam huruf kecil), sama seperti dokumen yang sudah tersimpan di koleksi repo
+var repoMongoFields = mongoFields{ID: "id", DeletedAt: "deletedat"}
+
+// deletedScopeMongo mengembalikan kondisi soft delete MongoDB sesuai scope, atau nil untuk semua data.
+// {deleted_at: null} juga cocok untuk dokumen lama yang belum punya field deleted_at.
+func deletedScopeMongo(fields mongoFields, scope entity.DeletedScope) bson.M {
+	switch scope {
+	case entity.DeletedExcluded:
+		return bson.M{fields.DeletedAt: nil}
+	case entity.DeletedOnly:
+		return bson.M{fields.DeletedAt: bson.M{"$ne": nil}}
+	}
+	return nil
+}
+
+// andFilterMongo menggabungkan kondisi menjadi satu filter BSON
+func andFilterMongo(conds []bson.M) bson.M {
+	if len(conds) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conds}
+}
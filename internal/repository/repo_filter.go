@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang-crud-clean-arch/internal/entity"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// repoSortColumn menjelaskan cara membaca nilai field sort dari entity dan men-decode-nya kembali dari cursor.
+// Nama field sama dengan nama kolom PostgreSQL; bson adalah nama field di koleksi repo MongoDB.
+type repoSortColumn struct {
+	bson   string
+	value  func(repo *entity.Repository) interface{}
+	decode func(raw json.RawMessage) (interface{}, error)
+}
+
+var repoSortColumns = map[string]repoSortColumn{
+	"name": {
+		bson:   "name",
+		value:  func(repo *entity.Repository) interface{} { return repo.Name },
+		decode: decodeStringValue,
+	},
+	"created_at": {
+		bson:   "createdat",
+		value:  func(repo *entity.Repository) interface{} { return repo.CreatedAt },
+		decode: decodeTimeValue,
+	},
+	"updated_at": {
+		bson:   "updatedat",
+		value:  func(repo *entity.Repository) interface{} { return repo.UpdatedAt },
+		decode: decodeTimeValue,
+	},
+	"ai_enabled": {
+		bson:   "aienabled",
+		value:  func(repo *entity.Repository) interface{} { return repo.AIEnabled },
+		decode: decodeBoolValue,
+	},
+}
+
+// pgDefaultRepoSort mempertahankan urutan keyset (created_at, id) sebagai default di PostgreSQL
+var pgDefaultRepoSort = []entity.SortField{{Field: "created_at"}}
+
+// sortCursor adalah posisi item terakhir untuk keyset pagination dengan sort bebas.
+// Sort disimpan agar cursor dari urutan lain ditolak.
+type sortCursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	ID     string            `json:"id"`
+}
+
+// repoWherePostgres menerjemahkan filter (tanpa sort/cursor) ke kondisi WHERE untuk tabel repositories
+func repoWherePostgres(filter entity.RepositoryFilter) (*whereBuilder, error) {
+	b := &whereBuilder{}
+	b.addDeletedScope(filter.Deleted)
+
+	if filter.UserID != "" {
+		userID, err := uuid.Parse(filter.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: user_id must be a UUID", entity.ErrInvalidFilter)
+		}
+		b.add("user_id = " + b.arg(userID))
+	}
+	if filter.URL != "" {
+		b.add("url = " + b.arg(filter.URL))
+	}
+	if filter.AIEnabled != nil {
+		b.add("ai_enabled = " + b.arg(*filter.AIEnabled))
+	}
+	if filter.NamePrefix != "" {
+		b.add("name ILIKE " + b.arg(escapeLike(filter.NamePrefix)+"%"))
+	}
+	if filter.NameContains != "" {
+		b.add("name ILIKE " + b.arg("%"+escapeLike(filter.NameContains)+"%"))
+	}
+	addTimeRange := func(column string, from, to *time.Time) {
+		if from != nil {
+			b.add(column + " >= " + b.arg(from.UTC()))
+		}
+		if to != nil {
+			b.add(column + " < " + b.arg(to.UTC()))
+		}
+	}
+	addTimeRange("created_at", filter.CreatedFrom, filter.CreatedTo)
+	addTimeRange("updated_at", filter.UpdatedFrom, filter.UpdatedTo)
+	return b, nil
+}
+
+// repoPageQueryPostgres menerjemahkan filter, sort dan cursor ke SQL untuk tabel repositories
+func repoPageQueryPostgres(query string, filter entity.RepositoryFilter, page entity.PageRequest) (string, []interface{}, error) {
+	b, err := repoWherePostgres(filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sort := filter.Sort
+	if len(sort) == 0 {
+		sort = pgDefaultRepoSort
+	}
+
+	if page.Cursor != "" {
+		values, id, err := decodeSortCursor(page.Cursor, sort)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return "", nil, fmt.Errorf("%w: %v", entity.ErrInvalidCursor, err)
+		}
+
+		// (a > va) OR (a = va AND b > vb) OR ... OR (a = va AND b = vb AND id > vid)
+		var ors []string
+		for i := 0; i <= len(sort); i++ {
+			var ands []string
+			for j := 0; j < i; j++ {
+				ands = append(ands, fmt.Sprintf("%s = %s", sort[j].Field, b.arg(values[j])))
+			}
+			if i < len(sort) {
+				ands = append(ands, fmt.Sprintf("%s %s %s", sort[i].Field, sortOperator(sort[i], ">", "<"), b.arg(values[i])))
+			} else {
+				ands = append(ands, "id > "+b.arg(id))
+			}
+			ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		}
+		b.add("(" + strings.Join(ors, " OR ") + ")")
+	}
+
+	orderBy := make([]string, 0, len(sort)+1)
+	for _, sf := range sort {
+		orderBy = append(orderBy, sf.Field+sortOperator(sf, "", " DESC"))
+	}
+	orderBy = append(orderBy, "id")
+
+	query += b.sql() + " ORDER BY " + strings.Join(orderBy, ", ") + fmt.Sprintf(" LIMIT %d", page.Limit+1)
+	return query, b.args, nil
+}
+
+// repoConditionsMongo menerjemahkan filter (tanpa sort/cursor) ke kondisi BSON untuk koleksi repo
+func repoConditionsMongo(filter entity.RepositoryFilter) []bson.M {
+	var conds []bson.M
+	if cond := deletedScopeMongo(repoMongoFields, filter.Deleted); cond != nil {
+		conds = append(conds, cond)
+	}
+
+	if filter.UserID != "" {
+		conds = append(conds, bson.M{"userid": filter.UserID})
+	}
+	if filter.URL != "" {
+		conds = append(conds, bson.M{"url": filter.URL})
+	}
+	if filter.AIEnabled != nil {
+		conds = append(conds, bson.M{"aienabled": *filter.AIEnabled})
+	}
+	if filter.NamePrefix != "" {
+		conds = append(conds, bson.M{"name": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.NamePrefix), Options: "i"}})
+	}
+	if filter.NameContains != "" {
+		conds = append(conds, bson.M{"name": primitive.Regex{Pattern: regexp.QuoteMeta(filter.NameContains), Options: "i"}})
+	}
+	addTimeRange := func(field string, from, to *time.Time) {
+		if from != nil {
+			conds = append(conds, bson.M{field: bson.M{"$gte": from.UTC()}})
+		}
+		if to != nil {
+			conds = append(conds, bson.M{field: bson.M{"$lt": to.UTC()}})
+		}
+	}
+	addTimeRange("createdat", filter.CreatedFrom, filter.CreatedTo)
+	addTimeRange("updatedat", filter.UpdatedFrom, filter.UpdatedTo)
+	return conds
+}
+
+// repoPageQueryMongo menerjemahkan filter, sort dan cursor ke filter BSON dan opsi find untuk koleksi repo.
+// Tanpa ?sort= urutan default adalah id (range id seperti listing user).
+func repoPageQueryMongo(filter entity.RepositoryFilter, page entity.PageRequest) (bson.M, *options.FindOptions, error) {
+	conds := repoConditionsMongo(filter)
+
+	sort := filter.Sort
+	if page.Cursor != "" {
+		values, id, err := decodeSortCursor(page.Cursor, sort)
+		if err != nil {
+			return nil, nil, err
+		}
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", entity.ErrInvalidCursor, err)
+		}
+
+		var ors []bson.M
+		for i := 0; i <= len(sort); i++ {
+			cond := bson.M{}
+			for j := 0; j < i; j++ {
+				cond[repoSortColumns[sort[j].Field].bson] = values[j]
+			}
+			if i < len(sort) {
+				cond[repoSortColumns[sort[i].Field].bson] = bson.M{sortOperator(sort[i], "$gt", "$lt"): values[i]}
+			} else {
+				cond[repoMongoFields.ID] = bson.M{"$gt": oid}
+			}
+			ors = append(ors, cond)
+		}
+		conds = append(conds, bson.M{"$or": ors})
+	}
+
+	sortDoc := bson.D{}
+	for _, sf := range sort {
+		sortDoc = append(sortDoc, bson.E{Key: repoSortColumns[sf.Field].bson, Value: sortOperator(sf, 1, -1)})
+	}
+	sortDoc = append(sortDoc, bson.E{Key: repoMongoFields.ID, Value: 1})
+
+	opts := options.Find().
+		SetSort(sortDoc).
+		SetLimit(int64(page.Limit + 1))
+	return andFilterMongo(conds), opts, nil
+}
+
+// nextRepoCursor membuat cursor halaman berikutnya dari repository terakhir sesuai urutan sort
+func nextRepoCursor(sort []entity.SortField, last *entity.Repository) string {
+	values := make([]json.RawMessage, len(sort))
+	for i, sf := range sort {
+		data, err := json.Marshal(repoSortColumns[sf.Field].value(last))
+		if err != nil {
+			return ""
+		}
+		values[i] = data
+	}
+	return encodeCursor(sortCursor{Sort: entity.SortString(sort), Values: values, ID: idString(last.ID)})
+}
+
+// decodeSortCursor membaca cursor dan memastikan cursor dibuat dengan urutan sort yang sama
+func decodeSortCursor(raw string, sort []entity.SortField) ([]interface{}, string, error) {
+	var cursor sortCursor
+	if err := decodeCursor(raw, &cursor); err != nil {
+		return nil, "", err
+	}
+	if cursor.Sort != entity.SortString(sort) || len(cursor.Values) != len(sort) {
+		return nil, "", fmt.Errorf("%w: cursor was created with a different sort", entity.ErrInvalidCursor)
+	}
+
+	values := make([]interface{}, len(sort))
+	for i, sf := range sort {
+		column, ok := repoSortColumns[sf.Field]
+		if !ok {
+			return nil, "", fmt.Errorf("%w: unknown sort field %q", entity.ErrInvalidFilter, sf.Field)
+		}
+		v, err := column.decode(cursor.Values[i])
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", entity.ErrInvalidCursor, err)
+		}
+		values[i] = v
+	}
+	return values, cursor.ID, nil
+}
+
+// sortOperator memilih nilai berdasarkan arah sort (asc, desc)
+func sortOperator[T any](sf entity.SortField, asc, desc T) T {
+	if sf.Desc {
+		return desc
+	}
+	return asc
+}
+
+// escapeLike meng-escape karakter wildcard LIKE (\, %, _)
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// idString mengubah ID (uuid.UUID, ObjectID, atau string) menjadi string
+func idString(id interface{}) string {
+	if oid, ok := id.(primitive.ObjectID); ok {
+		return oid.Hex()
+	}
+	return fmt.Sprintf("%v", id)
+}
+
+func decodeStringValue(raw json.RawMessage) (interface{}, error) {
+	var v string
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+func decodeTimeValue(raw json.RawMessage) (interface{}, error) {
+	var v time.Time
+	err := json.Unmarshal(raw, &v)
+	return v.UTC(), err
+}
+
+func decodeBoolValue(raw json.RawMessage) (interface{}, error) {
+	var v bool
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
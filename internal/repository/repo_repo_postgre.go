@@ -7,21 +7,21 @@ import (
 	"fmt"
 	"time"
 
+	"golang-crud-clean-arch/internal/cache"
 	"golang-crud-clean-arch/internal/entity"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 )
 
 // RepoRepositoryPostgres adalah struct untuk meng-handle operasi data repository ke PostgreSQL dan Redis
 type RepoRepositoryPostgres struct {
 	db    *sql.DB
-	redis *redis.Client
+	cache *cache.Cache
 }
 
 // NewRepoRepositoryPostgres membuat instance baru dari RepoRepositoryPostgres
-func NewRepoRepositoryPostgres(db *sql.DB, redis *redis.Client) *RepoRepositoryPostgres {
-	return &RepoRepositoryPostgres{db: db, redis: redis}
+func NewRepoRepositoryPostgres(db *sql.DB, cache *cache.Cache) *RepoRepositoryPostgres {
+	return &RepoRepositoryPostgres{db: db, cache: cache}
 }
 
 // Helper: parse interface{} ke uuid.UUID
@@ -36,48 +36,85 @@ func parseUserIDAsUUID(raw interface{}) (uuid.UUID, error) {
 	}
 }
 
+// restoreRepoUUIDs mengembalikan ID dan UserID ke uuid.UUID setelah dibaca dari cache (JSON menyimpannya sebagai string)
+func restoreRepoUUIDs(repo *entity.Repository) {
+	if id, err := parseUserIDAsUUID(repo.ID); err == nil {
+		repo.ID = id
+	}
+	if userID, err := parseUserIDAsUUID(repo.UserID); err == nil {
+		repo.UserID = userID
+	}
+}
+
 // Create menambahkan data repository baru ke PostgreSQL
 func (r *RepoRepositoryPostgres) Create(ctx context.Context, repo *entity.Repository) error {
-	id := uuid.New()
-	repo.ID = id
-	repo.CreatedAt = time.Now()
-	repo.UpdatedAt = time.Now()
+	if err := r.insertRepo(ctx, executor(ctx, r.db), repo); err != nil {
+		return err
+	}
 
+	invalidateAfterCommit(ctx, r.cache, "repositories:all")
+	fmt.Println("✅ Repository created successfully.")
+	return nil
+}
+
+// insertRepo menyimpan repository baru lewat q (koneksi atau transaksi) tanpa menyentuh cache
+func (r *RepoRepositoryPostgres) insertRepo(ctx context.Context, q sqlExecutor, repo *entity.Repository) error {
 	// Konversi UserID ke uuid.UUID
 	userID, err := parseUserIDAsUUID(repo.UserID)
 	if err != nil {
 		return fmt.Errorf("UserID parse error: %w", err)
 	}
 
-	query := `INSERT INTO repositories (id, user_id, name, url, ai_enabled, created_at, updated_at)
-			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	id := uuid.New()
+	repo.ID = id
+	repo.CreatedAt = time.Now()
+	repo.UpdatedAt = time.Now()
+	repo.Version = 1
+
+	query := `INSERT INTO repositories (id, user_id, name, url, ai_enabled, created_at, updated_at, version)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
-	_, err = r.db.ExecContext(ctx, query,
-		id, userID, repo.Name, repo.URL, repo.AIEnabled, repo.CreatedAt, repo.UpdatedAt,
+	_, err = q.ExecContext(ctx, query,
+		id, userID, repo.Name, repo.URL, repo.AIEnabled, repo.CreatedAt, repo.UpdatedAt, repo.Version,
 	)
-	if err == nil {
-		r.redis.Del(ctx, "repositories:all")
-		fmt.Println("✅ Repository created successfully.")
-	}
 	return err
 }
 
-// GetAllRepositories mengambil semua repository dari PostgreSQL
-func (r *RepoRepositoryPostgres) GetAllRepositories(ctx context.Context) ([]entity.Repository, error) {
-	query := `SELECT id, user_id, name, url, ai_enabled, created_at, updated_at FROM repositories`
-	rows, err := r.db.QueryContext(ctx, query)
+// GetAllRepositories mengambil repository dari PostgreSQL per halaman dengan filter dan sort.
+// Tanpa ?sort= urutan default adalah keyset (created_at, id).
+func (r *RepoRepositoryPostgres) GetAllRepositories(ctx context.Context, filter entity.RepositoryFilter, page entity.PageRequest) (*entity.RepositoryPage, error) {
+	page = page.Normalize()
+	cacheField := pageCacheField(page, filter)
+
+	// Cek cache Redis terlebih dahulu
+	var result entity.RepositoryPage
+	if r.cache.GetField(ctx, "repositories:all", cacheField, &result) {
+		for i := range result.Data {
+			restoreRepoUUIDs(&result.Data[i])
+		}
+		fmt.Println("✅ Repositories page retrieved from cache.")
+		return &result, nil
+	}
+
+	query := `SELECT id, user_id, name, url, ai_enabled, created_at, updated_at, version, deleted_at FROM repositories`
+	query, args, err := repoPageQueryPostgres(query, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var repos []entity.Repository
+	repos := []entity.Repository{}
 	for rows.Next() {
 		var (
 			id, userID uuid.UUID
 			repo       entity.Repository
 		)
-		err := rows.Scan(&id, &userID, &repo.Name, &repo.URL, &repo.AIEnabled, &repo.CreatedAt, &repo.UpdatedAt)
+		err := rows.Scan(&id, &userID, &repo.Name, &repo.URL, &repo.AIEnabled, &repo.CreatedAt, &repo.UpdatedAt, &repo.Version, &repo.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -85,8 +122,37 @@ func (r *RepoRepositoryPostgres) GetAllRepositories(ctx context.Context) ([]enti
 		repo.UserID = userID
 		repos = append(repos, repo)
 	}
-	fmt.Println("✅ Repositories retrieved successfully.")
-	return repos, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(repos) > page.Limit {
+		repos = repos[:page.Limit]
+		sort := filter.Sort
+		if len(sort) == 0 {
+			sort = pgDefaultRepoSort
+		}
+		result.NextCursor = nextRepoCursor(sort, &repos[len(repos)-1])
+	}
+	result.Data = repos
+
+	if page.WithTotal {
+		// Total dihitung dengan filter yang sama, tanpa cursor
+		where, err := repoWherePostgres(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		var total int64
+		if err := executor(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM repositories`+where.sql(), where.args...).Scan(&total); err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	r.cache.SetField(ctx, "repositories:all", cacheField, result, r.cache.ListTTL())
+	fmt.Println("✅ Repositories page retrieved successfully.")
+	return &result, nil
 }
 
 // GetByID mengambil repository berdasarkan ID dari PostgreSQL
@@ -96,7 +162,8 @@ func (r *RepoRepositoryPostgres) GetByID(ctx context.Context, id interface{}) (*
 	case string:
 		parsed, err := uuid.Parse(v)
 		if err != nil {
-			return nil, err
+			// id yang bukan UUID tidak mungkin ada
+			return nil, fmt.Errorf("%w: %v", entity.ErrNotFound, err)
 		}
 		uuidID = parsed
 	case uuid.UUID:
@@ -105,70 +172,181 @@ func (r *RepoRepositoryPostgres) GetByID(ctx context.Context, id interface{}) (*
 		return nil, errors.New("invalid ID type for GetByID")
 	}
 
-	query := `SELECT id, user_id, name, url, ai_enabled, created_at, updated_at FROM repositories WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, uuidID)
-
 	var (
 		repo   entity.Repository
 		userID uuid.UUID
 	)
-	err := row.Scan(&uuidID, &userID, &repo.Name, &repo.URL, &repo.AIEnabled, &repo.CreatedAt, &repo.UpdatedAt)
+
+	// Cek cache Redis terlebih dahulu
+	cacheKey := fmt.Sprintf("repositories:%v", uuidID)
+	// Di dalam transaksi cache dilewati agar data yang belum di-commit tidak ikut tersimpan
+	if !inTx(ctx) && r.cache.Get(ctx, cacheKey, &repo) {
+		restoreRepoUUIDs(&repo)
+		fmt.Println("✅ Repository retrieved from cache.")
+		return &repo, nil
+	}
+
+	query := `SELECT id, user_id, name, url, ai_enabled, created_at, updated_at, version, deleted_at FROM repositories WHERE id = $1 AND deleted_at IS NULL`
+	row := executor(ctx, r.db).QueryRowContext(ctx, query, uuidID)
+
+	err := row.Scan(&uuidID, &userID, &repo.Name, &repo.URL, &repo.AIEnabled, &repo.CreatedAt, &repo.UpdatedAt, &repo.Version, &repo.DeletedAt)
 	if err != nil {
 		return nil, err
 	}
 
 	repo.ID = uuidID
 	repo.UserID = userID
+	if !inTx(ctx) {
+		r.cache.Set(ctx, cacheKey, repo, r.cache.ItemTTL())
+	}
 	fmt.Println("✅ Repository retrieved successfully.")
 	return &repo, nil
 }
 
-// Update memperbarui data repository di PostgreSQL
+// Update memperbarui data repository di PostgreSQL.
+// Jika repo.Version > 0, update hanya berhasil bila versi di database sama (optimistic concurrency).
 func (r *RepoRepositoryPostgres) Update(ctx context.Context, repo *entity.Repository) error {
-	uuidID, ok := repo.ID.(uuid.UUID)
-	if !ok {
+	if err := r.updateRepo(ctx, executor(ctx, r.db), repo); err != nil {
+		return err
+	}
+
+	invalidateAfterCommit(ctx, r.cache, "repositories:all", fmt.Sprintf("repositories:%v", repo.ID))
+	fmt.Println("✅ Repository updated successfully.")
+	return nil
+}
+
+// updateRepo menjalankan UPDATE bersyarat versi lewat q (koneksi atau transaksi) tanpa menyentuh cache
+func (r *RepoRepositoryPostgres) updateRepo(ctx context.Context, q sqlExecutor, repo *entity.Repository) error {
+	uuidID, err := parseUserIDAsUUID(repo.ID)
+	if err != nil {
 		return errors.New("invalid ID type (expected uuid.UUID)")
 	}
+	repo.ID = uuidID
 	repo.UpdatedAt = time.Now()
 
-	query := `UPDATE repositories SET name = $1, url = $2, ai_enabled = $3, updated_at = $4 WHERE id = $5`
-	_, err := r.db.ExecContext(ctx, query,
-		repo.Name, repo.URL, repo.AIEnabled, repo.UpdatedAt, uuidID,
-	)
-
-	if err == nil {
-		r.redis.Del(ctx, "repositories:all", fmt.Sprintf("repositories:%v", uuidID))
-		fmt.Println("✅ Repository updated successfully.")
+	query := `UPDATE repositories SET name = $1, url = $2, ai_enabled = $3, updated_at = $4, version = version + 1
+			  WHERE id = $5 AND deleted_at IS NULL AND ($6::bigint = 0 OR version = $6::bigint)
+			  RETURNING version`
+	err = q.QueryRowContext(ctx, query,
+		repo.Name, repo.URL, repo.AIEnabled, repo.UpdatedAt, uuidID, repo.Version,
+	).Scan(&repo.Version)
+	if err == sql.ErrNoRows {
+		return versionMissPostgres(ctx, q, "repositories", uuidID)
 	}
 	return err
 }
 
-// Delete menghapus data repository dari PostgreSQL berdasarkan ID
-func (r *RepoRepositoryPostgres) Delete(ctx context.Context, id interface{}) error {
-	var uuidID uuid.UUID
-	switch v := id.(type) {
-	case string:
-		parsed, err := uuid.Parse(v)
-		if err != nil {
+// Delete melakukan soft delete repository (mengisi deleted_at) berdasarkan ID.
+// Jika expectedVersion > 0, delete hanya berhasil bila versi di database sama.
+func (r *RepoRepositoryPostgres) Delete(ctx context.Context, id interface{}, expectedVersion int64) error {
+	uuidID, err := r.softDeleteRepo(ctx, executor(ctx, r.db), id, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	invalidateAfterCommit(ctx, r.cache, "repositories:all", fmt.Sprintf("repositories:%v", uuidID))
+	fmt.Println("✅ Repository soft-deleted successfully.")
+	return nil
+}
+
+// softDeleteRepo mengisi deleted_at lewat q (koneksi atau transaksi) tanpa menyentuh cache.
+// Repository yang tidak ada menghasilkan ErrNotFound, versi berbeda menghasilkan ErrVersionConflict.
+func (r *RepoRepositoryPostgres) softDeleteRepo(ctx context.Context, q sqlExecutor, id interface{}, expectedVersion int64) (uuid.UUID, error) {
+	uuidID, err := parseUserIDAsUUID(id)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid ID type for Delete")
+	}
+
+	query := `UPDATE repositories SET deleted_at = $1, version = version + 1
+			  WHERE id = $2 AND deleted_at IS NULL AND ($3::bigint = 0 OR version = $3::bigint)`
+	result, err := q.ExecContext(ctx, query, time.Now(), uuidID, expectedVersion)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return uuid.Nil, versionMissPostgres(ctx, q, "repositories", uuidID)
+	}
+	return uuidID, nil
+}
+
+// ApplyBatch menjalankan operasi create/update/delete dalam satu transaksi dan mengembalikan error per operasi.
+// Pada mode atomic, satu kegagalan membuat tidak ada operasi yang tersimpan.
+func (r *RepoRepositoryPostgres) ApplyBatch(ctx context.Context, ops []entity.BatchOperation[entity.Repository], atomic bool) ([]error, error) {
+	cacheKeys := []string{"repositories:all"}
+	errs, err := runBatchPostgres(ctx, r.db, len(ops), atomic, func(q sqlExecutor, i int) error {
+		op := ops[i]
+		switch op.Op {
+		case entity.BatchCreate:
+			return r.insertRepo(ctx, q, op.Data)
+		case entity.BatchUpdate:
+			op.Data.ID = op.ID
+			op.Data.Version = op.Version
+			err := r.updateRepo(ctx, q, op.Data)
+			if err == nil {
+				cacheKeys = append(cacheKeys, fmt.Sprintf("repositories:%v", op.Data.ID))
+			}
 			return err
+		case entity.BatchDelete:
+			uuidID, err := r.softDeleteRepo(ctx, q, op.ID, op.Version)
+			if err == nil {
+				cacheKeys = append(cacheKeys, fmt.Sprintf("repositories:%v", uuidID))
+			}
+			return err
+		default:
+			return fmt.Errorf("unknown batch op %q", op.Op)
 		}
-		uuidID = parsed
-	case uuid.UUID:
-		uuidID = v
-	default:
-		return errors.New("invalid ID type for Delete")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !atomic || !batchFailed(errs) {
+		invalidateAfterCommit(ctx, r.cache, cacheKeys...)
+		fmt.Printf("✅ Repository batch applied (%d operations).\n", len(ops))
+	}
+	return errs, nil
+}
+
+// Restore mengembalikan repository yang sudah di-soft-delete
+func (r *RepoRepositoryPostgres) Restore(ctx context.Context, id interface{}) (*entity.Repository, error) {
+	uuidID, err := parseUserIDAsUUID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	query := `DELETE FROM repositories WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query, uuidID)
+	query := `UPDATE repositories SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL`
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, time.Now(), uuidID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tidak ada baris berarti repository tidak ada atau tidak sedang terhapus
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	invalidateAfterCommit(ctx, r.cache, "repositories:all", fmt.Sprintf("repositories:%v", uuidID))
+	fmt.Println("✅ Repository restored successfully.")
+	return r.GetByID(ctx, uuidID)
+}
+
+// Purge menghapus repository secara permanen (hard delete), termasuk yang sudah di-soft-delete
+func (r *RepoRepositoryPostgres) Purge(ctx context.Context, id interface{}) error {
+	uuidID, err := parseUserIDAsUUID(id)
 	if err != nil {
 		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected > 0 {
-		r.redis.Del(ctx, "repositories:all", fmt.Sprintf("repositories:%v", uuidID))
-		fmt.Println("✅ Repository deleted successfully.")
+	result, err := executor(ctx, r.db).ExecContext(ctx, `DELETE FROM repositories WHERE id = $1`, uuidID)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return sql.ErrNoRows
 	}
+
+	invalidateAfterCommit(ctx, r.cache, "repositories:all", fmt.Sprintf("repositories:%v", uuidID))
+	fmt.Println("✅ Repository purged successfully.")
 	return nil
 }
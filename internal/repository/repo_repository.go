@@ -4,25 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"golang-crud-clean-arch/internal/cache"
 	"golang-crud-clean-arch/internal/entity"
 
-	"github.com/go-redis/redis/v8"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // RepoRepository adalah struct untuk meng-handle operasi data repository (repo) ke MongoDB dan Redis
 type RepoRepository struct {
 	db     *mongo.Client // koneksi MongoDB
-	redis  *redis.Client // koneksi Redis
+	cache  *cache.Cache  // read-through cache Redis (namespace "mongo")
 	dbName string        // nama database
 }
 
 // NewRepoRepository membuat instance baru dari RepoRepository
-func NewRepoRepository(db *mongo.Client, redis *redis.Client, dbName string) *RepoRepository {
-	return &RepoRepository{db, redis, dbName}
+func NewRepoRepository(db *mongo.Client, cache *cache.Cache, dbName string) *RepoRepository {
+	return &RepoRepository{db, cache, dbName}
 }
 
 // Create menambahkan data repository baru ke MongoDB
@@ -32,105 +34,284 @@ func (r *RepoRepository) Create(ctx context.Context, repo *entity.Repository) er
 	// Buat ID baru untuk repository
 	id := primitive.NewObjectID()
 	repo.ID = id
+	repo.CreatedAt = time.Now()
+	repo.UpdatedAt = time.Now()
+	repo.Version = 1
 
 	// Simpan ke database
 	_, err := collection.InsertOne(ctx, repo)
 	if err == nil {
 		// Hapus cache jika insert berhasil
-		r.redis.Del(ctx, "repositories:all")
+		r.cache.Del(ctx, "repositories:all")
 		fmt.Println("✅ Repository created successfully.")
 	}
 	return err
 }
 
-// GetAllRepositories mengambil seluruh data repository dari MongoDB
-func (r *RepoRepository) GetAllRepositories(ctx context.Context) ([]entity.Repository, error) {
+// GetAllRepositories mengambil data repository dari MongoDB per halaman dengan filter dan sort.
+// Tanpa ?sort= urutan default adalah range pada id.
+func (r *RepoRepository) GetAllRepositories(ctx context.Context, filter entity.RepositoryFilter, page entity.PageRequest) (*entity.RepositoryPage, error) {
+	page = page.Normalize()
+	cacheField := pageCacheField(page, filter)
+
+	// Cek cache Redis terlebih dahulu
+	var result entity.RepositoryPage
+	if r.cache.GetField(ctx, "repositories:all", cacheField, &result) {
+		for i := range result.Data {
+			result.Data[i].ID = objectIDOrRaw(result.Data[i].ID)
+		}
+		fmt.Println("✅ Repositories page retrieved from cache.")
+		return &result, nil
+	}
+
+	mongoFilter, opts, err := repoPageQueryMongo(filter, page)
+	if err != nil {
+		return nil, err
+	}
+
 	collection := r.db.Database(r.dbName).Collection("repo")
 
-	// Ambil semua dokumen dari koleksi repo
-	cursor, err := collection.Find(ctx, bson.M{})
+	// Ambil satu halaman dokumen dari koleksi repo
+	cursor, err := collection.Find(ctx, mongoFilter, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
 	// Decode hasil query ke slice entity.Repository
-	var repos []entity.Repository
+	repos := []entity.Repository{}
 	if err = cursor.All(ctx, &repos); err != nil {
 		return nil, err
 	}
 
-	fmt.Println("✅ All repositories retrieved successfully.")
-	return repos, nil
+	if len(repos) > page.Limit {
+		repos = repos[:page.Limit]
+		result.NextCursor = nextRepoCursor(filter.Sort, &repos[len(repos)-1])
+	}
+	result.Data = repos
+
+	if page.WithTotal {
+		total, err := collection.CountDocuments(ctx, andFilterMongo(repoConditionsMongo(filter)))
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	r.cache.SetField(ctx, "repositories:all", cacheField, result, r.cache.ListTTL())
+	fmt.Println("✅ Repositories page retrieved successfully.")
+	return &result, nil
 }
 
 // GetByID mengambil repository berdasarkan ID
 func (r *RepoRepository) GetByID(ctx context.Context, id interface{}) (*entity.Repository, error) {
 	// Konversi ID ke ObjectID
-	objectID, ok := id.(primitive.ObjectID)
+	objectID, ok := objectIDOrRaw(id).(primitive.ObjectID)
 	if !ok {
-		return nil, errors.New("invalid ID format, expected ObjectID")
+		// id yang bukan ObjectID tidak mungkin ada
+		return nil, fmt.Errorf("%w: invalid ID format, expected ObjectID", entity.ErrNotFound)
+	}
+
+	// Cek cache Redis terlebih dahulu
+	var repo entity.Repository
+	cacheKey := fmt.Sprintf("repositories:%s", objectID.Hex())
+	if r.cache.Get(ctx, cacheKey, &repo) {
+		repo.ID = objectID
+		fmt.Println("✅ Repository retrieved from cache.")
+		return &repo, nil
 	}
 
 	collection := r.db.Database(r.dbName).Collection("repo")
 
-	// Cari data repository berdasarkan ID
-	var repo entity.Repository
-	err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&repo)
+	// Cari data repository aktif (belum di-soft-delete) berdasarkan ID
+	err := collection.FindOne(ctx, bson.M{repoMongoFields.ID: objectID, repoMongoFields.DeletedAt: nil}).Decode(&repo)
 	if err != nil {
 		return nil, err
 	}
 
+	r.cache.Set(ctx, cacheKey, repo, r.cache.ItemTTL())
 	fmt.Println("✅ Repository retrieved successfully.")
 	return &repo, nil
 }
 
-// Update memperbarui data repository di MongoDB
+// Update memperbarui data repository di MongoDB.
+// Jika repo.Version > 0, update hanya berlaku bila versi tersimpan sama (optimistic concurrency).
 func (r *RepoRepository) Update(ctx context.Context, repo *entity.Repository) error {
 	// Validasi bahwa ID adalah ObjectID
-	objectID, ok := repo.ID.(primitive.ObjectID)
+	objectID, ok := objectIDOrRaw(repo.ID).(primitive.ObjectID)
 	if !ok {
 		return errors.New("invalid ID type (expected ObjectID)")
 	}
+	repo.ID = objectID
+	repo.UpdatedAt = time.Now()
 
 	collection := r.db.Database(r.dbName).Collection("repo")
 
 	// Siapkan field yang akan diupdate
 	update := bson.M{
 		"$set": bson.M{
-			"name":       repo.Name,
-			"url":        repo.URL,
-			"ai_enabled": repo.AIEnabled,
-			"user_id":    repo.UserID,
+			"name":      repo.Name,
+			"url":       repo.URL,
+			"aienabled": repo.AIEnabled,
+			"userid":    repo.UserID,
+			"updatedat": repo.UpdatedAt,
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
-	// Jalankan update
-	result, err := collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
-	if err == nil && result.MatchedCount > 0 {
-		// Hapus cache jika berhasil update
-		r.redis.Del(ctx, "repositories:all", fmt.Sprintf("repositories:%s", objectID.Hex()))
-		fmt.Println("✅ Repository updated successfully.")
+	// Jalankan update dan ambil versi barunya
+	filter := versionFilterMongo(bson.M{repoMongoFields.ID: objectID, repoMongoFields.DeletedAt: nil}, repo.Version)
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"version": 1})
+
+	var updated struct {
+		Version int64
 	}
-	return err
+	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return versionMissMongo(ctx, collection, repoMongoFields, objectID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Hapus cache jika berhasil update
+	repo.Version = updated.Version
+	r.cache.Del(ctx, "repositories:all", fmt.Sprintf("repositories:%s", objectID.Hex()))
+	fmt.Println("✅ Repository updated successfully.")
+	return nil
 }
 
-// Delete menghapus repository dari MongoDB berdasarkan ID
-func (r *RepoRepository) Delete(ctx context.Context, id interface{}) error {
+// Delete melakukan soft delete repository (mengisi deletedat) berdasarkan ID.
+// Jika expectedVersion > 0, delete hanya berlaku bila versi tersimpan sama.
+func (r *RepoRepository) Delete(ctx context.Context, id interface{}, expectedVersion int64) error {
 	// Validasi bahwa ID adalah ObjectID
-	objectID, ok := id.(primitive.ObjectID)
+	objectID, ok := objectIDOrRaw(id).(primitive.ObjectID)
 	if !ok {
 		return errors.New("invalid ID type for Delete (expected ObjectID)")
 	}
 
 	collection := r.db.Database(r.dbName).Collection("repo")
 
-	// Hapus dokumen berdasarkan ID
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
-	if err == nil && result.DeletedCount > 0 {
+	// Tandai dokumen sebagai terhapus
+	update := bson.M{"$set": bson.M{repoMongoFields.DeletedAt: time.Now()}, "$inc": bson.M{"version": 1}}
+	filter := versionFilterMongo(bson.M{repoMongoFields.ID: objectID, repoMongoFields.DeletedAt: nil}, expectedVersion)
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err == nil && result.MatchedCount == 0 {
+		// Tanpa If-Match, dokumen yang tidak ada atau sudah terhapus tetap 404 seperti di PostgreSQL
+		if expectedVersion > 0 {
+			return versionMissMongo(ctx, collection, repoMongoFields, objectID)
+		}
+		return entity.ErrNotFound
+	}
+	if err == nil && result.ModifiedCount > 0 {
 		// Hapus cache jika delete berhasil
-		r.redis.Del(ctx, "repositories:all", fmt.Sprintf("repositories:%s", objectID.Hex()))
-		fmt.Println("✅ Repository deleted successfully.")
+		r.cache.Del(ctx, "repositories:all", fmt.Sprintf("repositories:%s", objectID.Hex()))
+		fmt.Println("✅ Repository soft-deleted successfully.")
 	}
 	return err
 }
+
+// ApplyBatch menjalankan operasi create/update/delete dengan satu BulkWrite dan mengembalikan error per operasi
+func (r *RepoRepository) ApplyBatch(ctx context.Context, ops []entity.BatchOperation[entity.Repository], atomic bool) ([]error, error) {
+	now := time.Now()
+	collection := r.db.Database(r.dbName).Collection("repo")
+	errs, err := runBatchMongo(ctx, collection, repoMongoFields, ops, atomic, func(op entity.BatchOperation[entity.Repository], id primitive.ObjectID, current int64) mongo.WriteModel {
+		switch op.Op {
+		case entity.BatchCreate:
+			op.Data.ID = primitive.NewObjectID()
+			op.Data.CreatedAt = now
+			op.Data.UpdatedAt = now
+			op.Data.Version = 1
+			return mongo.NewInsertOneModel().SetDocument(op.Data)
+		case entity.BatchUpdate:
+			op.Data.ID = id
+			op.Data.UpdatedAt = now
+			op.Data.Version = current + 1
+			return mongo.NewUpdateOneModel().
+				SetFilter(versionFilterMongo(bson.M{repoMongoFields.ID: id, repoMongoFields.DeletedAt: nil}, current)).
+				SetUpdate(bson.M{
+					"$set": bson.M{
+						"name":      op.Data.Name,
+						"url":       op.Data.URL,
+						"aienabled": op.Data.AIEnabled,
+						"userid":    op.Data.UserID,
+						"updatedat": now,
+					},
+					"$inc": bson.M{"version": 1},
+				})
+		default:
+			return mongo.NewUpdateOneModel().
+				SetFilter(versionFilterMongo(bson.M{repoMongoFields.ID: id, repoMongoFields.DeletedAt: nil}, current)).
+				SetUpdate(bson.M{"$set": bson.M{repoMongoFields.DeletedAt: now}, "$inc": bson.M{"version": 1}})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Hapus cache untuk dokumen yang berubah
+	cacheKeys := []string{"repositories:all"}
+	for i, op := range ops {
+		if errs[i] == nil && op.Op != entity.BatchCreate {
+			cacheKeys = append(cacheKeys, fmt.Sprintf("repositories:%s", op.ID))
+		}
+	}
+	if !atomic || !batchFailed(errs) {
+		r.cache.Del(ctx, cacheKeys...)
+		fmt.Printf("✅ Repository batch applied (%d operations).\n", len(ops))
+	}
+	return errs, nil
+}
+
+// Restore mengembalikan repository yang sudah di-soft-delete
+func (r *RepoRepository) Restore(ctx context.Context, id interface{}) (*entity.Repository, error) {
+	objectID, ok := objectIDOrRaw(id).(primitive.ObjectID)
+	if !ok {
+		return nil, errors.New("invalid ID type for Restore (expected ObjectID)")
+	}
+
+	collection := r.db.Database(r.dbName).Collection("repo")
+
+	update := bson.M{
+		"$unset": bson.M{repoMongoFields.DeletedAt: ""},
+		"$set":   bson.M{"updatedat": time.Now()},
+		"$inc":   bson.M{"version": 1},
+	}
+	result, err := collection.UpdateOne(ctx, bson.M{repoMongoFields.ID: objectID, repoMongoFields.DeletedAt: bson.M{"$ne": nil}}, update)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tidak ada dokumen berarti repository tidak ada atau tidak sedang terhapus
+	if result.MatchedCount == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	r.cache.Del(ctx, "repositories:all", fmt.Sprintf("repositories:%s", objectID.Hex()))
+	fmt.Println("✅ Repository restored successfully.")
+	return r.GetByID(ctx, objectID)
+}
+
+// Purge menghapus repository secara permanen (hard delete), termasuk yang sudah di-soft-delete
+func (r *RepoRepository) Purge(ctx context.Context, id interface{}) error {
+	objectID, ok := objectIDOrRaw(id).(primitive.ObjectID)
+	if !ok {
+		return errors.New("invalid ID type for Purge (expected ObjectID)")
+	}
+
+	collection := r.db.Database(r.dbName).Collection("repo")
+
+	result, err := collection.DeleteOne(ctx, bson.M{repoMongoFields.ID: objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	r.cache.Del(ctx, "repositories:all", fmt.Sprintf("repositories:%s", objectID.Hex()))
+	fmt.Println("✅ Repository purged successfully.")
+	return nil
+}
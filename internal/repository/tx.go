@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"golang-crud-clean-arch/internal/cache"
+)
+
+// sqlExecutor dipenuhi oleh *sql.DB dan *sql.Tx, sehingga query yang sama
+// bisa dijalankan di dalam maupun di luar transaksi
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// txState adalah transaksi aktif yang dibawa lewat context beserta hook setelah commit
+type txState struct {
+	tx          *sql.Tx
+	afterCommit []func()
+}
+
+// PostgresTransactor menjalankan beberapa operasi repository PostgreSQL dalam satu transaksi.
+// Repository yang menerima ctx dari WithinTx otomatis memakai transaksi tersebut.
+type PostgresTransactor struct {
+	db *sql.DB
+}
+
+// NewPostgresTransactor membuat instance baru dari PostgresTransactor
+func NewPostgresTransactor(db *sql.DB) *PostgresTransactor {
+	return &PostgresTransactor{db: db}
+}
+
+// WithinTx menjalankan fn dalam satu transaksi: commit jika fn berhasil, rollback jika gagal.
+// Pemanggilan bertingkat memakai transaksi terluar.
+func (t *PostgresTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	state := &txState{tx: tx}
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// executor mengembalikan transaksi aktif di ctx, atau db jika tidak ada transaksi
+func executor(ctx context.Context, db *sql.DB) sqlExecutor {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
+
+// inTx melaporkan apakah ctx membawa transaksi aktif
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*txState)
+	return ok
+}
+
+// afterCommit menjalankan fn setelah transaksi di ctx di-commit, atau langsung jika tidak ada transaksi
+func afterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// invalidateAfterCommit menghapus key cache setelah commit agar pembaca lain
+// tidak mengisi ulang cache dengan data lama sebelum transaksi selesai
+func invalidateAfterCommit(ctx context.Context, c *cache.Cache, keys ...string) {
+	afterCommit(ctx, func() {
+		c.Del(context.WithoutCancel(ctx), keys...)
+	})
+}
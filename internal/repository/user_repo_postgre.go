@@ -7,26 +7,26 @@ import (
 	"fmt"
 	"time"
 
+	"golang-crud-clean-arch/internal/cache"
 	"golang-crud-clean-arch/internal/entity"
 	"golang-crud-clean-arch/internal/event"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 )
 
 // UserRepositoryPostgres adalah struct untuk meng-handle operasi data user ke PostgreSQL dan Redis
 type UserRepositoryPostgres struct {
 	db        *sql.DB              // koneksi ke database PostgreSQL
-	redis     *redis.Client        // koneksi ke Redis
+	cache     *cache.Cache         // read-through cache Redis (namespace "pg")
 	validate  *validator.Validate  // validasi data dengan go-playground/validator
 	publisher event.EventPublisher // publisher untuk mempublikasikan event
 }
 
-func NewUserRepositoryPostgres(db *sql.DB, redis *redis.Client, publisher event.EventPublisher) *UserRepositoryPostgres {
+func NewUserRepositoryPostgres(db *sql.DB, cache *cache.Cache, publisher event.EventPublisher) *UserRepositoryPostgres {
 	return &UserRepositoryPostgres{
 		db:        db,
-		redis:     redis,
+		cache:     cache,
 		validate:  validator.New(),
 		publisher: publisher,
 	}
@@ -34,6 +34,18 @@ func NewUserRepositoryPostgres(db *sql.DB, redis *redis.Client, publisher event.
 
 // Create menambahkan data user baru ke PostgreSQL
 func (r *UserRepositoryPostgres) Create(ctx context.Context, user *entity.User) error {
+	if err := r.insertUser(ctx, executor(ctx, r.db), user); err != nil {
+		return err
+	}
+
+	// Hapus cache Redis jika insert berhasil
+	invalidateAfterCommit(ctx, r.cache, "users:all")
+	fmt.Println("✅ User created successfully.")
+	return nil
+}
+
+// insertUser menyimpan user baru lewat q (koneksi atau transaksi) tanpa menyentuh cache
+func (r *UserRepositoryPostgres) insertUser(ctx context.Context, q sqlExecutor, user *entity.User) error {
 	// Validasi data user sebelum disimpan
 	if err := r.validate.Struct(user); err != nil {
 		return errors.New("validation failed: " + err.Error())
@@ -43,15 +55,11 @@ func (r *UserRepositoryPostgres) Create(ctx context.Context, user *entity.User)
 	user.ID = uuid.New()
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
+	user.Version = 1
 
 	// Query untuk menyimpan user ke database
-	query := `INSERT INTO users (id, name, email, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.ExecContext(ctx, query, user.ID, user.Name, user.Email, user.CreatedAt, user.UpdatedAt)
-	if err == nil {
-		// Hapus cache Redis jika insert berhasil
-		r.redis.Del(ctx, "users:all")
-		fmt.Println("✅ User created successfully.")
-	}
+	query := `INSERT INTO users (id, name, email, created_at, updated_at, version) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := q.ExecContext(ctx, query, user.ID, user.Name, user.Email, user.CreatedAt, user.UpdatedAt, user.Version)
 	return err
 }
 
@@ -66,7 +74,8 @@ func (r *UserRepositoryPostgres) GetByID(ctx context.Context, id interface{}) (*
 		var err error
 		uuidID, err = uuid.Parse(v)
 		if err != nil {
-			return nil, err
+			// id yang bukan UUID tidak mungkin ada
+			return nil, fmt.Errorf("%w: %v", entity.ErrNotFound, err)
 		}
 	case uuid.UUID:
 		uuidID = v
@@ -74,93 +83,278 @@ func (r *UserRepositoryPostgres) GetByID(ctx context.Context, id interface{}) (*
 		return nil, fmt.Errorf("invalid id type")
 	}
 
-	// Query untuk mencari user berdasarkan ID
-	query := `SELECT id, name, email, created_at, updated_at FROM users WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, uuidID)
+	// Cek cache Redis terlebih dahulu
+	cacheKey := fmt.Sprintf("users:%v", uuidID)
+	// Di dalam transaksi cache dilewati agar data yang belum di-commit tidak ikut tersimpan
+	if !inTx(ctx) && r.cache.Get(ctx, cacheKey, &user) {
+		fmt.Println("✅ User retrieved from cache.")
+		return &user, nil
+	}
+
+	// Query untuk mencari user aktif (belum di-soft-delete) berdasarkan ID
+	query := `SELECT id, name, email, created_at, updated_at, version, deleted_at FROM users WHERE id = $1 AND deleted_at IS NULL`
+	row := executor(ctx, r.db).QueryRowContext(ctx, query, uuidID)
 
 	// Scan hasil query ke dalam struct user
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt, &user.Version, &user.DeletedAt)
 	if err != nil {
 		return nil, err
 	}
 
+	if !inTx(ctx) {
+		r.cache.Set(ctx, cacheKey, user, r.cache.ItemTTL())
+	}
 	fmt.Println("✅ User retrieved successfully.")
 	return &user, nil
 }
 
-// Update memperbarui data user di PostgreSQL
+// Update memperbarui data user di PostgreSQL.
+// Jika user.Version > 0, update hanya berhasil bila versi di database sama (optimistic concurrency).
+// Setelah berhasil, user.Version berisi versi baru.
 func (r *UserRepositoryPostgres) Update(ctx context.Context, user *entity.User) error {
+	uuidID, err := r.updateUser(ctx, executor(ctx, r.db), user)
+	if err != nil {
+		return err
+	}
+
+	// Hapus cache Redis jika update berhasil
+	invalidateAfterCommit(ctx, r.cache, "users:all", fmt.Sprintf("users:%v", uuidID))
+	fmt.Println("✅ User updated successfully.")
+	return nil
+}
+
+// updateUser menjalankan UPDATE bersyarat versi lewat q (koneksi atau transaksi) tanpa menyentuh cache
+func (r *UserRepositoryPostgres) updateUser(ctx context.Context, q sqlExecutor, user *entity.User) (uuid.UUID, error) {
 	// Validasi data user sebelum update
 	if err := r.validate.Struct(user); err != nil {
-		return errors.New("validation failed: " + err.Error())
+		return uuid.Nil, errors.New("validation failed: " + err.Error())
 	}
 
-	// Query untuk mengupdate data user
-	query := `UPDATE users SET name = $1, email = $2, updated_at = $3 WHERE id = $4`
-	_, err := r.db.ExecContext(ctx, query, user.Name, user.Email, time.Now(), user.ID)
-	if err == nil {
-		// Hapus cache Redis jika update berhasil
-		r.redis.Del(ctx, "users:all", fmt.Sprintf("users:%v", user.ID))
-		fmt.Println("✅ User updated successfully.")
+	uuidID, err := parseUserIDAsUUID(user.ID)
+	if err != nil {
+		return uuid.Nil, err
 	}
-	return err
+
+	// Query untuk mengupdate data user dan menaikkan versi
+	user.UpdatedAt = time.Now()
+	query := `UPDATE users SET name = $1, email = $2, updated_at = $3, version = version + 1
+			  WHERE id = $4 AND deleted_at IS NULL AND ($5::bigint = 0 OR version = $5::bigint)
+			  RETURNING version`
+	err = q.QueryRowContext(ctx, query, user.Name, user.Email, user.UpdatedAt, uuidID, user.Version).Scan(&user.Version)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, versionMissPostgres(ctx, q, "users", uuidID)
+	}
+	return uuidID, err
 }
 
-// Delete menghapus data user dari PostgreSQL berdasarkan ID
-func (r *UserRepositoryPostgres) Delete(ctx context.Context, id interface{}) error {
-	var uuidID uuid.UUID
+// Delete melakukan soft delete user (mengisi deleted_at) berdasarkan ID.
+// Jika expectedVersion > 0, delete hanya berhasil bila versi di database sama.
+func (r *UserRepositoryPostgres) Delete(ctx context.Context, id interface{}, expectedVersion int64) error {
+	uuidID, err := r.softDeleteUser(ctx, executor(ctx, r.db), id, expectedVersion)
+	if err != nil {
+		return err
+	}
 
-	// Konversi ID ke uuid.UUID
-	switch v := id.(type) {
-	case string:
-		var err error
-		uuidID, err = uuid.Parse(v)
-		if err != nil {
+	// Hapus cache jika berhasil
+	invalidateAfterCommit(ctx, r.cache, "users:all", fmt.Sprintf("users:%v", uuidID))
+	fmt.Println("✅ User soft-deleted successfully.")
+	return nil
+}
+
+// softDeleteUser mengisi deleted_at lewat q (koneksi atau transaksi) tanpa menyentuh cache.
+// User yang tidak ada menghasilkan ErrNotFound, versi berbeda menghasilkan ErrVersionConflict.
+func (r *UserRepositoryPostgres) softDeleteUser(ctx context.Context, q sqlExecutor, id interface{}, expectedVersion int64) (uuid.UUID, error) {
+	uuidID, err := parseUserIDAsUUID(id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// Query untuk soft delete user berdasarkan ID
+	query := `UPDATE users SET deleted_at = $1, version = version + 1
+			  WHERE id = $2 AND deleted_at IS NULL AND ($3::bigint = 0 OR version = $3::bigint)`
+	result, err := q.ExecContext(ctx, query, time.Now(), uuidID, expectedVersion)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return uuid.Nil, versionMissPostgres(ctx, q, "users", uuidID)
+	}
+	return uuidID, nil
+}
+
+// ApplyBatch menjalankan operasi create/update/delete dalam satu transaksi dan mengembalikan error per operasi.
+// Pada mode atomic, satu kegagalan membuat tidak ada operasi yang tersimpan.
+func (r *UserRepositoryPostgres) ApplyBatch(ctx context.Context, ops []entity.BatchOperation[entity.User], atomic bool) ([]error, error) {
+	cacheKeys := []string{"users:all"}
+	errs, err := runBatchPostgres(ctx, r.db, len(ops), atomic, func(q sqlExecutor, i int) error {
+		op := ops[i]
+		switch op.Op {
+		case entity.BatchCreate:
+			return r.insertUser(ctx, q, op.Data)
+		case entity.BatchUpdate:
+			op.Data.ID = op.ID
+			op.Data.Version = op.Version
+			uuidID, err := r.updateUser(ctx, q, op.Data)
+			if err == nil {
+				op.Data.ID = uuidID
+				cacheKeys = append(cacheKeys, fmt.Sprintf("users:%v", uuidID))
+			}
+			return err
+		case entity.BatchDelete:
+			uuidID, err := r.softDeleteUser(ctx, q, op.ID, op.Version)
+			if err == nil {
+				cacheKeys = append(cacheKeys, fmt.Sprintf("users:%v", uuidID))
+			}
 			return err
+		default:
+			return fmt.Errorf("unknown batch op %q", op.Op)
 		}
-	case uuid.UUID:
-		uuidID = v
-	default:
-		return fmt.Errorf("invalid id type")
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Query untuk menghapus user berdasarkan ID
-	query := `DELETE FROM users WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query, uuidID)
+	if !atomic || !batchFailed(errs) {
+		invalidateAfterCommit(ctx, r.cache, cacheKeys...)
+		fmt.Printf("✅ User batch applied (%d operations).\n", len(ops))
+	}
+	return errs, nil
+}
+
+// Restore mengembalikan user yang sudah di-soft-delete
+func (r *UserRepositoryPostgres) Restore(ctx context.Context, id interface{}) (*entity.User, error) {
+	uuidID, err := parseUserIDAsUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE users SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL`
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, time.Now(), uuidID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tidak ada baris berarti user tidak ada atau tidak sedang terhapus
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	invalidateAfterCommit(ctx, r.cache, "users:all", fmt.Sprintf("users:%v", uuidID))
+	fmt.Println("✅ User restored successfully.")
+	return r.GetByID(ctx, uuidID)
+}
+
+// Purge menghapus user secara permanen (hard delete), termasuk yang sudah di-soft-delete.
+// Repository milik user ikut terhapus lewat ON DELETE CASCADE.
+func (r *UserRepositoryPostgres) Purge(ctx context.Context, id interface{}) error {
+	uuidID, err := parseUserIDAsUUID(id)
+	if err != nil {
+		return err
+	}
+
+	// Hapus user beserta repository miliknya dalam satu statement; RETURNING memberi ID repository
+	// yang benar-benar terhapus agar cache-nya bisa diinvalidasi tanpa jeda antara SELECT dan DELETE
+	query := `
+		WITH purged_repos AS (
+			DELETE FROM repositories WHERE user_id = $1 RETURNING id
+		), purged_user AS (
+			DELETE FROM users WHERE id = $1 RETURNING id
+		)
+		SELECT 'user', id FROM purged_user
+		UNION ALL
+		SELECT 'repository', id FROM purged_repos`
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, uuidID)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	// Cek apakah ada baris yang dihapus dan hapus cache jika berhasil
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected > 0 {
-		r.redis.Del(ctx, "users:all", fmt.Sprintf("users:%v", id))
-		fmt.Println("✅ User deleted successfully.")
+	purged := false
+	repoKeys := []string{"repositories:all"}
+	for rows.Next() {
+		var kind string
+		var purgedID uuid.UUID
+		if err := rows.Scan(&kind, &purgedID); err != nil {
+			return err
+		}
+		if kind == "user" {
+			purged = true
+			continue
+		}
+		repoKeys = append(repoKeys, fmt.Sprintf("repositories:%v", purgedID))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !purged {
+		return sql.ErrNoRows
 	}
+
+	invalidateAfterCommit(ctx, r.cache, append(repoKeys, "users:all", fmt.Sprintf("users:%v", uuidID))...)
+	fmt.Println("✅ User purged successfully.")
 	return nil
 }
 
-// GetAll mengambil semua data user dari PostgreSQL
-func (r *UserRepositoryPostgres) GetAll(ctx context.Context) ([]entity.User, error) {
-	// Query untuk mengambil semua data user
-	query := `SELECT id, name, email, created_at, updated_at FROM users`
-	rows, err := r.db.QueryContext(ctx, query)
+// GetAll mengambil data user dari PostgreSQL per halaman (keyset pada created_at, id)
+func (r *UserRepositoryPostgres) GetAll(ctx context.Context, filter entity.UserFilter, page entity.PageRequest) (*entity.UserPage, error) {
+	page = page.Normalize()
+	cacheField := pageCacheField(page, filter)
+
+	// Cek cache Redis terlebih dahulu
+	var result entity.UserPage
+	if r.cache.GetField(ctx, "users:all", cacheField, &result) {
+		fmt.Println("✅ Users page retrieved from cache.")
+		return &result, nil
+	}
+
+	// Query untuk mengambil satu halaman user, ambil limit+1 untuk mengetahui ada halaman berikutnya
+	where := userWherePostgres(filter)
+	query := `SELECT id, name, email, created_at, updated_at, version, deleted_at FROM users`
+	query, args, err := keysetPageQuery(query, where, page)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	// Menyimpan hasil query ke slice user
-	var users []entity.User
+	users := []entity.User{}
 	for rows.Next() {
 		var user entity.User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt, &user.Version, &user.DeletedAt); err != nil {
 			return nil, err
 		}
 		users = append(users, user)
 	}
-	fmt.Println("✅ All users retrieved successfully.")
-	return users, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		last := users[len(users)-1]
+		result.NextCursor = nextKeysetCursor(last.CreatedAt, last.ID)
+	}
+	result.Data = users
+
+	if page.WithTotal {
+		countWhere := userWherePostgres(filter)
+
+		var total int64
+		if err := executor(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+countWhere.sql(), countWhere.args...).Scan(&total); err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	r.cache.SetField(ctx, "users:all", cacheField, result, r.cache.ListTTL())
+	fmt.Println("✅ Users page retrieved successfully.")
+	return &result, nil
 }
 
 func (r *UserRepositoryPostgres) PublishEvent(ctx context.Context, eventType string, eventData interface{}) error {
@@ -178,3 +372,13 @@ func (r *UserRepositoryPostgres) PublishEvent(ctx context.Context, eventType str
 
 	return nil
 }
+
+// userWherePostgres menerjemahkan filter ke kondisi WHERE untuk tabel users
+func userWherePostgres(filter entity.UserFilter) *whereBuilder {
+	b := &whereBuilder{}
+	b.addDeletedScope(filter.Deleted)
+	if filter.Email != "" {
+		b.add("email = " + b.arg(filter.Email))
+	}
+	return b
+}
@@ -6,15 +6,16 @@ import (
 	"fmt"
 	"time"
 
+	"golang-crud-clean-arch/internal/cache"
 	"golang-crud-clean-arch/internal/entity"
 	"golang-crud-clean-arch/internal/event"
 	"golang-crud-clean-arch/internal/notification"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/go-redis/redis/v8"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -23,17 +24,17 @@ import (
 
 type UserRepositoryMongo struct {
 	db        *mongo.Client
-	redis     *redis.Client
+	cache     *cache.Cache
 	dbName    string
 	validate  *validator.Validate
 	tracer    trace.Tracer
 	publisher event.EventPublisher
 }
 
-func NewUserRepositoryMongo(db *mongo.Client, redis *redis.Client, dbName string, publisher event.EventPublisher) *UserRepositoryMongo {
+func NewUserRepositoryMongo(db *mongo.Client, cache *cache.Cache, dbName string, publisher event.EventPublisher) *UserRepositoryMongo {
 	return &UserRepositoryMongo{
 		db:        db,
-		redis:     redis,
+		cache:     cache,
 		dbName:    dbName,
 		validate:  validator.New(),
 		tracer:    otel.Tracer("user-repository-mongo"),
@@ -54,6 +55,7 @@ func (r *UserRepositoryMongo) Create(ctx context.Context, user *entity.User) err
 	user.ID = primitive.NewObjectID()
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
+	user.Version = 1
 
 	span.SetAttributes(
 		attribute.String("user.id", user.ID.(primitive.ObjectID).Hex()),
@@ -68,16 +70,9 @@ func (r *UserRepositoryMongo) Create(ctx context.Context, user *entity.User) err
 		return err
 	}
 
-	r.redis.Del(ctx, "users:all")
+	r.cache.Del(ctx, "users:all")
 
-	// Publish Kafka event
-	eventData := entity.Event{
-		Type: "user.created",
-		Data: user,
-	}
-	if err := r.publisher.Publish(ctx, "user-events", eventData.Type, eventData.Data); err != nil {
-		return err
-	}
+	// Event user.created dipublikasikan oleh usecase
 	notification.SendTelegramMessage("✅ User created: " + user.Email)
 	return nil
 }
@@ -93,7 +88,8 @@ func (r *UserRepositoryMongo) GetByID(ctx context.Context, id interface{}) (*ent
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "invalid ID format")
-			return nil, err
+			// id yang bukan ObjectID tidak mungkin ada
+			return nil, fmt.Errorf("%w: %v", entity.ErrNotFound, err)
 		}
 		objectID = oid
 	case primitive.ObjectID:
@@ -108,14 +104,23 @@ func (r *UserRepositoryMongo) GetByID(ctx context.Context, id interface{}) (*ent
 	span.SetAttributes(attribute.String("user.id", objectID.Hex()))
 
 	var user entity.User
+	cacheKey := fmt.Sprintf("users:%s", objectID.Hex())
+	if r.cache.Get(ctx, cacheKey, &user) {
+		user.ID = objectID
+		span.SetAttributes(attribute.String("user.email", user.Email))
+		span.SetStatus(codes.Ok, "user fetched from cache")
+		return &user, nil
+	}
+
 	collection := r.db.Database(r.dbName).Collection("users")
-	err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+	err := collection.FindOne(ctx, bson.M{"_id": objectID, "deleted_at": nil}).Decode(&user)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "user not found")
 		return nil, err
 	}
 
+	r.cache.Set(ctx, cacheKey, user, r.cache.ItemTTL())
 	span.SetAttributes(attribute.String("user.email", user.Email))
 	span.SetStatus(codes.Ok, "user fetched")
 	return &user, nil
@@ -131,50 +136,60 @@ func (r *UserRepositoryMongo) Update(ctx context.Context, user *entity.User) err
 		return errors.New("validation failed: " + err.Error())
 	}
 
+	// ID dari handler berupa hex string, konversi ke ObjectID agar filter & invalidasi cache tepat
+	user.ID = objectIDOrRaw(user.ID)
+	objectID, ok := user.ID.(primitive.ObjectID)
+	if !ok {
+		err := fmt.Errorf("invalid id type for MongoDB")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid ID type")
+		return err
+	}
+
 	span.SetAttributes(
-		attribute.String("user.id", user.ID.(primitive.ObjectID).Hex()),
+		attribute.String("user.id", objectID.Hex()),
 		attribute.String("user.email", user.Email),
 	)
 
 	collection := r.db.Database(r.dbName).Collection("users")
+	user.UpdatedAt = time.Now()
 	update := bson.M{
 		"$set": bson.M{
 			"name":       user.Name,
 			"email":      user.Email,
-			"updated_at": time.Now(),
+			"updated_at": user.UpdatedAt,
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
-	result, err := collection.UpdateOne(ctx, bson.M{"_id": user.ID}, update)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "update failed")
-		return err
-	}
+	// Jika user.Version > 0, update hanya berlaku bila versi tersimpan sama (optimistic concurrency)
+	filter := versionFilterMongo(bson.M{"_id": objectID, "deleted_at": nil}, user.Version)
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"version": 1})
 
-	if result.MatchedCount > 0 {
-		if objectID, ok := user.ID.(primitive.ObjectID); ok {
-			r.redis.Del(ctx, "users:all", fmt.Sprintf("users:%s", objectID.Hex()))
-		}
+	var updated struct {
+		Version int64 `bson:"version"`
 	}
-
-	// Publish Kafka event
-	eventData := entity.Event{
-		Type: "user.updated",
-		Data: user,
+	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		err = versionMissMongo(ctx, collection, userMongoFields, objectID)
 	}
-	if err := r.publisher.Publish(ctx, "user-events", eventData.Type, eventData.Data); err != nil {
+	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "event publish failed")
+		span.SetStatus(codes.Error, "update failed")
 		return err
 	}
 
-	span.SetAttributes(attribute.Int64("matched_count", result.MatchedCount))
+	user.Version = updated.Version
+	r.cache.Del(ctx, "users:all", fmt.Sprintf("users:%s", objectID.Hex()))
+
+	span.SetAttributes(attribute.Int64("user.version", user.Version))
 	span.SetStatus(codes.Ok, "user updated")
 	return nil
 }
 
-func (r *UserRepositoryMongo) Delete(ctx context.Context, id interface{}) error {
+func (r *UserRepositoryMongo) Delete(ctx context.Context, id interface{}, expectedVersion int64) error {
 	ctx, span := r.tracer.Start(ctx, "UserRepositoryMongo.Delete")
 	defer span.End()
 
@@ -199,40 +214,196 @@ func (r *UserRepositoryMongo) Delete(ctx context.Context, id interface{}) error
 
 	span.SetAttributes(attribute.String("user.id", objectID.Hex()))
 
+	// Soft delete: tandai dokumen dengan deleted_at
 	collection := r.db.Database(r.dbName).Collection("users")
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	update := bson.M{"$set": bson.M{"deleted_at": time.Now()}, "$inc": bson.M{"version": 1}}
+	filter := versionFilterMongo(bson.M{"_id": objectID, "deleted_at": nil}, expectedVersion)
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err == nil && result.MatchedCount == 0 {
+		// Tanpa If-Match, dokumen yang tidak ada atau sudah terhapus tetap 404 seperti di PostgreSQL
+		if expectedVersion > 0 {
+			err = versionMissMongo(ctx, collection, userMongoFields, objectID)
+		} else {
+			err = entity.ErrNotFound
+		}
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "delete failed")
 		return err
 	}
 
-	if result.DeletedCount > 0 {
-		r.redis.Del(ctx, "users:all", fmt.Sprintf("users:%s", objectID.Hex()))
+	if result.ModifiedCount > 0 {
+		r.cache.Del(ctx, "users:all", fmt.Sprintf("users:%s", objectID.Hex()))
+	}
+
+	span.SetAttributes(attribute.Int64("deleted_count", result.ModifiedCount))
+	span.SetStatus(codes.Ok, "user deleted")
+	return nil
+}
+
+// ApplyBatch menjalankan operasi create/update/delete dengan satu BulkWrite dan mengembalikan error per operasi.
+// Event tidak dipublikasikan di sini, usecase mengirimnya dalam satu batch.
+func (r *UserRepositoryMongo) ApplyBatch(ctx context.Context, ops []entity.BatchOperation[entity.User], atomic bool) ([]error, error) {
+	ctx, span := r.tracer.Start(ctx, "UserRepositoryMongo.ApplyBatch")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("batch.size", len(ops)),
+		attribute.Bool("batch.atomic", atomic),
+	)
+
+	now := time.Now()
+	collection := r.db.Database(r.dbName).Collection("users")
+	errs, err := runBatchMongo(ctx, collection, userMongoFields, ops, atomic, func(op entity.BatchOperation[entity.User], id primitive.ObjectID, current int64) mongo.WriteModel {
+		switch op.Op {
+		case entity.BatchCreate:
+			op.Data.ID = primitive.NewObjectID()
+			op.Data.CreatedAt = now
+			op.Data.UpdatedAt = now
+			op.Data.Version = 1
+			return mongo.NewInsertOneModel().SetDocument(op.Data)
+		case entity.BatchUpdate:
+			op.Data.ID = id
+			op.Data.UpdatedAt = now
+			op.Data.Version = current + 1
+			return mongo.NewUpdateOneModel().
+				SetFilter(versionFilterMongo(bson.M{"_id": id, "deleted_at": nil}, current)).
+				SetUpdate(bson.M{
+					"$set": bson.M{"name": op.Data.Name, "email": op.Data.Email, "updated_at": now},
+					"$inc": bson.M{"version": 1},
+				})
+		default:
+			return mongo.NewUpdateOneModel().
+				SetFilter(versionFilterMongo(bson.M{"_id": id, "deleted_at": nil}, current)).
+				SetUpdate(bson.M{"$set": bson.M{"deleted_at": now}, "$inc": bson.M{"version": 1}})
+		}
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "batch failed")
+		return nil, err
+	}
+
+	// Invalidasi cache untuk dokumen yang berubah
+	cacheKeys := []string{"users:all"}
+	for i, op := range ops {
+		if errs[i] == nil && op.Op != entity.BatchCreate {
+			cacheKeys = append(cacheKeys, fmt.Sprintf("users:%s", op.ID))
+		}
+	}
+	if !atomic || !batchFailed(errs) {
+		r.cache.Del(ctx, cacheKeys...)
+	}
+
+	span.SetStatus(codes.Ok, "batch applied")
+	return errs, nil
+}
+
+func (r *UserRepositoryMongo) Restore(ctx context.Context, id interface{}) (*entity.User, error) {
+	ctx, span := r.tracer.Start(ctx, "UserRepositoryMongo.Restore")
+	defer span.End()
+
+	objectID, ok := objectIDOrRaw(id).(primitive.ObjectID)
+	if !ok {
+		err := fmt.Errorf("invalid id type for MongoDB")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid ID type")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("user.id", objectID.Hex()))
+
+	collection := r.db.Database(r.dbName).Collection("users")
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$inc":   bson.M{"version": 1},
 	}
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": objectID, "deleted_at": bson.M{"$ne": nil}}, update)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "restore failed")
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		span.SetStatus(codes.Error, "user not in trash")
+		return nil, mongo.ErrNoDocuments
+	}
+
+	r.cache.Del(ctx, "users:all", fmt.Sprintf("users:%s", objectID.Hex()))
+
+	span.SetStatus(codes.Ok, "user restored")
+	return r.GetByID(ctx, objectID)
+}
+
+func (r *UserRepositoryMongo) Purge(ctx context.Context, id interface{}) error {
+	ctx, span := r.tracer.Start(ctx, "UserRepositoryMongo.Purge")
+	defer span.End()
 
-	// Publish Kafka event
-	eventData := entity.Event{
-		Type: "user.deleted",
-		Data: map[string]interface{}{"id": objectID.Hex()},
+	objectID, ok := objectIDOrRaw(id).(primitive.ObjectID)
+	if !ok {
+		err := fmt.Errorf("invalid id type for MongoDB")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid ID type")
+		return err
 	}
-	if err := r.publisher.Publish(ctx, "user-events", eventData.Type, eventData.Data); err != nil {
+
+	span.SetAttributes(attribute.String("user.id", objectID.Hex()))
+
+	collection := r.db.Database(r.dbName).Collection("users")
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "event publish failed")
+		span.SetStatus(codes.Error, "purge failed")
 		return err
 	}
+	if result.DeletedCount == 0 {
+		span.SetStatus(codes.Error, "user not found")
+		return mongo.ErrNoDocuments
+	}
 
-	span.SetAttributes(attribute.Int64("deleted_count", result.DeletedCount))
-	span.SetStatus(codes.Ok, "user deleted")
+	r.cache.Del(ctx, "users:all", fmt.Sprintf("users:%s", objectID.Hex()))
+
+	span.SetStatus(codes.Ok, "user purged")
 	return nil
 }
 
-func (r *UserRepositoryMongo) GetAll(ctx context.Context) ([]entity.User, error) {
+func (r *UserRepositoryMongo) GetAll(ctx context.Context, filter entity.UserFilter, page entity.PageRequest) (*entity.UserPage, error) {
 	ctx, span := r.tracer.Start(ctx, "UserRepositoryMongo.GetAll")
 	defer span.End()
 
+	page = page.Normalize()
+	cacheField := pageCacheField(page, filter)
+	span.SetAttributes(attribute.Int("page.limit", page.Limit))
+
+	var conds []bson.M
+	if cond := deletedScopeMongo(userMongoFields, filter.Deleted); cond != nil {
+		conds = append(conds, cond)
+	}
+	if filter.Email != "" {
+		conds = append(conds, bson.M{"email": filter.Email})
+	}
+
+	var result entity.UserPage
+	if r.cache.GetField(ctx, "users:all", cacheField, &result) {
+		for i := range result.Data {
+			result.Data[i].ID = objectIDOrRaw(result.Data[i].ID)
+		}
+		span.SetAttributes(attribute.Int("user.count", len(result.Data)))
+		span.SetStatus(codes.Ok, "users page fetched from cache")
+		return &result, nil
+	}
+
+	mongoFilter, opts, err := objectIDPageQuery(conds, page)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid cursor")
+		return nil, err
+	}
+
 	collection := r.db.Database(r.dbName).Collection("users")
-	cursor, err := collection.Find(ctx, bson.M{})
+	cursor, err := collection.Find(ctx, mongoFilter, opts)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "find failed")
@@ -240,16 +411,34 @@ func (r *UserRepositoryMongo) GetAll(ctx context.Context) ([]entity.User, error)
 	}
 	defer cursor.Close(ctx)
 
-	var users []entity.User
+	users := []entity.User{}
 	if err = cursor.All(ctx, &users); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "decode failed")
 		return nil, err
 	}
 
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		result.NextCursor = nextObjectIDCursor(users[len(users)-1].ID)
+	}
+	result.Data = users
+
+	if page.WithTotal {
+		total, err := collection.CountDocuments(ctx, andFilterMongo(conds))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "count failed")
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	r.cache.SetField(ctx, "users:all", cacheField, result, r.cache.ListTTL())
+
 	span.SetAttributes(attribute.Int("user.count", len(users)))
-	span.SetStatus(codes.Ok, "all users fetched")
-	return users, nil
+	span.SetStatus(codes.Ok, "users page fetched")
+	return &result, nil
 }
 
 func (r *UserRepositoryMongo) PublishEvent(ctx context.Context, eventType string, eventData interface{}) error {
@@ -267,3 +456,14 @@ func (r *UserRepositoryMongo) PublishEvent(ctx context.Context, eventType string
 
 	return nil
 }
+
+// objectIDOrRaw mengembalikan ObjectID jika raw adalah hex string yang valid.
+// Dipakai saat membaca entitas dari cache, karena JSON menyimpan ObjectID sebagai string.
+func objectIDOrRaw(raw interface{}) interface{} {
+	if s, ok := raw.(string); ok {
+		if oid, err := primitive.ObjectIDFromHex(s); err == nil {
+			return oid
+		}
+	}
+	return raw
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"golang-crud-clean-arch/internal/entity"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// versionMissPostgres menjelaskan kenapa UPDATE bersyarat versi tidak mengenai baris apa pun:
+// entitas tidak ada / sudah terhapus (ErrNotFound) atau versinya sudah berubah (ErrVersionConflict)
+func versionMissPostgres(ctx context.Context, q sqlExecutor, table string, id interface{}) error {
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND deleted_at IS NULL)`, table)
+	if err := q.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return entity.ErrVersionConflict
+	}
+	return entity.ErrNotFound
+}
+
+// versionMissMongo adalah versi MongoDB dari versionMissPostgres
+func versionMissMongo(ctx context.Context, collection *mongo.Collection, fields mongoFields, id interface{}) error {
+	count, err := collection.CountDocuments(ctx, bson.M{fields.ID: id, fields.DeletedAt: nil})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return entity.ErrVersionConflict
+	}
+	return entity.ErrNotFound
+}
+
+// versionFilterMongo menambahkan syarat versi ke filter jika expectedVersion > 0
+func versionFilterMongo(filter bson.M, expectedVersion int64) bson.M {
+	if expectedVersion > 0 {
+		filter["version"] = expectedVersion
+	}
+	return filter
+}
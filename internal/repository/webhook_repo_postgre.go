@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"golang-crud-clean-arch/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRepositoryPostgres menyimpan langganan webhook, log pengiriman dan antrian retry-nya
+// di tabel webhook_subscriptions, webhook_deliveries dan webhook_retries
+type WebhookRepositoryPostgres struct {
+	db *sql.DB
+}
+
+// NewWebhookRepositoryPostgres membuat instance baru dari WebhookRepositoryPostgres
+func NewWebhookRepositoryPostgres(db *sql.DB) *WebhookRepositoryPostgres {
+	return &WebhookRepositoryPostgres{db: db}
+}
+
+const webhookColumns = `id::text, url, secret, event_types, active, consecutive_failures,
+	disabled_at, disabled_reason, created_at, updated_at`
+
+// Create menyimpan langganan baru dengan id baru
+func (r *WebhookRepositoryPostgres) Create(ctx context.Context, s *entity.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(nonNil(s.EventTypes))
+	if err != nil {
+		return err
+	}
+
+	s.ID = uuid.NewString()
+	s.Active = true
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+
+	query := `INSERT INTO webhook_subscriptions (id, url, secret, event_types, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = executor(ctx, r.db).ExecContext(ctx, query, s.ID, s.URL, s.Secret, eventTypes, s.Active, s.CreatedAt, s.UpdatedAt)
+	return err
+}
+
+// GetByID mengambil langganan berdasarkan id, entity.ErrNotFound jika tidak ada
+func (r *WebhookRepositoryPostgres) GetByID(ctx context.Context, id string) (*entity.WebhookSubscription, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, entity.ErrNotFound
+	}
+
+	query := `SELECT ` + webhookColumns + ` FROM webhook_subscriptions WHERE id = $1`
+	s, err := scanWebhook(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entity.ErrNotFound
+	}
+	return s, err
+}
+
+// List mengembalikan semua langganan, atau hanya yang aktif jika activeOnly
+func (r *WebhookRepositoryPostgres) List(ctx context.Context, activeOnly bool) ([]entity.WebhookSubscription, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhook_subscriptions`
+	if activeOnly {
+		query += ` WHERE active`
+	}
+	query += ` ORDER BY created_at`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []entity.WebhookSubscription{}
+	for rows.Next() {
+		s, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *s)
+	}
+	return subscriptions, rows.Err()
+}
+
+// Update menyimpan url, secret, event_types dan active. Mengaktifkan kembali langganan
+// mereset hitungan kegagalan dan alasan penonaktifannya.
+func (r *WebhookRepositoryPostgres) Update(ctx context.Context, s *entity.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(nonNil(s.EventTypes))
+	if err != nil {
+		return err
+	}
+	s.UpdatedAt = time.Now()
+
+	query := `UPDATE webhook_subscriptions SET url = $2, secret = $3, event_types = $4, active = $5, updated_at = $6,
+			consecutive_failures = CASE WHEN $5 AND NOT active THEN 0 ELSE consecutive_failures END,
+			disabled_at = CASE WHEN $5 THEN NULL ELSE disabled_at END,
+			disabled_reason = CASE WHEN $5 THEN NULL ELSE disabled_reason END
+		WHERE id = $1
+		RETURNING ` + webhookColumns
+	updated, err := scanWebhook(executor(ctx, r.db).QueryRowContext(ctx, query, s.ID, s.URL, s.Secret, eventTypes, s.Active, s.UpdatedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	*s = *updated
+	return nil
+}
+
+// Delete menghapus langganan beserta log pengirimannya
+func (r *WebhookRepositoryPostgres) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return entity.ErrNotFound
+	}
+	res, err := executor(ctx, r.db).ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+// RecordSuccess mereset hitungan kegagalan berturut-turut setelah event berhasil dikirim
+func (r *WebhookRepositoryPostgres) RecordSuccess(ctx context.Context, id string) error {
+	query := `UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures <> 0`
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, id)
+	return err
+}
+
+// RecordFailure menaikkan hitungan kegagalan berturut-turut dan menonaktifkan langganan jika
+// sudah mencapai disableAfter. Mengembalikan true jika langganan baru saja dinonaktifkan.
+func (r *WebhookRepositoryPostgres) RecordFailure(ctx context.Context, id string, disableAfter int, reason string) (bool, error) {
+	query := `UPDATE webhook_subscriptions SET consecutive_failures = consecutive_failures + 1,
+			active = active AND consecutive_failures + 1 < $2,
+			disabled_at = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN NOW() ELSE disabled_at END,
+			disabled_reason = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN $3 ELSE disabled_reason END
+		WHERE id = $1
+		RETURNING disabled_at IS NOT NULL AND NOT active AND consecutive_failures = $2`
+	var disabled bool
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id, disableAfter, reason).Scan(&disabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, entity.ErrNotFound
+	}
+	return disabled, err
+}
+
+// SaveDelivery menyimpan satu percobaan pengiriman
+func (r *WebhookRepositoryPostgres) SaveDelivery(ctx context.Context, d *entity.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries
+			(subscription_id, event_id, event_type, attempt, status_code, success, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+	return executor(ctx, r.db).QueryRowContext(ctx, query,
+		d.SubscriptionID, d.EventID, d.EventType, d.Attempt, d.StatusCode, d.Success, d.Error, d.DurationMs,
+	).Scan(&d.ID, &d.CreatedAt)
+}
+
+// ListDeliveries mengembalikan percobaan pengiriman terbaru untuk satu langganan
+func (r *WebhookRepositoryPostgres) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]entity.WebhookDelivery, error) {
+	query := `SELECT id, subscription_id::text, event_id, event_type, attempt, status_code, success, error, duration_ms, created_at
+		FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2`
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []entity.WebhookDelivery{}
+	for rows.Next() {
+		var d entity.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Attempt,
+			&d.StatusCode, &d.Success, &d.Error, &d.DurationMs, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ScheduleRetry menyimpan pengiriman yang gagal untuk dicoba lagi setelah delay
+func (r *WebhookRepositoryPostgres) ScheduleRetry(ctx context.Context, retry *entity.WebhookRetry, delay time.Duration) error {
+	query := `INSERT INTO webhook_retries
+			(subscription_id, event_id, event_type, payload, attempt, next_attempt_at, last_error)
+		VALUES ($1, $2, $3, $4, $5, NOW() + $6 * INTERVAL '1 millisecond', $7)
+		RETURNING id, next_attempt_at, created_at`
+	return executor(ctx, r.db).QueryRowContext(ctx, query,
+		retry.SubscriptionID, retry.EventID, retry.EventType, string(retry.Payload), retry.Attempt,
+		delay.Milliseconds(), retry.LastError,
+	).Scan(&retry.ID, &retry.NextAttemptAt, &retry.CreatedAt)
+}
+
+// ClaimRetries mengklaim retry yang sudah jatuh tempo selama lease, sehingga beberapa instance
+// bisa menjalankan worker tanpa mengirim retry yang sama dua kali
+func (r *WebhookRepositoryPostgres) ClaimRetries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookRetry, error) {
+	query := `UPDATE webhook_retries SET claimed_until = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM webhook_retries
+			WHERE next_attempt_at <= NOW() AND (claimed_until IS NULL OR claimed_until < NOW())
+			ORDER BY next_attempt_at LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, subscription_id::text, event_id, event_type, payload, attempt, next_attempt_at, last_error, created_at`
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var retries []entity.WebhookRetry
+	for rows.Next() {
+		var retry entity.WebhookRetry
+		if err := rows.Scan(&retry.ID, &retry.SubscriptionID, &retry.EventID, &retry.EventType, &retry.Payload,
+			&retry.Attempt, &retry.NextAttemptAt, &retry.LastError, &retry.CreatedAt); err != nil {
+			return nil, err
+		}
+		retries = append(retries, retry)
+	}
+	return retries, rows.Err()
+}
+
+// RescheduleRetry menjadwalkan percobaan berikutnya dan melepas klaim retry
+func (r *WebhookRepositoryPostgres) RescheduleRetry(ctx context.Context, id int64, attempt int, delay time.Duration, reason string) error {
+	query := `UPDATE webhook_retries SET attempt = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond',
+			last_error = $4, claimed_until = NULL
+		WHERE id = $1`
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, id, attempt, delay.Milliseconds(), reason)
+	return err
+}
+
+// DeleteRetry menghapus retry yang sudah berhasil, habis percobaannya atau langganannya nonaktif
+func (r *WebhookRepositoryPostgres) DeleteRetry(ctx context.Context, id int64) error {
+	_, err := executor(ctx, r.db).ExecContext(ctx, `DELETE FROM webhook_retries WHERE id = $1`, id)
+	return err
+}
+
+// rowScanner adalah *sql.Row atau *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhook membaca satu baris webhookColumns
+func scanWebhook(row rowScanner) (*entity.WebhookSubscription, error) {
+	var s entity.WebhookSubscription
+	var eventTypes []byte
+	if err := row.Scan(&s.ID, &s.URL, &s.Secret, &eventTypes, &s.Active, &s.ConsecutiveFailures,
+		&s.DisabledAt, &s.DisabledReason, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(eventTypes, &s.EventTypes); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// nonNil mengganti slice nil dengan slice kosong agar tersimpan sebagai [] bukan null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
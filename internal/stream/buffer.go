@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// bufferKey adalah stream Redis yang menyimpan event live terakhir
+const bufferKey = "live:events"
+
+// liveTypes adalah tipe event yang diteruskan ke client live
+var liveTypes = []string{"user.*", "repo.*"}
+
+// ErrInvalidEventID dikembalikan jika Last-Event-ID bukan id entry stream Redis ("<ms>-<seq>")
+var ErrInvalidEventID = errors.New("invalid last event id")
+
+// Entry adalah satu event di buffer; ID adalah id entry stream Redis dan dikirim ke client
+// sebagai id SSE agar bisa dipakai lagi sebagai Last-Event-ID
+type Entry struct {
+	ID    string
+	Event event.CloudEvent
+}
+
+// RedisBuffer menyimpan event live terakhir di stream Redis yang dipangkas ke sekitar maxLen entry.
+// Buffer ini dipakai bersama oleh semua instance: hanya satu instance (lewat consumer group) yang
+// menulis setiap event, dan setiap instance membaca ulang buffer untuk client-nya sendiri.
+type RedisBuffer struct {
+	client *redis.Client
+	maxLen int64
+}
+
+// NewRedisBuffer membuat RedisBuffer. Client dimiliki pemanggil.
+func NewRedisBuffer(client *redis.Client, maxLen int64) *RedisBuffer {
+	return &RedisBuffer{client: client, maxLen: maxLen}
+}
+
+// Ingest menambahkan event user.* dan repo.* ke buffer; dipakai sebagai handler consumer.
+// Event replika dari sync worker dilewati karena perubahan yang sama sudah dikirim oleh backend asalnya.
+func (b *RedisBuffer) Ingest(ctx context.Context, ce event.CloudEvent) error {
+	if ce.IsReplica() || !event.MatchType(liveTypes, ce.Type) {
+		return nil
+	}
+	body, err := json.Marshal(ce)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cloudevent: %w", err)
+	}
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: bufferKey,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": body},
+	}).Err()
+}
+
+// Since mengembalikan entry setelah lastID sesuai urutan. truncated bernilai true jika entry
+// tertua di buffer lebih baru dari lastID, artinya sebagian event mungkin sudah terpangkas.
+func (b *RedisBuffer) Since(ctx context.Context, lastID string) (entries []Entry, truncated bool, err error) {
+	if _, _, err := parseID(lastID); err != nil {
+		return nil, false, err
+	}
+
+	oldest, err := b.client.XRangeN(ctx, bufferKey, "-", "+", 1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(oldest) > 0 && CompareID(oldest[0].ID, lastID) > 0 {
+		truncated = true
+	}
+
+	// XRANGE inklusif; entry lastID sendiri sudah diterima client
+	messages, err := b.client.XRange(ctx, bufferKey, lastID, "+").Result()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, m := range messages {
+		if m.ID == lastID {
+			continue
+		}
+		entry, err := decodeEntry(m)
+		if err != nil {
+			log.Printf("⚠️ Skipping live event %s: %v", m.ID, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, truncated, nil
+}
+
+// Tail memanggil fn untuk setiap entry baru sejak Tail dipanggil sampai ctx selesai
+func (b *RedisBuffer) Tail(ctx context.Context, fn func(Entry)) error {
+	lastID := "0-0"
+	latest, err := b.client.XRevRangeN(ctx, bufferKey, "+", "-", 1).Result()
+	if err != nil {
+		return err
+	}
+	if len(latest) > 0 {
+		lastID = latest[0].ID
+	}
+
+	for ctx.Err() == nil {
+		streams, err := b.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{bufferKey, lastID},
+			Count:   100,
+			Block:   5 * time.Second,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("❌ Failed to read live event buffer: %v", err)
+			sleepCtx(ctx, time.Second)
+			continue
+		}
+
+		for _, s := range streams {
+			for _, m := range s.Messages {
+				lastID = m.ID
+				entry, err := decodeEntry(m)
+				if err != nil {
+					log.Printf("⚠️ Skipping live event %s: %v", m.ID, err)
+					continue
+				}
+				fn(entry)
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// decodeEntry membaca CloudEvent dari entry stream
+func decodeEntry(m redis.XMessage) (Entry, error) {
+	body, ok := m.Values["event"].(string)
+	if !ok {
+		return Entry{}, errors.New("entry has no event field")
+	}
+	var ce event.CloudEvent
+	if err := json.Unmarshal([]byte(body), &ce); err != nil {
+		return Entry{}, err
+	}
+	return Entry{ID: m.ID, Event: ce}, nil
+}
+
+// CompareID membandingkan dua id entry stream Redis; id yang tidak valid dianggap paling lama
+func CompareID(a, b string) int {
+	aMs, aSeq, _ := parseID(a)
+	bMs, bSeq, _ := parseID(b)
+	if c := cmp.Compare(aMs, bMs); c != 0 {
+		return c
+	}
+	return cmp.Compare(aSeq, bSeq)
+}
+
+// parseID memecah id "<ms>-<seq>"
+func parseID(id string) (ms, seq uint64, err error) {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, ErrInvalidEventID
+	}
+	if ms, err = strconv.ParseUint(msPart, 10, 64); err != nil {
+		return 0, 0, ErrInvalidEventID
+	}
+	if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+		return 0, 0, ErrInvalidEventID
+	}
+	return ms, seq, nil
+}
+
+// sleepCtx menunggu d atau sampai ctx selesai
+func sleepCtx(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+
+	"golang-crud-clean-arch/internal/event"
+)
+
+var (
+	// ErrSlowClient adalah alasan client diputus karena antreannya penuh
+	ErrSlowClient = errors.New("client is too slow, reconnect with Last-Event-ID to resume")
+	// ErrHubClosed adalah alasan client diputus saat server berhenti
+	ErrHubClosed = errors.New("live event stream is shutting down")
+)
+
+// Filter memilih event untuk satu koneksi. Types memakai pola yang sama dengan langganan
+// webhook ("user.created", "repo.*"); EntityIDs dicocokkan dengan subject event.
+// Filter kosong menerima semua event.
+type Filter struct {
+	Types     []string
+	EntityIDs []string
+}
+
+// Matches melaporkan apakah ce lolos filter
+func (f Filter) Matches(ce event.CloudEvent) bool {
+	if !event.MatchType(f.Types, ce.Type) {
+		return false
+	}
+	return len(f.EntityIDs) == 0 || slices.Contains(f.EntityIDs, ce.Subject)
+}
+
+// Client adalah satu koneksi SSE atau WebSocket yang terdaftar di Hub
+type Client struct {
+	filter Filter
+	events chan Entry
+	err    error // alasan Events ditutup oleh hub, dibaca setelah channel tertutup
+}
+
+// Events mengirim entry live yang lolos filter. Channel ditutup jika client diputus oleh hub;
+// alasannya tersedia lewat Err.
+func (c *Client) Events() <-chan Entry {
+	return c.events
+}
+
+// Err mengembalikan alasan client diputus oleh hub (ErrSlowClient atau ErrHubClosed)
+func (c *Client) Err() error {
+	return c.err
+}
+
+// Hub membaca buffer live dan meneruskan setiap entry ke semua client yang filternya cocok.
+// Setiap client punya antrean terbatas; client yang antreannya penuh diputus (ErrSlowClient)
+// agar tidak menahan client lain, lalu bisa tersambung ulang dan melanjutkan dari Last-Event-ID.
+type Hub struct {
+	buffer    *RedisBuffer
+	queueSize int
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	closed  bool
+}
+
+// NewHub membuat Hub dengan antrean queueSize entry per client
+func NewHub(buffer *RedisBuffer, queueSize int) *Hub {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	return &Hub{
+		buffer:    buffer,
+		queueSize: queueSize,
+		clients:   map[*Client]struct{}{},
+	}
+}
+
+// Run meneruskan entry baru ke client sampai ctx selesai, lalu memutus semua client
+func (h *Hub) Run(ctx context.Context) {
+	log.Println("📡 Live event stream started")
+	err := h.buffer.Tail(ctx, h.broadcast)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("❌ Live event stream stopped: %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for c := range h.clients {
+		h.drop(c, ErrHubClosed)
+	}
+}
+
+// broadcast memasukkan entry ke antrean setiap client yang filternya cocok
+func (h *Hub) broadcast(entry Entry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if !c.filter.Matches(entry.Event) {
+			continue
+		}
+		select {
+		case c.events <- entry:
+		default:
+			log.Printf("⚠️ Dropping slow live client after %d queued events", h.queueSize)
+			h.drop(c, ErrSlowClient)
+		}
+	}
+}
+
+// Subscribe mendaftarkan client baru. Jika lastEventID diisi, entry setelahnya yang lolos filter
+// dikembalikan sebagai replay; truncated bernilai true jika sebagian event sudah terpangkas dari
+// buffer sehingga client perlu memuat ulang datanya. Client didaftarkan sebelum buffer dibaca,
+// jadi entry yang sama bisa muncul di replay dan di Events; lewati entry live dengan id yang
+// tidak lebih baru dari entry terakhir yang dikirim (CompareID).
+func (h *Hub) Subscribe(ctx context.Context, filter Filter, lastEventID string) (client *Client, replay []Entry, truncated bool, err error) {
+	client = &Client{filter: filter, events: make(chan Entry, h.queueSize)}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil, nil, false, ErrHubClosed
+	}
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+
+	if lastEventID == "" {
+		return client, nil, false, nil
+	}
+
+	entries, truncated, err := h.buffer.Since(ctx, lastEventID)
+	if err != nil {
+		h.Unsubscribe(client)
+		return nil, nil, false, err
+	}
+	for _, entry := range entries {
+		if filter.Matches(entry.Event) {
+			replay = append(replay, entry)
+		}
+	}
+	return client, replay, truncated, nil
+}
+
+// Unsubscribe melepas client; aman dipanggil setelah client diputus oleh hub
+func (h *Hub) Unsubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		close(client.events)
+	}
+}
+
+// drop memutus client dengan alasan err; h.mu harus sudah dipegang
+func (h *Hub) drop(c *Client, err error) {
+	c.err = err
+	delete(h.clients, c)
+	close(c.events)
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/event"
+)
+
+// runBatch memvalidasi setiap operasi, menjalankan operasi yang valid lewat apply,
+// lalu menyusun hasil per operasi sesuai urutan di request.
+func runBatch[T any](
+	req entity.BatchRequest[T],
+	validate func(*T) error,
+	apply func(ops []entity.BatchOperation[T]) ([]error, error),
+) (*entity.BatchResult, error) {
+	if len(req.Operations) == 0 || len(req.Operations) > entity.MaxBatchSize {
+		return nil, fmt.Errorf("%w: operations must contain 1..%d items", entity.ErrInvalidBatch, entity.MaxBatchSize)
+	}
+
+	errs := make([]error, len(req.Operations))
+	for i, op := range req.Operations {
+		errs[i] = precheckBatchOp(op, validate)
+	}
+
+	// Operasi yang valid diteruskan ke repository, positions menyimpan index aslinya
+	var (
+		valid     []entity.BatchOperation[T]
+		positions []int
+	)
+	if !req.Atomic || !anyFailed(errs) {
+		for i, op := range req.Operations {
+			if errs[i] == nil {
+				valid = append(valid, op)
+				positions = append(positions, i)
+			}
+		}
+	}
+
+	if len(valid) > 0 {
+		applyErrs, err := apply(valid)
+		if err != nil {
+			return nil, err
+		}
+		for j, err := range applyErrs {
+			errs[positions[j]] = err
+		}
+	}
+
+	return buildBatchResult(req, errs), nil
+}
+
+// precheckBatchOp memeriksa kelengkapan satu operasi sebelum menyentuh database
+func precheckBatchOp[T any](op entity.BatchOperation[T], validate func(*T) error) error {
+	switch op.Op {
+	case entity.BatchCreate, entity.BatchUpdate:
+		if op.Op == entity.BatchUpdate && op.ID == "" {
+			return errors.New("id is required for update")
+		}
+		if op.Data == nil {
+			return fmt.Errorf("data is required for %s", op.Op)
+		}
+		return validate(op.Data)
+	case entity.BatchDelete:
+		if op.ID == "" {
+			return errors.New("id is required for delete")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown op %q (expected create, update or delete)", op.Op)
+	}
+}
+
+// buildBatchResult menyusun hasil per operasi. Pada batch atomic yang gagal,
+// operasi yang tidak gagal ditandai aborted karena tidak ada yang tersimpan.
+func buildBatchResult[T any](req entity.BatchRequest[T], errs []error) *entity.BatchResult {
+	aborted := req.Atomic && anyFailed(errs)
+	result := &entity.BatchResult{
+		Atomic:  req.Atomic,
+		Results: make([]entity.BatchItemResult, len(req.Operations)),
+	}
+
+	for i, op := range req.Operations {
+		item := entity.BatchItemResult{Index: i, Op: op.Op}
+		if op.ID != "" {
+			item.ID = op.ID
+		}
+
+		switch {
+		case errs[i] != nil:
+			item.Status = entity.BatchStatusFailed
+			item.Error = errs[i].Error()
+			result.Failed++
+		case aborted:
+			item.Status = entity.BatchStatusAborted
+			result.Failed++
+		default:
+			item.Status = batchSuccessStatus(op.Op)
+			if op.Data != nil && op.Op != entity.BatchDelete {
+				item.Data = op.Data
+			}
+			result.Succeeded++
+		}
+		result.Results[i] = item
+	}
+	return result
+}
+
+func batchSuccessStatus(op entity.BatchOp) string {
+	switch op {
+	case entity.BatchCreate:
+		return entity.BatchStatusCreated
+	case entity.BatchUpdate:
+		return entity.BatchStatusUpdated
+	default:
+		return entity.BatchStatusDeleted
+	}
+}
+
+// batchEvents membuat event untuk setiap operasi yang berhasil, misalnya prefix "user" menghasilkan user.created
+func batchEvents(prefix string, result *entity.BatchResult) []event.Message {
+	var messages []event.Message
+	for _, item := range result.Results {
+		var data interface{}
+		switch item.Status {
+		case entity.BatchStatusCreated, entity.BatchStatusUpdated:
+			data = item.Data
+		case entity.BatchStatusDeleted:
+			data = map[string]interface{}{"id": item.ID}
+		default:
+			continue
+		}
+
+		eventType := fmt.Sprintf("%s.%s", prefix, item.Status)
+		messages = append(messages, event.Message{Key: eventType, Value: data})
+	}
+	return messages
+}
+
+func anyFailed(errs []error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
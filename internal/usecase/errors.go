@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"golang-crud-clean-arch/internal/entity"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// isClientError menandai error akibat input client (cursor/filter tidak valid, versi tidak cocok, data tidak ada).
+// Error ini tidak dihitung sebagai kegagalan oleh circuit breaker.
+func isClientError(err error) bool {
+	return errors.Is(err, entity.ErrInvalidCursor) ||
+		errors.Is(err, entity.ErrInvalidFilter) ||
+		errors.Is(err, entity.ErrVersionConflict) ||
+		errors.Is(err, entity.ErrNotFound)
+}
+
+// isMissing melaporkan apakah err berarti entitas tidak ada di backend mana pun
+func isMissing(err error) bool {
+	return errors.Is(err, entity.ErrNotFound) || errors.Is(err, sql.ErrNoRows) || errors.Is(err, mongo.ErrNoDocuments)
+}
+
+// notFoundOr membungkus error "tidak ada" dengan entity.ErrNotFound; error lain (database mati,
+// timeout) diteruskan apa adanya agar menjadi 500, bukan 404
+func notFoundOr(err error) error {
+	if isMissing(err) && !errors.Is(err, entity.ErrNotFound) {
+		return fmt.Errorf("%w: %v", entity.ErrNotFound, err)
+	}
+	return err
+}
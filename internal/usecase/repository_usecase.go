@@ -8,10 +8,12 @@ import (
 
 	"golang-crud-clean-arch/internal/entity"
 	"golang-crud-clean-arch/internal/event"
+	"golang-crud-clean-arch/internal/patch"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/sony/gobreaker"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -20,8 +22,11 @@ type RepoRepository interface {
 	Create(ctx context.Context, repo *entity.Repository) error
 	GetByID(ctx context.Context, id interface{}) (*entity.Repository, error)
 	Update(ctx context.Context, repo *entity.Repository) error
-	Delete(ctx context.Context, id interface{}) error
-	GetAllRepositories(ctx context.Context) ([]entity.Repository, error)
+	Delete(ctx context.Context, id interface{}, expectedVersion int64) error
+	Restore(ctx context.Context, id interface{}) (*entity.Repository, error)
+	Purge(ctx context.Context, id interface{}) error
+	ApplyBatch(ctx context.Context, ops []entity.BatchOperation[entity.Repository], atomic bool) ([]error, error)
+	GetAllRepositories(ctx context.Context, filter entity.RepositoryFilter, page entity.PageRequest) (*entity.RepositoryPage, error)
 }
 
 type RepositoryUsecase struct {
@@ -30,9 +35,12 @@ type RepositoryUsecase struct {
 	cb        *gobreaker.CircuitBreaker
 	tracer    trace.Tracer
 	publisher event.EventPublisher
+	tx        Transactor // nil jika backend tidak mendukung transaksi (MongoDB)
 }
 
-func NewRepositoryUsecase(repo RepoRepository, redis *redis.Client, publisher event.EventPublisher) *RepositoryUsecase {
+// NewRepositoryUsecase membuat RepositoryUsecase. tx boleh nil; jika diisi, perubahan repository
+// dan event-nya dijalankan dalam satu transaksi (transactional outbox).
+func NewRepositoryUsecase(repo RepoRepository, redis *redis.Client, publisher event.EventPublisher, tx Transactor) *RepositoryUsecase {
 	cbSettings := gobreaker.Settings{
 		Name:        "RepoGetAllBreaker",
 		MaxRequests: 1,
@@ -41,6 +49,10 @@ func NewRepositoryUsecase(repo RepoRepository, redis *redis.Client, publisher ev
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
 			return counts.ConsecutiveFailures >= 3
 		},
+		// Cursor/filter yang tidak valid adalah kesalahan client, bukan kegagalan service
+		IsSuccessful: func(err error) bool {
+			return err == nil || isClientError(err)
+		},
 	}
 	return &RepositoryUsecase{
 		repo:      repo,
@@ -48,6 +60,7 @@ func NewRepositoryUsecase(repo RepoRepository, redis *redis.Client, publisher ev
 		cb:        gobreaker.NewCircuitBreaker(cbSettings),
 		tracer:    otel.Tracer("repository-usecase"),
 		publisher: publisher,
+		tx:        tx,
 	}
 }
 
@@ -61,28 +74,33 @@ func (u *RepositoryUsecase) CreateRepository(ctx context.Context, repo *entity.R
 		return err
 	}
 
-	err := u.repo.Create(ctx, repo)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Create failed")
-		return err
-	}
-
-	// Publish Kafka event
-	eventData := entity.Event{
-		Type: "repo.created",
-		Data: repo,
-	}
-	if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
-		log.Printf("❌ Failed to publish Kafka event: %v", err)
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Kafka publish failed")
-	} else {
-		log.Println("✅ Kafka event published: repo.created")
-		span.SetStatus(codes.Ok, "Repository created & event published")
-	}
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, repo); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Create failed")
+			return err
+		}
 
-	return nil
+		// Publish Kafka event
+		eventData := entity.Event{
+			Type: "repo.created",
+			Data: repo,
+		}
+		if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
+			log.Printf("❌ Failed to publish Kafka event: %v", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka publish failed")
+			// Dengan outbox, event yang gagal disimpan ikut membatalkan perubahan
+			if u.tx != nil {
+				return err
+			}
+		} else {
+			log.Println("✅ Kafka event published: repo.created")
+			span.SetStatus(codes.Ok, "Repository created & event published")
+		}
+		return nil
+	})
+	return err
 }
 
 func (u *RepositoryUsecase) GetRepository(ctx context.Context, id interface{}) (*entity.Repository, error) {
@@ -110,75 +128,291 @@ func (u *RepositoryUsecase) UpdateRepository(ctx context.Context, repo *entity.R
 		return err
 	}
 
-	err := u.repo.Update(ctx, repo)
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, repo); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Update failed")
+			return err
+		}
+
+		// Publish Kafka event
+		eventData := entity.Event{
+			Type: "repo.updated",
+			Data: repo,
+		}
+		if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
+			log.Printf("❌ Failed to publish Kafka event: %v", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka publish failed")
+			// Dengan outbox, event yang gagal disimpan ikut membatalkan perubahan
+			if u.tx != nil {
+				return err
+			}
+		} else {
+			log.Println("✅ Kafka event published: repo.updated")
+			span.SetStatus(codes.Ok, "Repository updated & event published")
+		}
+		return nil
+	})
+	return err
+}
+
+// repositoryReadOnlyFields tidak boleh diubah lewat PATCH
+var repositoryReadOnlyFields = []string{"id", "user_id", "created_at", "updated_at", "version", "deleted_at"}
+
+// PatchRepository menerapkan merge patch / JSON patch ke repository yang tersimpan, memvalidasi ulang hasilnya,
+// lalu menyimpan dengan pengecekan versi. expectedVersion > 0 berasal dari If-Match.
+func (u *RepositoryUsecase) PatchRepository(ctx context.Context, id interface{}, p patch.Patch, expectedVersion int64) (*entity.Repository, error) {
+	ctx, span := u.tracer.Start(ctx, "PatchRepository")
+	defer span.End()
+
+	current, err := u.repo.GetByID(ctx, id)
 	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "Update failed")
-		return err
+		span.SetStatus(codes.Error, "Get failed")
+		return nil, notFoundOr(err)
+	}
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		span.SetStatus(codes.Error, "Version mismatch")
+		return nil, entity.ErrVersionConflict
 	}
 
-	// Publish Kafka event
-	eventData := entity.Event{
-		Type: "repo.updated",
-		Data: repo,
+	var patched entity.Repository
+	if err := patch.ApplyTo(current, p, &patched, repositoryReadOnlyFields...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Patch failed")
+		return nil, err
 	}
-	if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
-		log.Printf("❌ Failed to publish Kafka event: %v", err)
+	// user_id read-only, pertahankan tipe aslinya (uuid.UUID / string) dari data tersimpan
+	patched.UserID = current.UserID
+	if err := patched.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Validation failed")
+		return nil, fmt.Errorf("%w: %v", entity.ErrValidation, err)
+	}
+
+	changes, err := patch.Changes(current, &patched, "updated_at", "version")
+	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "Kafka publish failed")
-	} else {
-		log.Println("✅ Kafka event published: repo.updated")
-		span.SetStatus(codes.Ok, "Repository updated & event published")
+		span.SetStatus(codes.Error, "Diff failed")
+		return nil, err
+	}
+	if len(changes) == 0 {
+		span.SetStatus(codes.Ok, "Repository unchanged")
+		return current, nil
+	}
+
+	patched.ID = current.ID
+	patched.Version = current.Version
+	err = withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, &patched); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Update failed")
+			return err
+		}
+
+		// Publish Kafka event
+		eventData := entity.Event{
+			Type: "repo.updated",
+			Data: entity.RepositoryChanges{Repository: &patched, ChangedFields: changes},
+		}
+		if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
+			log.Printf("❌ Failed to publish Kafka event: %v", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka publish failed")
+			// Dengan outbox, event yang gagal disimpan ikut membatalkan perubahan
+			if u.tx != nil {
+				return err
+			}
+		} else {
+			log.Println("✅ Kafka event published: repo.updated")
+			span.SetStatus(codes.Ok, "Repository patched & event published")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &patched, nil
 }
 
-func (u *RepositoryUsecase) DeleteRepository(ctx context.Context, id interface{}) error {
+// DeleteRepository melakukan soft delete; expectedVersion > 0 mengaktifkan pengecekan versi (If-Match)
+func (u *RepositoryUsecase) DeleteRepository(ctx context.Context, id interface{}, expectedVersion int64) error {
 	ctx, span := u.tracer.Start(ctx, "DeleteRepository")
 	defer span.End()
 
-	err := u.repo.Delete(ctx, id)
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, id, expectedVersion); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Delete failed")
+			return err
+		}
+
+		// Publish Kafka event
+		eventData := entity.Event{
+			Type: "repo.deleted",
+			Data: map[string]interface{}{"id": id},
+		}
+		if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
+			log.Printf("❌ Failed to publish Kafka event: %v", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka publish failed")
+			// Dengan outbox, event yang gagal disimpan ikut membatalkan perubahan
+			if u.tx != nil {
+				return err
+			}
+		} else {
+			log.Println("✅ Kafka event published: repo.deleted")
+			span.SetStatus(codes.Ok, "Repository deleted & event published")
+		}
+		return nil
+	})
+	return err
+}
+
+// BatchRepositories menjalankan banyak operasi create/update/delete sekaligus dan mengembalikan hasil per operasi
+func (u *RepositoryUsecase) BatchRepositories(ctx context.Context, req entity.RepositoryBatchRequest) (*entity.BatchResult, error) {
+	ctx, span := u.tracer.Start(ctx, "BatchRepositories")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("batch.size", len(req.Operations)),
+		attribute.Bool("batch.atomic", req.Atomic),
+	)
+
+	var result *entity.BatchResult
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		var err error
+		result, err = runBatch(req, (*entity.Repository).Validate, func(ops []entity.BatchOperation[entity.Repository]) ([]error, error) {
+			return u.repo.ApplyBatch(ctx, ops, req.Atomic)
+		})
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Batch failed")
+			return err
+		}
+
+		// Publish Kafka events dalam satu batch
+		if err := event.PublishAll(ctx, u.publisher, "repo-events", batchEvents("repo", result)); err != nil {
+			log.Printf("❌ Failed to publish Kafka events: %v", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka publish failed")
+			// Dengan outbox, event yang gagal disimpan ikut membatalkan batch
+			if u.tx != nil {
+				return err
+			}
+		} else {
+			log.Printf("✅ Kafka events published for repository batch (%d succeeded)", result.Succeeded)
+			span.SetStatus(codes.Ok, "Repository batch applied & events published")
+		}
+		return nil
+	})
 	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Delete failed")
-		return err
+		return nil, err
 	}
 
-	// Publish Kafka event
-	eventData := entity.Event{
-		Type: "repo.deleted",
-		Data: map[string]interface{}{"id": id},
-	}
-	if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
-		log.Printf("❌ Failed to publish Kafka event: %v", err)
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Kafka publish failed")
-	} else {
-		log.Println("✅ Kafka event published: repo.deleted")
-		span.SetStatus(codes.Ok, "Repository deleted & event published")
+	return result, nil
+}
+
+func (u *RepositoryUsecase) RestoreRepository(ctx context.Context, id interface{}) (*entity.Repository, error) {
+	ctx, span := u.tracer.Start(ctx, "RestoreRepository")
+	defer span.End()
+
+	var repo *entity.Repository
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		var err error
+		if repo, err = u.repo.Restore(ctx, id); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Restore failed")
+			return err
+		}
+
+		// Publish Kafka event
+		eventData := entity.Event{
+			Type: "repo.restored",
+			Data: repo,
+		}
+		if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
+			log.Printf("❌ Failed to publish Kafka event: %v", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka publish failed")
+			// Dengan outbox, event yang gagal disimpan ikut membatalkan perubahan
+			if u.tx != nil {
+				return err
+			}
+		} else {
+			log.Println("✅ Kafka event published: repo.restored")
+			span.SetStatus(codes.Ok, "Repository restored & event published")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return repo, nil
+}
+
+func (u *RepositoryUsecase) PurgeRepository(ctx context.Context, id interface{}) error {
+	ctx, span := u.tracer.Start(ctx, "PurgeRepository")
+	defer span.End()
+
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Purge(ctx, id); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Purge failed")
+			return err
+		}
+
+		// Publish Kafka event
+		eventData := entity.Event{
+			Type: "repo.purged",
+			Data: map[string]interface{}{"id": id},
+		}
+		if err := u.publisher.Publish(ctx, "repo-events", eventData.Type, eventData.Data); err != nil {
+			log.Printf("❌ Failed to publish Kafka event: %v", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka publish failed")
+			// Dengan outbox, event yang gagal disimpan ikut membatalkan perubahan
+			if u.tx != nil {
+				return err
+			}
+		} else {
+			log.Println("✅ Kafka event published: repo.purged")
+			span.SetStatus(codes.Ok, "Repository purged & event published")
+		}
+		return nil
+	})
+	return err
 }
 
-func (u *RepositoryUsecase) GetAllRepositories(ctx context.Context) ([]entity.Repository, error) {
+func (u *RepositoryUsecase) GetAllRepositories(ctx context.Context, filter entity.RepositoryFilter, page entity.PageRequest) (*entity.RepositoryPage, error) {
 	ctx, span := u.tracer.Start(ctx, "GetAllRepositories")
 	defer span.End()
 
-	span.SetAttributes()
+	span.SetAttributes(
+		attribute.Int("page.limit", page.Limit),
+		attribute.Bool("page.has_cursor", page.Cursor != ""),
+		attribute.String("filter", filter.String()),
+	)
 
 	result, err := u.cb.Execute(func() (interface{}, error) {
-		return u.repo.GetAllRepositories(ctx)
+		return u.repo.GetAllRepositories(ctx, filter, page)
 	})
 
+	if isClientError(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request")
+		return nil, err
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Circuit breaker triggered")
 		return nil, fmt.Errorf("repository service unavailable: %w", err)
 	}
 
-	repos, ok := result.([]entity.Repository)
+	repos, ok := result.(*entity.RepositoryPage)
 	if !ok {
 		span.SetStatus(codes.Error, "Type assertion failed")
 		return nil, fmt.Errorf("repository service: type assertion failed")
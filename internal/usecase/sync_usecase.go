@@ -0,0 +1,500 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/event"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IDMappingStore menyimpan pasangan id PostgreSQL ↔ MongoDB, diimplementasikan oleh
+// repository.IDMappingRepositoryPostgres
+type IDMappingStore interface {
+	Lookup(ctx context.Context, entityName, backend, id string) (string, error)
+	Save(ctx context.Context, m entity.IDMapping) error
+	Delete(ctx context.Context, entityName, backend, id string) error
+	List(ctx context.Context, entityName string) ([]entity.IDMapping, error)
+}
+
+// SyncUsecase menerapkan event user/repository dari satu backend ke backend lainnya lewat
+// repository masing-masing. Repository tidak mempublikasikan event (hanya usecase yang melakukannya),
+// jadi penulisan sync tidak menghasilkan event baru; event.WithOrigin tetap dipasang agar event yang
+// mungkin dihasilkan backend tujuan ditandai sebagai replika dan tidak disinkronkan balik.
+type SyncUsecase struct {
+	users    map[string]UserRepository // key: event.BackendPostgres / event.BackendMongo
+	repos    map[string]RepoRepository
+	mappings IDMappingStore
+	tracer   trace.Tracer
+}
+
+// NewSyncUsecase membuat SyncUsecase dari repository kedua backend
+func NewSyncUsecase(pgUsers, mongoUsers UserRepository, pgRepos, mongoRepos RepoRepository, mappings IDMappingStore) *SyncUsecase {
+	return &SyncUsecase{
+		users:    map[string]UserRepository{event.BackendPostgres: pgUsers, event.BackendMongo: mongoUsers},
+		repos:    map[string]RepoRepository{event.BackendPostgres: pgRepos, event.BackendMongo: mongoRepos},
+		mappings: mappings,
+		tracer:   otel.Tracer("sync-usecase"),
+	}
+}
+
+// ApplyUserEvent menerapkan event user.* ke backend pasangan dari backend asal event
+func (u *SyncUsecase) ApplyUserEvent(ctx context.Context, ce event.CloudEvent) error {
+	source, target, ok := syncDirection(ce)
+	if !ok {
+		return nil
+	}
+
+	ctx, span := u.tracer.Start(ctx, "SyncUserEvent")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("event.type", ce.Type),
+		attribute.String("sync.source", source),
+		attribute.String("sync.target", target),
+	)
+
+	err := u.applyUserEvent(event.WithOrigin(ctx, source), ce, source, target)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "sync failed")
+		return err
+	}
+	span.SetStatus(codes.Ok, "synced")
+	return nil
+}
+
+func (u *SyncUsecase) applyUserEvent(ctx context.Context, ce event.CloudEvent, source, target string) error {
+	repo := u.users[target]
+
+	switch ce.Type {
+	case "user.created", "user.updated":
+		var changes entity.UserChanges
+		if err := ce.DataAs(&changes); err != nil {
+			return err
+		}
+		if changes.User == nil {
+			return nil
+		}
+		sourceID := syncID(changes.ID)
+		targetID, err := u.lookup(ctx, entity.SyncEntityUser, source, sourceID)
+		if err != nil {
+			return err
+		}
+
+		user := entity.User{Name: changes.Name, Email: changes.Email}
+		if targetID == "" {
+			// Create dan Save pemetaan tidak atomik. Jika Save gagal setelah Create, retry
+			// menemukan user yang sudah dibuat lewat email (unik) alih-alih membuat duplikat.
+			existing, err := userByEmail(ctx, repo, changes.Email)
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				if err := repo.Create(ctx, &user); err != nil {
+					return err
+				}
+				log.Printf("🔁 Synced user %s (%s) → %s (%s)", sourceID, source, user.GetIDString(), target)
+				return u.mappings.Save(ctx, newIDMapping(entity.SyncEntityUser, source, sourceID, user.GetIDString()))
+			}
+
+			targetID = existing.GetIDString()
+			log.Printf("🔁 Linked user %s (%s) → existing %s (%s)", sourceID, source, targetID, target)
+			if err := u.mappings.Save(ctx, newIDMapping(entity.SyncEntityUser, source, sourceID, targetID)); err != nil {
+				return err
+			}
+		}
+		if ce.Type == "user.created" {
+			return nil // sudah pernah disinkronkan
+		}
+		user.ID = targetID
+		return ignoreMissing(repo.Update(ctx, &user))
+
+	case "user.deleted", "user.restored", "user.purged":
+		sourceID, targetID, err := u.lookupRef(ctx, ce, entity.SyncEntityUser, source)
+		if err != nil || targetID == "" {
+			return err
+		}
+		switch ce.Type {
+		case "user.deleted":
+			return ignoreMissing(repo.Delete(ctx, targetID, 0))
+		case "user.restored":
+			_, err := repo.Restore(ctx, targetID)
+			return ignoreMissing(err)
+		default:
+			if err := ignoreMissing(repo.Purge(ctx, targetID)); err != nil {
+				return err
+			}
+			return u.mappings.Delete(ctx, entity.SyncEntityUser, source, sourceID)
+		}
+	}
+	return nil
+}
+
+// ApplyRepositoryEvent menerapkan event repo.* ke backend pasangan dari backend asal event.
+// user_id diterjemahkan lewat pemetaan user, sehingga user harus sudah tersinkron lebih dulu.
+func (u *SyncUsecase) ApplyRepositoryEvent(ctx context.Context, ce event.CloudEvent) error {
+	source, target, ok := syncDirection(ce)
+	if !ok {
+		return nil
+	}
+
+	ctx, span := u.tracer.Start(ctx, "SyncRepositoryEvent")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("event.type", ce.Type),
+		attribute.String("sync.source", source),
+		attribute.String("sync.target", target),
+	)
+
+	err := u.applyRepositoryEvent(event.WithOrigin(ctx, source), ce, source, target)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "sync failed")
+		return err
+	}
+	span.SetStatus(codes.Ok, "synced")
+	return nil
+}
+
+func (u *SyncUsecase) applyRepositoryEvent(ctx context.Context, ce event.CloudEvent, source, target string) error {
+	repo := u.repos[target]
+
+	switch ce.Type {
+	case "repo.created", "repo.updated":
+		var changes entity.RepositoryChanges
+		if err := ce.DataAs(&changes); err != nil {
+			return err
+		}
+		if changes.Repository == nil {
+			return nil
+		}
+		sourceID := syncID(changes.ID)
+		targetID, err := u.lookup(ctx, entity.SyncEntityRepository, source, sourceID)
+		if err != nil {
+			return err
+		}
+		if targetID != "" && ce.Type == "repo.created" {
+			return nil // sudah pernah disinkronkan
+		}
+
+		userID, err := u.lookup(ctx, entity.SyncEntityUser, source, syncID(changes.UserID))
+		if err != nil {
+			return err
+		}
+		if userID == "" {
+			return fmt.Errorf("user %v of repository %s is not synced to %s yet", changes.UserID, sourceID, target)
+		}
+
+		data := entity.Repository{
+			UserID:    userID,
+			Name:      changes.Name,
+			URL:       changes.URL,
+			AIEnabled: changes.AIEnabled,
+		}
+		if targetID == "" {
+			// Sama seperti user: retry setelah Save pemetaan gagal menemukan repository
+			// yang sudah dibuat lewat url dan pemiliknya, bukan membuat duplikat
+			existing, err := repositoryByURL(ctx, repo, userID, changes.URL)
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				if err := repo.Create(ctx, &data); err != nil {
+					return err
+				}
+				log.Printf("🔁 Synced repository %s (%s) → %s (%s)", sourceID, source, syncID(data.ID), target)
+				return u.mappings.Save(ctx, newIDMapping(entity.SyncEntityRepository, source, sourceID, syncID(data.ID)))
+			}
+
+			targetID = syncID(existing.ID)
+			log.Printf("🔁 Linked repository %s (%s) → existing %s (%s)", sourceID, source, targetID, target)
+			if err := u.mappings.Save(ctx, newIDMapping(entity.SyncEntityRepository, source, sourceID, targetID)); err != nil {
+				return err
+			}
+			if ce.Type == "repo.created" {
+				return nil
+			}
+		}
+		data.ID = targetID
+		return ignoreMissing(repo.Update(ctx, &data))
+
+	case "repo.deleted", "repo.restored", "repo.purged":
+		sourceID, targetID, err := u.lookupRef(ctx, ce, entity.SyncEntityRepository, source)
+		if err != nil || targetID == "" {
+			return err
+		}
+		switch ce.Type {
+		case "repo.deleted":
+			return ignoreMissing(repo.Delete(ctx, targetID, 0))
+		case "repo.restored":
+			_, err := repo.Restore(ctx, targetID)
+			return ignoreMissing(err)
+		default:
+			if err := ignoreMissing(repo.Purge(ctx, targetID)); err != nil {
+				return err
+			}
+			return u.mappings.Delete(ctx, entity.SyncEntityRepository, source, sourceID)
+		}
+	}
+	return nil
+}
+
+// lookup mengembalikan id pasangan, string kosong jika belum ada pemetaan
+func (u *SyncUsecase) lookup(ctx context.Context, entityName, source, id string) (string, error) {
+	targetID, err := u.mappings.Lookup(ctx, entityName, source, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		return "", nil
+	}
+	return targetID, err
+}
+
+// userByEmail mencari user di backend tujuan berdasarkan email, termasuk yang sudah di-soft-delete.
+// nil jika tidak ada.
+func userByEmail(ctx context.Context, repo UserRepository, email string) (*entity.User, error) {
+	if email == "" {
+		return nil, nil
+	}
+	page, err := repo.GetAll(ctx, entity.UserFilter{Email: email, Deleted: entity.DeletedIncluded}, entity.PageRequest{Limit: 1})
+	if err != nil || len(page.Data) == 0 {
+		return nil, err
+	}
+	return &page.Data[0], nil
+}
+
+// repositoryByURL mencari repository milik userID di backend tujuan berdasarkan url,
+// termasuk yang sudah di-soft-delete. nil jika tidak ada.
+func repositoryByURL(ctx context.Context, repo RepoRepository, userID, url string) (*entity.Repository, error) {
+	if url == "" {
+		return nil, nil
+	}
+	filter := entity.RepositoryFilter{UserID: userID, URL: url, Deleted: entity.DeletedIncluded}
+	page, err := repo.GetAllRepositories(ctx, filter, entity.PageRequest{Limit: 1})
+	if err != nil || len(page.Data) == 0 {
+		return nil, err
+	}
+	return &page.Data[0], nil
+}
+
+// lookupRef membaca payload {"id": ...} lalu mencari id pasangannya
+func (u *SyncUsecase) lookupRef(ctx context.Context, ce event.CloudEvent, entityName, source string) (string, string, error) {
+	var ref struct {
+		ID interface{} `json:"id"`
+	}
+	if err := ce.DataAs(&ref); err != nil {
+		return "", "", err
+	}
+	sourceID := syncID(ref.ID)
+	targetID, err := u.lookup(ctx, entityName, source, sourceID)
+	return sourceID, targetID, err
+}
+
+// Report membandingkan data aktif kedua backend untuk entityName (entity.SyncEntityUser/SyncEntityRepository)
+func (u *SyncUsecase) Report(ctx context.Context, entityName string) (*entity.SyncReport, error) {
+	ctx, span := u.tracer.Start(ctx, "SyncReport")
+	defer span.End()
+	span.SetAttributes(attribute.String("sync.entity", entityName))
+
+	var (
+		pgRecords, mongoRecords map[string]map[string]string
+		err                     error
+	)
+	switch entityName {
+	case entity.SyncEntityUser:
+		if pgRecords, err = u.userRecords(ctx, event.BackendPostgres); err == nil {
+			mongoRecords, err = u.userRecords(ctx, event.BackendMongo)
+		}
+	case entity.SyncEntityRepository:
+		if pgRecords, err = u.repositoryRecords(ctx, event.BackendPostgres); err == nil {
+			mongoRecords, err = u.repositoryRecords(ctx, event.BackendMongo)
+		}
+		if err == nil {
+			err = u.translateUserIDs(ctx, mongoRecords)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown sync entity %q", entity.ErrInvalidFilter, entityName)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "load failed")
+		return nil, err
+	}
+
+	mappings, err := u.mappings.List(ctx, entityName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "load mappings failed")
+		return nil, err
+	}
+
+	report := &entity.SyncReport{
+		Entity:            entityName,
+		GeneratedAt:       time.Now().UTC(),
+		PostgresCount:     len(pgRecords),
+		MongoCount:        len(mongoRecords),
+		MissingInMongo:    []string{},
+		MissingInPostgres: []string{},
+		Mismatched:        []entity.SyncMismatch{},
+	}
+
+	pairedPg, pairedMongo := map[string]bool{}, map[string]bool{}
+	for _, m := range mappings {
+		pg, okPg := pgRecords[m.PgID]
+		mg, okMongo := mongoRecords[m.MongoID]
+		if !okPg || !okMongo {
+			continue
+		}
+		pairedPg[m.PgID], pairedMongo[m.MongoID] = true, true
+
+		if fields := diffFields(pg, mg); len(fields) > 0 {
+			report.Mismatched = append(report.Mismatched, entity.SyncMismatch{PgID: m.PgID, MongoID: m.MongoID, Fields: fields})
+		} else {
+			report.InSync++
+		}
+	}
+	for id := range pgRecords {
+		if !pairedPg[id] {
+			report.MissingInMongo = append(report.MissingInMongo, id)
+		}
+	}
+	for id := range mongoRecords {
+		if !pairedMongo[id] {
+			report.MissingInPostgres = append(report.MissingInPostgres, id)
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("sync.in_sync", report.InSync),
+		attribute.Int("sync.mismatched", len(report.Mismatched)),
+	)
+	span.SetStatus(codes.Ok, "report generated")
+	return report, nil
+}
+
+// userRecords membaca semua user aktif sebagai id → field yang dibandingkan
+func (u *SyncUsecase) userRecords(ctx context.Context, backend string) (map[string]map[string]string, error) {
+	records := map[string]map[string]string{}
+	page := entity.PageRequest{Limit: entity.MaxPageLimit}
+	for {
+		result, err := u.users[backend].GetAll(ctx, entity.UserFilter{}, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range result.Data {
+			records[syncID(user.ID)] = map[string]string{"name": user.Name, "email": user.Email}
+		}
+		if result.NextCursor == "" {
+			return records, nil
+		}
+		page.Cursor = result.NextCursor
+	}
+}
+
+// repositoryRecords membaca semua repository aktif sebagai id → field yang dibandingkan
+func (u *SyncUsecase) repositoryRecords(ctx context.Context, backend string) (map[string]map[string]string, error) {
+	records := map[string]map[string]string{}
+	page := entity.PageRequest{Limit: entity.MaxPageLimit}
+	for {
+		result, err := u.repos[backend].GetAllRepositories(ctx, entity.RepositoryFilter{}, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range result.Data {
+			records[syncID(repo.ID)] = map[string]string{
+				"name":       repo.Name,
+				"url":        repo.URL,
+				"ai_enabled": fmt.Sprint(repo.AIEnabled),
+				"user_id":    syncID(repo.UserID),
+			}
+		}
+		if result.NextCursor == "" {
+			return records, nil
+		}
+		page.Cursor = result.NextCursor
+	}
+}
+
+// translateUserIDs mengganti user_id MongoDB dengan id PostgreSQL pasangannya agar bisa dibandingkan
+func (u *SyncUsecase) translateUserIDs(ctx context.Context, records map[string]map[string]string) error {
+	mappings, err := u.mappings.List(ctx, entity.SyncEntityUser)
+	if err != nil {
+		return err
+	}
+	pgIDs := make(map[string]string, len(mappings))
+	for _, m := range mappings {
+		pgIDs[m.MongoID] = m.PgID
+	}
+	for _, record := range records {
+		if pgID, ok := pgIDs[record["user_id"]]; ok {
+			record["user_id"] = pgID
+		}
+	}
+	return nil
+}
+
+// diffFields mengembalikan nama field yang nilainya berbeda
+func diffFields(a, b map[string]string) []string {
+	var fields []string
+	for field, value := range a {
+		if b[field] != value {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// syncDirection menentukan backend asal dan tujuan event. Event tanpa ekstensi backend
+// (pesan lama) dan event replika hasil sinkronisasi dilewati.
+func syncDirection(ce event.CloudEvent) (string, string, bool) {
+	if ce.IsReplica() {
+		return "", "", false
+	}
+	switch ce.Backend {
+	case event.BackendPostgres:
+		return event.BackendPostgres, event.BackendMongo, true
+	case event.BackendMongo:
+		return event.BackendMongo, event.BackendPostgres, true
+	}
+	return "", "", false
+}
+
+// newIDMapping memasangkan sourceID dan targetID sesuai backend asal
+func newIDMapping(entityName, source, sourceID, targetID string) entity.IDMapping {
+	if source == event.BackendPostgres {
+		return entity.IDMapping{Entity: entityName, PgID: sourceID, MongoID: targetID}
+	}
+	return entity.IDMapping{Entity: entityName, PgID: targetID, MongoID: sourceID}
+}
+
+// syncID mengubah id entitas (UUID, ObjectID atau string dari JSON) menjadi string
+func syncID(id interface{}) string {
+	switch v := id.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case uuid.UUID:
+		return v.String()
+	case primitive.ObjectID:
+		return v.Hex()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ignoreMissing menganggap entitas yang sudah tidak ada di backend tujuan sebagai sudah tersinkron
+func ignoreMissing(err error) error {
+	if isMissing(err) {
+		return nil
+	}
+	return err
+}
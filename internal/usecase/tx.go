@@ -0,0 +1,18 @@
+package usecase
+
+import "context"
+
+// Transactor menjalankan fn dalam satu transaksi database.
+// Repository yang dipanggil dengan ctx dari fn ikut transaksi tersebut,
+// termasuk penulisan event ke outbox, sehingga perubahan entitas dan event tersimpan atomik.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// withinTx menjalankan fn lewat tx, atau langsung jika backend tidak mendukung transaksi (tx nil)
+func withinTx(ctx context.Context, tx Transactor, fn func(ctx context.Context) error) error {
+	if tx == nil {
+		return fn(ctx)
+	}
+	return tx.WithinTx(ctx, fn)
+}
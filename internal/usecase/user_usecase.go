@@ -3,11 +3,13 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"golang-crud-clean-arch/internal/entity"
 	"golang-crud-clean-arch/internal/event"
 	"golang-crud-clean-arch/internal/notification"
+	"golang-crud-clean-arch/internal/patch"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/sony/gobreaker"
@@ -21,8 +23,11 @@ type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
 	GetByID(ctx context.Context, id interface{}) (*entity.User, error)
 	Update(ctx context.Context, user *entity.User) error
-	Delete(ctx context.Context, id interface{}) error
-	GetAll(ctx context.Context) ([]entity.User, error)
+	Delete(ctx context.Context, id interface{}, expectedVersion int64) error
+	Restore(ctx context.Context, id interface{}) (*entity.User, error)
+	Purge(ctx context.Context, id interface{}) error
+	ApplyBatch(ctx context.Context, ops []entity.BatchOperation[entity.User], atomic bool) ([]error, error)
+	GetAll(ctx context.Context, filter entity.UserFilter, page entity.PageRequest) (*entity.UserPage, error)
 	PublishEvent(ctx context.Context, eventType string, data interface{}) error
 }
 
@@ -32,9 +37,12 @@ type UserUsecase struct {
 	cb        *gobreaker.CircuitBreaker
 	tracer    trace.Tracer
 	publisher event.EventPublisher
+	tx        Transactor // nil jika backend tidak mendukung transaksi (MongoDB)
 }
 
-func NewUserUsecase(repo UserRepository, redis *redis.Client, publisher event.EventPublisher) *UserUsecase {
+// NewUserUsecase membuat UserUsecase. tx boleh nil; jika diisi, perubahan user dan
+// event-nya dijalankan dalam satu transaksi (transactional outbox).
+func NewUserUsecase(repo UserRepository, redis *redis.Client, publisher event.EventPublisher, tx Transactor) *UserUsecase {
 	cbSettings := gobreaker.Settings{
 		Name:        "UserGetAllBreaker",
 		MaxRequests: 1,
@@ -43,6 +51,10 @@ func NewUserUsecase(repo UserRepository, redis *redis.Client, publisher event.Ev
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
 			return counts.ConsecutiveFailures >= 3
 		},
+		// Cursor/filter yang tidak valid adalah kesalahan client, bukan kegagalan service
+		IsSuccessful: func(err error) bool {
+			return err == nil || isClientError(err)
+		},
 	}
 
 	return &UserUsecase{
@@ -51,6 +63,7 @@ func NewUserUsecase(repo UserRepository, redis *redis.Client, publisher event.Ev
 		cb:        gobreaker.NewCircuitBreaker(cbSettings),
 		tracer:    otel.Tracer("user-usecase"),
 		publisher: publisher, // tambahkan publisher di sini
+		tx:        tx,
 	}
 }
 
@@ -66,22 +79,27 @@ func (u *UserUsecase) CreateUser(ctx context.Context, user *entity.User) error {
 		return err
 	}
 
-	// Call the repository to create the user and handle Kafka event publishing
-	err := u.repo.Create(ctx, user)
+	// Create user dan event-nya dalam satu transaksi
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, user); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Create failed")
+			return err
+		}
+
+		// Publish event to Kafka
+		eventData := entity.Event{
+			Type: "user.created",
+			Data: user,
+		}
+		if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka event publish failed")
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Create failed")
-		return err
-	}
-
-	// Publish event to Kafka
-	eventData := entity.Event{
-		Type: "user.created",
-		Data: user,
-	}
-	if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Kafka event publish failed")
 		return err
 	}
 
@@ -125,29 +143,115 @@ func (u *UserUsecase) UpdateUser(ctx context.Context, user *entity.User) error {
 		return err
 	}
 
-	err := u.repo.Update(ctx, user)
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, user); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Update failed")
+			return err
+		}
+
+		// Publish event to Kafka
+		eventData := entity.Event{
+			Type: "user.updated",
+			Data: user,
+		}
+		if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka event publish failed")
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Update failed")
 		return err
 	}
 
-	// Publish event to Kafka
-	eventData := entity.Event{
-		Type: "user.updated",
-		Data: user,
+	span.SetStatus(codes.Ok, "User updated")
+	return nil
+}
+
+// userReadOnlyFields tidak boleh diubah lewat PATCH
+var userReadOnlyFields = []string{"id", "created_at", "updated_at", "version", "deleted_at"}
+
+// PatchUser menerapkan merge patch / JSON patch ke user yang tersimpan, memvalidasi ulang hasilnya,
+// lalu menyimpan dengan pengecekan versi. expectedVersion > 0 berasal dari If-Match.
+func (u *UserUsecase) PatchUser(ctx context.Context, id interface{}, p patch.Patch, expectedVersion int64) (*entity.User, error) {
+	ctx, span := u.tracer.Start(ctx, "PatchUser")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("operation", "patch_user"),
+		attribute.String("user.id", fmt.Sprintf("%v", id)),
+	)
+
+	current, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Get failed")
+		return nil, notFoundOr(err)
 	}
-	if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		span.SetStatus(codes.Error, "Version mismatch")
+		return nil, entity.ErrVersionConflict
+	}
+
+	var patched entity.User
+	if err := patch.ApplyTo(current, p, &patched, userReadOnlyFields...); err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "Kafka event publish failed")
-		return err
+		span.SetStatus(codes.Error, "Patch failed")
+		return nil, err
+	}
+	if err := patched.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Validation failed")
+		return nil, fmt.Errorf("%w: %v", entity.ErrValidation, err)
 	}
 
-	span.SetStatus(codes.Ok, "User updated")
-	return nil
+	changes, err := patch.Changes(current, &patched, "updated_at", "version")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Diff failed")
+		return nil, err
+	}
+	if len(changes) == 0 {
+		// Tidak ada yang berubah: tidak perlu menulis ke database maupun mengirim event
+		span.SetStatus(codes.Ok, "User unchanged")
+		return current, nil
+	}
+
+	// Simpan dengan versi yang dibaca agar perubahan lain di antara GET dan UPDATE terdeteksi
+	patched.ID = current.ID
+	patched.Version = current.Version
+	err = withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, &patched); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Update failed")
+			return err
+		}
+
+		// Publish event to Kafka
+		eventData := entity.Event{
+			Type: "user.updated",
+			Data: entity.UserChanges{User: &patched, ChangedFields: changes},
+		}
+		if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka event publish failed")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("user.changed_fields", len(changes)))
+	span.SetStatus(codes.Ok, "User patched")
+	return &patched, nil
 }
 
-func (u *UserUsecase) DeleteUser(ctx context.Context, id interface{}) error {
+// DeleteUser melakukan soft delete; expectedVersion > 0 mengaktifkan pengecekan versi (If-Match)
+func (u *UserUsecase) DeleteUser(ctx context.Context, id interface{}, expectedVersion int64) error {
 	ctx, span := u.tracer.Start(ctx, "DeleteUser")
 	defer span.End()
 
@@ -156,38 +260,182 @@ func (u *UserUsecase) DeleteUser(ctx context.Context, id interface{}) error {
 		attribute.String("user.id", fmt.Sprintf("%v", id)),
 	)
 
-	err := u.repo.Delete(ctx, id)
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, id, expectedVersion); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Delete failed")
+			return err
+		}
+
+		// Publish event to Kafka
+		eventData := entity.Event{
+			Type: "user.deleted",
+			Data: map[string]interface{}{"id": id},
+		}
+		if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka event publish failed")
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Delete failed")
 		return err
 	}
 
-	// Publish event to Kafka
-	eventData := entity.Event{
-		Type: "user.deleted",
-		Data: map[string]interface{}{"id": id},
+	span.SetStatus(codes.Ok, "User deleted")
+	return nil
+}
+
+// BatchUsers menjalankan banyak operasi create/update/delete sekaligus dan mengembalikan hasil per operasi.
+// Event untuk operasi yang berhasil dipublikasikan dalam satu batch write.
+func (u *UserUsecase) BatchUsers(ctx context.Context, req entity.UserBatchRequest) (*entity.BatchResult, error) {
+	ctx, span := u.tracer.Start(ctx, "BatchUsers")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("operation", "batch_users"),
+		attribute.Int("batch.size", len(req.Operations)),
+		attribute.Bool("batch.atomic", req.Atomic),
+	)
+
+	var (
+		result    *entity.BatchResult
+		published = true
+	)
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		var err error
+		result, err = runBatch(req, (*entity.User).Validate, func(ops []entity.BatchOperation[entity.User]) ([]error, error) {
+			return u.repo.ApplyBatch(ctx, ops, req.Atomic)
+		})
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Batch failed")
+			return err
+		}
+
+		// Publish event to Kafka
+		if err := event.PublishAll(ctx, u.publisher, "user-events", batchEvents("user", result)); err != nil {
+			log.Printf("❌ Failed to publish batch events: %v", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka event publish failed")
+			// Dengan outbox, event gagal disimpan berarti batch ikut dibatalkan
+			if u.tx != nil {
+				return err
+			}
+			published = false
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !published {
+		return result, nil
+	}
+
+	span.SetAttributes(
+		attribute.Int("batch.succeeded", result.Succeeded),
+		attribute.Int("batch.failed", result.Failed),
+	)
+	span.SetStatus(codes.Ok, "Batch applied")
+	return result, nil
+}
+
+func (u *UserUsecase) RestoreUser(ctx context.Context, id interface{}) (*entity.User, error) {
+	ctx, span := u.tracer.Start(ctx, "RestoreUser")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("operation", "restore_user"),
+		attribute.String("user.id", fmt.Sprintf("%v", id)),
+	)
+
+	var user *entity.User
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		var err error
+		if user, err = u.repo.Restore(ctx, id); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Restore failed")
+			return err
+		}
+
+		// Publish event to Kafka
+		eventData := entity.Event{
+			Type: "user.restored",
+			Data: user,
+		}
+		if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka event publish failed")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Kafka event publish failed")
+
+	span.SetStatus(codes.Ok, "User restored")
+	return user, nil
+}
+
+func (u *UserUsecase) PurgeUser(ctx context.Context, id interface{}) error {
+	ctx, span := u.tracer.Start(ctx, "PurgeUser")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("operation", "purge_user"),
+		attribute.String("user.id", fmt.Sprintf("%v", id)),
+	)
+
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := u.repo.Purge(ctx, id); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Purge failed")
+			return err
+		}
+
+		// Publish event to Kafka
+		eventData := entity.Event{
+			Type: "user.purged",
+			Data: map[string]interface{}{"id": id},
+		}
+		if err := u.repo.PublishEvent(ctx, eventData.Type, eventData.Data); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka event publish failed")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
-	span.SetStatus(codes.Ok, "User deleted")
+	span.SetStatus(codes.Ok, "User purged")
 	return nil
 }
 
-func (u *UserUsecase) GetAllUsers(ctx context.Context) ([]entity.User, error) {
+func (u *UserUsecase) GetAllUsers(ctx context.Context, filter entity.UserFilter, page entity.PageRequest) (*entity.UserPage, error) {
 	ctx, span := u.tracer.Start(ctx, "GetAllUsers")
 	defer span.End()
 
-	span.SetAttributes(attribute.String("operation", "get_all_users"))
+	span.SetAttributes(
+		attribute.String("operation", "get_all_users"),
+		attribute.Int("page.limit", page.Limit),
+		attribute.Bool("page.has_cursor", page.Cursor != ""),
+		attribute.String("filter", filter.String()),
+	)
 
 	result, err := u.cb.Execute(func() (interface{}, error) {
-		return u.repo.GetAll(ctx)
+		return u.repo.GetAll(ctx, filter, page)
 	})
 
+	if isClientError(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request")
+		return nil, err
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Circuit Breaker triggered")
@@ -198,13 +446,13 @@ func (u *UserUsecase) GetAllUsers(ctx context.Context) ([]entity.User, error) {
 		return nil, fmt.Errorf("user service unavailable: %w", err)
 	}
 
-	users, ok := result.([]entity.User)
+	users, ok := result.(*entity.UserPage)
 	if !ok {
 		span.SetStatus(codes.Error, "Type assertion failed")
 		return nil, fmt.Errorf("user service: type assertion failed")
 	}
 
-	span.SetAttributes(attribute.Int("user.count", len(users)))
+	span.SetAttributes(attribute.Int("user.count", len(users.Data)))
 	span.SetStatus(codes.Ok, "Users fetched")
 	return users, nil
 }
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"golang-crud-clean-arch/config"
+)
+
+// newWebhookClient membuat HTTP client untuk pengiriman webhook. URL langganan ditentukan pengguna,
+// jadi alamat tujuan diperiksa saat dial (setelah DNS di-resolve) agar webhook tidak bisa dipakai
+// untuk menjangkau jaringan internal (SSRF). Redirect tidak diikuti dan dianggap gagal.
+func newWebhookClient(cfg config.WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = webhookDialControl
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Tanpa proxy: lewat proxy, yang di-dial adalah alamat proxy dan tujuan sebenarnya tidak diperiksa
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// blockedWebhookPrefixes adalah rentang non-publik yang tidak tercakup method netip.Addr:
+// jaringan "this host" dan carrier-grade NAT (RFC 6598)
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// webhookDialControl menolak koneksi ke alamat loopback, link-local, private, unspecified,
+// multicast dan carrier-grade NAT
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() ||
+		ip.IsUnspecified() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("webhook destination %s is not a public address", ip)
+	}
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("webhook destination %s is not a public address", ip)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,427 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-crud-clean-arch/config"
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/event"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header request webhook. Penerima memverifikasi X-Webhook-Signature dengan
+// HMAC-SHA256(secret, "{X-Webhook-Timestamp}.{body}"), lihat SignWebhook.
+const (
+	HeaderWebhookID        = "X-Webhook-ID" // id CloudEvent, sama untuk setiap percobaan
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp" // detik Unix saat request dikirim
+	HeaderWebhookSignature = "X-Webhook-Signature" // "sha256=" + hex HMAC
+)
+
+// WebhookStore menyimpan langganan webhook dan log pengirimannya, diimplementasikan oleh
+// repository.WebhookRepositoryPostgres
+type WebhookStore interface {
+	Create(ctx context.Context, s *entity.WebhookSubscription) error
+	GetByID(ctx context.Context, id string) (*entity.WebhookSubscription, error)
+	List(ctx context.Context, activeOnly bool) ([]entity.WebhookSubscription, error)
+	Update(ctx context.Context, s *entity.WebhookSubscription) error
+	Delete(ctx context.Context, id string) error
+	RecordSuccess(ctx context.Context, id string) error
+	RecordFailure(ctx context.Context, id string, disableAfter int, reason string) (bool, error)
+	SaveDelivery(ctx context.Context, d *entity.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]entity.WebhookDelivery, error)
+	ScheduleRetry(ctx context.Context, retry *entity.WebhookRetry, delay time.Duration) error
+	ClaimRetries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookRetry, error)
+	RescheduleRetry(ctx context.Context, id int64, attempt int, delay time.Duration, reason string) error
+	DeleteRetry(ctx context.Context, id int64) error
+}
+
+// WebhookUsecase mengelola langganan webhook dan mengirim event user/repository ke setiap
+// langganan aktif yang cocok. Consumer hanya mencoba sekali; pengiriman yang gagal disimpan di
+// antrian retry dan dikirim ulang oleh RunRetries dengan backoff eksponensial. Langganan yang
+// gagal menerima WEBHOOK_DISABLE_AFTER event berturut-turut dinonaktifkan otomatis.
+type WebhookUsecase struct {
+	store  WebhookStore
+	client *http.Client
+	cfg    config.WebhookConfig
+	tracer trace.Tracer
+}
+
+// NewWebhookUsecase membuat WebhookUsecase dengan HTTP client ber-timeout WEBHOOK_TIMEOUT
+// yang hanya terhubung ke alamat publik (lihat newWebhookClient)
+func NewWebhookUsecase(store WebhookStore, cfg config.WebhookConfig) *WebhookUsecase {
+	return &WebhookUsecase{
+		store:  store,
+		client: newWebhookClient(cfg),
+		cfg:    cfg,
+		tracer: otel.Tracer("webhook-usecase"),
+	}
+}
+
+// Create memvalidasi dan menyimpan langganan baru. Secret dibuat acak jika kosong.
+func (u *WebhookUsecase) Create(ctx context.Context, s *entity.WebhookSubscription) error {
+	if err := normalizeWebhook(s); err != nil {
+		return err
+	}
+	if s.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return err
+		}
+		s.Secret = secret
+	}
+	return u.store.Create(ctx, s)
+}
+
+// Get mengambil satu langganan
+func (u *WebhookUsecase) Get(ctx context.Context, id string) (*entity.WebhookSubscription, error) {
+	return u.store.GetByID(ctx, id)
+}
+
+// List mengembalikan semua langganan, termasuk yang nonaktif
+func (u *WebhookUsecase) List(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	return u.store.List(ctx, false)
+}
+
+// Update mengganti url, event_types dan active langganan. Secret lama dipertahankan jika kosong;
+// active=true mengaktifkan kembali langganan yang dinonaktifkan otomatis.
+func (u *WebhookUsecase) Update(ctx context.Context, s *entity.WebhookSubscription) error {
+	if err := normalizeWebhook(s); err != nil {
+		return err
+	}
+	if s.Secret == "" {
+		current, err := u.store.GetByID(ctx, s.ID)
+		if err != nil {
+			return err
+		}
+		s.Secret = current.Secret
+	}
+	return u.store.Update(ctx, s)
+}
+
+// Delete menghapus langganan beserta log pengirimannya
+func (u *WebhookUsecase) Delete(ctx context.Context, id string) error {
+	return u.store.Delete(ctx, id)
+}
+
+// Deliveries mengembalikan log pengiriman terbaru untuk satu langganan
+func (u *WebhookUsecase) Deliveries(ctx context.Context, id string, limit int) ([]entity.WebhookDelivery, error) {
+	if _, err := u.store.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.store.ListDeliveries(ctx, id, limit)
+}
+
+// Dispatch mengirim ce ke semua langganan aktif yang cocok secara paralel, masing-masing satu kali,
+// lalu menunggu sampai semuanya selesai. Pengiriman yang gagal dijadwalkan ulang lewat antrian retry,
+// sehingga consumer tidak tertahan oleh endpoint yang lambat atau mati dan offset langsung di-commit.
+// Error hanya dikembalikan jika daftar langganan tidak bisa dibaca atau ctx selesai sebelum
+// pengiriman selesai.
+func (u *WebhookUsecase) Dispatch(ctx context.Context, ce event.CloudEvent) error {
+	// Event hasil sync worker adalah salinan perubahan yang sudah dikirim dari backend asalnya
+	if ce.IsReplica() {
+		return nil
+	}
+
+	subscriptions, err := u.store.List(ctx, true)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	body, err := json.Marshal(ce)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cloudevent: %w", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, s := range subscriptions {
+		if !event.MatchType(s.EventTypes, ce.Type) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retry := entity.WebhookRetry{SubscriptionID: s.ID, EventID: ce.ID, EventType: ce.Type, Payload: body, Attempt: 1}
+			if err := u.finish(ctx, s, &retry, u.attempt(ctx, s, retry)); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Saat shutdown event belum selesai dikirim, kembalikan error agar diproses ulang setelah restart
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// Retry yang gagal dijadwalkan dikembalikan ke consumer, yang meneruskan event ke retry topic
+	// dan DLQ group webhook. Langganan lain ikut menerima event itu lagi dengan id yang sama.
+	return errors.Join(errs...)
+}
+
+// RunRetries mengirim ulang pengiriman yang jatuh tempo setiap WEBHOOK_RETRY_INTERVAL sampai ctx selesai
+func (u *WebhookUsecase) RunRetries(ctx context.Context) {
+	ticker := time.NewTicker(u.cfg.RetryInterval)
+	defer ticker.Stop()
+
+	log.Printf("🔁 Webhook retry worker started (interval %s, batch %d)", u.cfg.RetryInterval, u.cfg.RetryBatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("🛑 Webhook retry worker stopped")
+			return
+		case <-ticker.C:
+			if err := u.retryDue(ctx); err != nil {
+				log.Printf("❌ Webhook retry error: %v", err)
+			}
+		}
+	}
+}
+
+// retryDue mengklaim satu batch retry yang jatuh tempo dan mengirimnya secara paralel.
+// Klaim berlaku dua kali WEBHOOK_TIMEOUT; jika instance mati di tengah jalan, retry diambil
+// instance lain setelah klaimnya habis.
+func (u *WebhookUsecase) retryDue(ctx context.Context) error {
+	lease := 2 * u.cfg.Timeout
+	if lease <= 0 {
+		lease = time.Minute
+	}
+	retries, err := u.store.ClaimRetries(ctx, u.cfg.RetryBatchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, retry := range retries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u.retry(ctx, retry)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// retry mengirim ulang satu pengiriman. Retry milik langganan yang sudah dihapus atau
+// dinonaktifkan dibuang.
+func (u *WebhookUsecase) retry(ctx context.Context, retry entity.WebhookRetry) {
+	s, err := u.store.GetByID(ctx, retry.SubscriptionID)
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		log.Printf("⚠️ Failed to load webhook subscription %s for retry: %v", retry.SubscriptionID, err)
+		return
+	}
+	if s == nil || !s.Active {
+		if err := u.store.DeleteRetry(ctx, retry.ID); err != nil {
+			log.Printf("⚠️ Failed to drop webhook retry %d: %v", retry.ID, err)
+		}
+		return
+	}
+	if err := u.finish(ctx, *s, &retry, u.attempt(ctx, *s, retry)); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// attempt melakukan satu percobaan pengiriman retry.Attempt dan mencatatnya di log pengiriman
+func (u *WebhookUsecase) attempt(ctx context.Context, s entity.WebhookSubscription, retry entity.WebhookRetry) error {
+	ctx, span := u.tracer.Start(ctx, "DeliverWebhook", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("webhook.subscription_id", s.ID),
+		attribute.String("cloudevents.event_id", retry.EventID),
+		attribute.String("cloudevents.event_type", retry.EventType),
+		attribute.Int("webhook.attempt", retry.Attempt),
+	)
+
+	start := time.Now()
+	status, err := u.post(ctx, s, retry.EventID, retry.EventType, retry.Payload)
+	delivery := &entity.WebhookDelivery{
+		SubscriptionID: s.ID,
+		EventID:        retry.EventID,
+		EventType:      retry.EventType,
+		Attempt:        retry.Attempt,
+		StatusCode:     status,
+		Success:        err == nil,
+		DurationMs:     time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		msg := err.Error()
+		delivery.Error = &msg
+	}
+	if saveErr := u.store.SaveDelivery(ctx, delivery); saveErr != nil {
+		log.Printf("⚠️ Failed to log webhook delivery %s → %s: %v", retry.EventID, s.ID, saveErr)
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "delivery failed")
+		log.Printf("⚠️ Webhook %s to %s failed (attempt %d/%d): %v", retry.EventID, s.URL, retry.Attempt, u.cfg.MaxAttempts, err)
+		return err
+	}
+	span.SetStatus(codes.Ok, "delivered")
+	log.Printf("📨 Webhook %s delivered to %s (attempt %d)", retry.EventID, s.URL, retry.Attempt)
+	return nil
+}
+
+// finish memproses hasil satu percobaan: reset hitungan kegagalan jika berhasil, jadwalkan
+// percobaan berikutnya jika masih tersisa, atau catat kegagalan langganan jika MaxAttempts habis.
+// retry.ID nol berarti percobaan pertama dari consumer yang belum punya baris retry.
+// Error dikembalikan jika percobaan berikutnya tidak bisa dijadwalkan: untuk percobaan pertama
+// consumer harus memproses ulang event, baris retry yang sudah ada diambil lagi setelah klaimnya habis.
+func (u *WebhookUsecase) finish(ctx context.Context, s entity.WebhookSubscription, retry *entity.WebhookRetry, err error) error {
+	if err == nil {
+		if err := u.store.RecordSuccess(ctx, s.ID); err != nil {
+			log.Printf("⚠️ Failed to reset webhook failures for %s: %v", s.ID, err)
+		}
+		u.dropRetry(ctx, retry)
+		return nil
+	}
+	// Saat shutdown: event diproses ulang oleh consumer atau retry diambil lagi setelah klaimnya habis
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if retry.Attempt < u.cfg.MaxAttempts {
+		delay := webhookBackoff(u.cfg, retry.Attempt)
+		reason := err.Error()
+		var scheduleErr error
+		if retry.ID == 0 {
+			next := *retry
+			next.Attempt++
+			next.LastError = &reason
+			scheduleErr = u.store.ScheduleRetry(ctx, &next, delay)
+		} else {
+			scheduleErr = u.store.RescheduleRetry(ctx, retry.ID, retry.Attempt+1, delay, reason)
+		}
+		if scheduleErr == nil {
+			log.Printf("⏳ Webhook %s to %s scheduled for attempt %d in %s", retry.EventID, s.URL, retry.Attempt+1, delay)
+			return nil
+		}
+		return fmt.Errorf("failed to schedule webhook retry %s → %s: %w", retry.EventID, s.ID, scheduleErr)
+	}
+
+	u.dropRetry(ctx, retry)
+	disabled, recordErr := u.store.RecordFailure(ctx, s.ID, u.cfg.DisableAfter, err.Error())
+	if recordErr != nil {
+		log.Printf("⚠️ Failed to record webhook failure for %s: %v", s.ID, recordErr)
+		return nil
+	}
+	if disabled {
+		log.Printf("🚫 Webhook subscription %s (%s) disabled after %d consecutive failed events", s.ID, s.URL, u.cfg.DisableAfter)
+	}
+	return nil
+}
+
+// dropRetry menghapus baris retry yang sudah selesai, jika ada
+func (u *WebhookUsecase) dropRetry(ctx context.Context, retry *entity.WebhookRetry) {
+	if retry.ID == 0 {
+		return
+	}
+	if err := u.store.DeleteRetry(ctx, retry.ID); err != nil {
+		log.Printf("⚠️ Failed to delete webhook retry %d: %v", retry.ID, err)
+	}
+}
+
+// post mengirim satu request bertanda tangan. Status code nil jika tidak ada respons.
+func (u *WebhookUsecase) post(ctx context.Context, s entity.WebhookSubscription, eventID, eventType string, body []byte) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", event.ContentTypeCloudEventsJSON)
+	req.Header.Set("User-Agent", "golang-crud-clean-arch-webhook")
+	req.Header.Set(HeaderWebhookID, eventID)
+	req.Header.Set(HeaderWebhookEvent, eventType)
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderWebhookSignature, "sha256="+SignWebhook(s.Secret, timestamp, body))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := resp.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, fmt.Errorf("unexpected status %d", status)
+	}
+	return &status, nil
+}
+
+// SignWebhook menghitung hex HMAC-SHA256 dari "{timestamp}.{body}" dengan secret langganan.
+// Timestamp ikut ditandatangani agar penerima bisa menolak request lama yang dikirim ulang.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// normalizeWebhook memvalidasi url dan merapikan event_types
+func normalizeWebhook(s *entity.WebhookSubscription) error {
+	s.URL = strings.TrimSpace(s.URL)
+	target, err := url.Parse(s.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", entity.ErrInvalidWebhook)
+	}
+
+	eventTypes := make([]string, 0, len(s.EventTypes))
+	for _, t := range s.EventTypes {
+		t = strings.TrimSpace(t)
+		if t == "" || strings.ContainsAny(t, " \t") {
+			return fmt.Errorf("%w: invalid event type %q", entity.ErrInvalidWebhook, t)
+		}
+		eventTypes = append(eventTypes, t)
+	}
+	s.EventTypes = eventTypes
+	return nil
+}
+
+// newWebhookSecret membuat secret acak 32 byte (hex)
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// webhookBackoff menghitung jeda setelah percobaan ke-attempt (mulai dari 1)
+func webhookBackoff(cfg config.WebhookConfig, attempt int) time.Duration {
+	d := cfg.InitialBackoff
+	for i := 1; i < attempt && d < cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if cfg.MaxBackoff > 0 && d > cfg.MaxBackoff {
+		return cfg.MaxBackoff
+	}
+	return d
+}
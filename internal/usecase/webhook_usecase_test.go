@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-crud-clean-arch/config"
+	"golang-crud-clean-arch/internal/entity"
+	"golang-crud-clean-arch/internal/event"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"id":"e1","type":"user.created"}`)
+	got := SignWebhook("s3cret", 1700000000, body)
+
+	// Penerima menghitung ulang HMAC-SHA256 dari "{timestamp}.{body}"
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	if want := hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Fatalf("SignWebhook = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+	}{
+		{name: "different secret", secret: "other", timestamp: 1700000000, body: body},
+		{name: "different timestamp", secret: "s3cret", timestamp: 1700000001, body: body},
+		{name: "different body", secret: "s3cret", timestamp: 1700000000, body: []byte(`{}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if SignWebhook(tt.secret, tt.timestamp, tt.body) == got {
+				t.Fatal("signature must change")
+			}
+		})
+	}
+}
+
+func TestWebhookDialControl(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"127.0.0.1:80", false},
+		{"[::1]:443", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"10.1.2.3:443", false},
+		{"172.16.0.1:443", false},
+		{"192.168.1.10:8080", false},
+		{"[fd00::1]:443", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"0.0.0.0:80", false},
+		{"0.1.2.3:80", false},
+		{"[::]:80", false},
+		{"100.64.0.1:443", false},
+		{"100.127.255.254:443", false},
+		{"224.0.0.1:80", false},
+		{"100.128.0.1:443", true},
+		{"8.8.8.8:443", true},
+		{"[2606:4700:4700::1111]:443", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := webhookDialControl("tcp", tt.address, nil)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected %s to be allowed, got %v", tt.address, err)
+			}
+			if !tt.allowed && err == nil {
+				t.Fatalf("expected %s to be rejected", tt.address)
+			}
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cfg := config.WebhookConfig{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := webhookBackoff(cfg, i+1); got != w {
+			t.Errorf("webhookBackoff(attempt %d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+// dispatchStore adalah WebhookStore palsu untuk Dispatch; method lain tidak dipanggil
+type dispatchStore struct {
+	WebhookStore
+	subscriptions []entity.WebhookSubscription
+	scheduleErr   error
+	scheduled     int
+	failures      int
+}
+
+func (s *dispatchStore) List(ctx context.Context, activeOnly bool) ([]entity.WebhookSubscription, error) {
+	return s.subscriptions, nil
+}
+
+func (s *dispatchStore) SaveDelivery(ctx context.Context, d *entity.WebhookDelivery) error {
+	return nil
+}
+
+func (s *dispatchStore) ScheduleRetry(ctx context.Context, retry *entity.WebhookRetry, delay time.Duration) error {
+	s.scheduled++
+	return s.scheduleErr
+}
+
+func (s *dispatchStore) RecordFailure(ctx context.Context, id string, disableAfter int, reason string) (bool, error) {
+	s.failures++
+	return false, nil
+}
+
+func TestWebhookDispatchScheduleFailure(t *testing.T) {
+	errStore := errors.New("database unavailable")
+
+	tests := []struct {
+		name        string
+		scheduleErr error
+		wantErr     error
+	}{
+		{name: "retry scheduled", scheduleErr: nil, wantErr: nil},
+		{name: "retry not scheduled", scheduleErr: errStore, wantErr: errStore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Loopback diblokir webhookDialControl, jadi percobaan pertama selalu gagal
+			store := &dispatchStore{
+				subscriptions: []entity.WebhookSubscription{{ID: "s1", URL: "http://127.0.0.1:1/hook", Secret: "x", Active: true}},
+				scheduleErr:   tt.scheduleErr,
+			}
+			u := NewWebhookUsecase(store, config.WebhookConfig{Timeout: time.Second, MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute, DisableAfter: 10})
+
+			ce := event.CloudEvent{SpecVersion: "1.0", ID: "e1", Type: "user.created", Source: "/test"}
+			err := u.Dispatch(context.Background(), ce)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Dispatch() error = %v, want %v", err, tt.wantErr)
+			}
+			if store.scheduled != 1 {
+				t.Errorf("ScheduleRetry called %d times, want 1", store.scheduled)
+			}
+			if store.failures != 0 {
+				t.Errorf("RecordFailure called %d times, want 0", store.failures)
+			}
+		})
+	}
+}